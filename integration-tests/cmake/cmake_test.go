@@ -366,7 +366,7 @@ func createAndExtractArtifactArchive(t *testing.T, dir string, cifuzz string) st
 	require.NoError(t, err)
 	archiveFile, err := os.Open(archivePath)
 	require.NoError(t, err)
-	err = artifact.ExtractArchiveForTestsOnly(archiveFile, archiveDir)
+	err = artifact.ExtractArchive(archiveFile, archiveDir)
 	require.NoError(t, err)
 	return archiveDir
 }
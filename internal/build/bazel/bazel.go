@@ -0,0 +1,435 @@
+package bazel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The build options with which we configure Bazel's C/C++ toolchain to
+// build the fuzz tests with libFuzzer. They are defined as configs in
+// the bazelrc file written by the builder, which the workspace's
+// .bazelrc must import (see BazelrcImport). Passing a bazelrc file via
+// the --bazelrc option instead would replace the user's ~/.bazelrc and
+// restart the Bazel server whenever it changes.
+// Note: Keep in sync with the flags in internal/build/other/other.go.
+var commonFlags = []string{
+	// Keep debug symbols and do optimizations, like CMake's
+	// RelWithDebInfo build type
+	"--compilation_mode=opt",
+	"--copt=-g",
+	"--strip=never",
+	// To get good stack frames for better debugging
+	"--copt=-fno-omit-frame-pointer",
+	// Conventional macro to conditionally compile out fuzzer road blocks
+	// See https://llvm.org/docs/LibFuzzer.html#fuzzer-friendly-build-mode
+	"--copt=-DFUZZING_BUILD_MODE_UNSAFE_FOR_PRODUCTION",
+	// Link the fuzz tests statically, so that they don't depend on the
+	// shared libraries in the runfiles, which are not accessible in the
+	// sandbox
+	"--dynamic_mode=off",
+	// Compile with edge coverage and compare instrumentation and link
+	// the libFuzzer runtime
+	"--copt=-fsanitize=fuzzer-no-link",
+	"--linkopt=-fsanitize=fuzzer",
+}
+
+var sanitizerFlags = map[string][]string{
+	"address": {
+		"--copt=-fsanitize=address",
+		"--linkopt=-fsanitize=address",
+		// To support recovering from ASan findings
+		"--copt=-fsanitize-recover=address",
+		// Use additional error detectors for use-after-scope bugs
+		"--copt=-fsanitize-address-use-after-scope",
+	},
+	"undefined": {
+		"--copt=-fsanitize=undefined",
+		"--linkopt=-fsanitize=undefined",
+		// Bazel links C++ code with clang instead of clang++, which
+		// doesn't link the C++ part of the UBSan runtime, see
+		// https://github.com/bazelbuild/bazel/issues/11122#issuecomment-896613570
+		"--linkopt=-fsanitize-link-c++-runtime",
+	},
+	"memory": {
+		"--copt=-fsanitize=memory",
+		"--linkopt=-fsanitize=memory",
+		// Report where uninitialized values were created
+		"--copt=-fsanitize-memory-track-origins",
+	},
+	"thread": {
+		"--copt=-fsanitize=thread",
+		"--linkopt=-fsanitize=thread",
+	},
+	"leak": {
+		"--copt=-fsanitize=leak",
+		"--linkopt=-fsanitize=leak",
+	},
+}
+
+// The path of the bazelrc file which defines the configs used by
+// cifuzz, relative to the workspace
+var bazelrcPath = filepath.Join(".cifuzz-build", "bazel", "cifuzz.bazelrc")
+
+// BazelrcImport is the line which must be added to the .bazelrc of the
+// workspace to make the configs used by cifuzz available
+const BazelrcImport = "try-import %workspace%/.cifuzz-build/bazel/cifuzz.bazelrc"
+
+// The query which lists the fuzz tests of the project, i.e. the C/C++
+// binaries and tests which have "fuzz" in their name or tags
+const fuzzTestsQuery = `let targets = kind("cc_(binary|test) rule", //...) in ` +
+	`attr(name, "fuzz", $targets) + attr(tags, "fuzz", $targets)`
+
+// The Starlark expression which is evaluated by cquery to print the
+// information about a fuzz test which we need to run it as a single
+// line of JSON. The paths are relative to the execution root.
+const targetInfoExpr = `json.encode({` +
+	`"package": target.label.package, ` +
+	`"name": target.label.name, ` +
+	`"executable": target.files_to_run.executable.path if target.files_to_run.executable else "", ` +
+	`"runfiles": [f.path for f in target.default_runfiles.files.to_list()]` +
+	`})`
+
+type targetInfo struct {
+	Package    string   `json:"package"`
+	Name       string   `json:"name"`
+	Executable string   `json:"executable"`
+	Runfiles   []string `json:"runfiles"`
+}
+
+type BuilderOptions struct {
+	ProjectDir string
+	Engine     string
+	Sanitizers []string
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	// Check that the project dir is set
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	// Check that the project dir exists and can be accessed
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if opts.Engine != "libfuzzer" {
+		return errors.Errorf("Engine %q is not supported for Bazel projects", opts.Engine)
+	}
+	for _, sanitizer := range opts.Sanitizers {
+		if _, ok := sanitizerFlags[sanitizer]; !ok {
+			return errors.Errorf("Sanitizer %q is not supported for Bazel projects", sanitizer)
+		}
+	}
+	return nil
+}
+
+// Builder builds C/C++ fuzz tests, i.e. cc_binary or cc_test targets
+// which define LLVMFuzzerTestOneInput (like the cc_fuzz_test rule of
+// rules_fuzzing does), in a Bazel workspace.
+type Builder struct {
+	*BuilderOptions
+	env []string
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Builder{BuilderOptions: opts}
+
+	err = b.writeBazelrc()
+	if err != nil {
+		return nil, err
+	}
+
+	// Bazel's auto-configured C/C++ toolchain uses the compiler
+	// specified via CC, which is set to clang here
+	b.env, err = build.CommonBuildEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Flags returns the build options which are passed to Bazel to build
+// the fuzz tests with the engine and sanitizers of the builder.
+func (b *Builder) Flags() []string {
+	return []string{"--config=" + configName(b.Engine, b.Sanitizers)}
+}
+
+// configName returns the name of the bazelrc config which builds the
+// fuzz tests with the engine and the sanitizers, which are sorted to
+// not depend on the order in which they were specified
+func configName(engine string, sanitizers []string) string {
+	sorted := append([]string{}, sanitizers...)
+	sort.Strings(sorted)
+	sanitizersSegment := strings.Join(sorted, "-")
+	if sanitizersSegment == "" {
+		sanitizersSegment = "none"
+	}
+	return "cifuzz-" + engine + "-" + sanitizersSegment
+}
+
+// bazelrc returns the content of the bazelrc file which defines a
+// config for the engine, one for each sanitizer and one for each
+// combination of the engine and the sanitizers, which is the one
+// referenced by Flags.
+func bazelrc() string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by cifuzz, changes will be overwritten.\n")
+	sb.WriteString("# Import this file in the .bazelrc of your workspace via:\n")
+	sb.WriteString("#   " + BazelrcImport + "\n")
+
+	const engine = "libfuzzer"
+	sb.WriteString("\n")
+	for _, flag := range commonFlags {
+		sb.WriteString("build:cifuzz-" + engine + " " + flag + "\n")
+	}
+
+	var sanitizers []string
+	for sanitizer := range sanitizerFlags {
+		sanitizers = append(sanitizers, sanitizer)
+	}
+	sort.Strings(sanitizers)
+	for _, sanitizer := range sanitizers {
+		sb.WriteString("\n")
+		for _, flag := range sanitizerFlags[sanitizer] {
+			sb.WriteString("build:cifuzz-" + sanitizer + " " + flag + "\n")
+		}
+	}
+
+	// A config for each combination of the sanitizers
+	sb.WriteString("\n")
+	for i := 0; i < 1<<len(sanitizers); i++ {
+		configs := []string{"--config=cifuzz-" + engine}
+		var subset []string
+		for j, sanitizer := range sanitizers {
+			if i&(1<<j) != 0 {
+				subset = append(subset, sanitizer)
+				configs = append(configs, "--config=cifuzz-"+sanitizer)
+			}
+		}
+		sb.WriteString("build:" + configName(engine, subset) + " " + strings.Join(configs, " ") + "\n")
+	}
+	return sb.String()
+}
+
+// writeBazelrc writes the bazelrc file which defines the configs used
+// by cifuzz to the workspace and checks that it's imported by the
+// workspace's .bazelrc.
+func (b *Builder) writeBazelrc() error {
+	path := filepath.Join(b.ProjectDir, bazelrcPath)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Write the file atomically, because Bazel might be reading it
+	// concurrently in another cifuzz invocation
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".cifuzz.bazelrc-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = tmpFile.WriteString(bazelrc())
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fileutil.Cleanup(tmpFile.Name())
+		return errors.WithStack(err)
+	}
+	err = os.Rename(tmpFile.Name(), path)
+	if err != nil {
+		fileutil.Cleanup(tmpFile.Name())
+		return errors.WithStack(err)
+	}
+
+	imported, err := importsBazelrc(b.ProjectDir)
+	if err != nil {
+		return err
+	}
+	if !imported {
+		err = errors.Errorf(`The .bazelrc of the workspace doesn't import the configs used by cifuzz.
+Please add the following line to %s:
+
+    %s`, filepath.Join(b.ProjectDir, ".bazelrc"), BazelrcImport)
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
+
+// importsBazelrc returns true if the .bazelrc of the workspace imports
+// the bazelrc file written by cifuzz
+func importsBazelrc(projectDir string) (bool, error) {
+	content, err := os.ReadFile(filepath.Join(projectDir, ".bazelrc"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && (fields[0] == "import" || fields[0] == "try-import") &&
+			strings.HasSuffix(filepath.ToSlash(fields[1]), "/.cifuzz-build/bazel/cifuzz.bazelrc") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Build builds the specified fuzz tests, which are Bazel labels, and
+// returns their build results.
+func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
+	// Build all fuzz tests at once, which allows Bazel to parallelize
+	// the build
+	args := append([]string{"build"}, b.Flags()...)
+	args = append(args, "--")
+	args = append(args, fuzzTests...)
+	err := b.run(b.Stdout, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var execRoot bytes.Buffer
+	err = b.run(&execRoot, "info", "execution_root")
+	if err != nil {
+		return nil, err
+	}
+	execRootDir := strings.TrimSpace(execRoot.String())
+
+	results := make(map[string]*build.Result)
+	for _, fuzzTest := range fuzzTests {
+		info, err := b.targetInfo(fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+		if info.Executable == "" {
+			return nil, errors.Errorf("Target %s is not executable", fuzzTest)
+		}
+
+		executable := filepath.Join(execRootDir, filepath.FromSlash(info.Executable))
+		var runtimeDeps []string
+		for _, path := range info.Runfiles {
+			if path == info.Executable {
+				continue
+			}
+			runtimeDeps = append(runtimeDeps, filepath.Join(execRootDir, filepath.FromSlash(path)))
+		}
+
+		results[fuzzTest] = &build.Result{
+			Executable: executable,
+			// Like for CMake, the default seed corpus is the
+			// <name>_seed_corpus directory in the source directory of
+			// the fuzz test
+			SeedCorpus:  filepath.Join(b.ProjectDir, filepath.FromSlash(info.Package), info.Name+"_seed_corpus"),
+			BuildDir:    execRootDir,
+			Engine:      b.Engine,
+			Sanitizers:  b.Sanitizers,
+			RuntimeDeps: runtimeDeps,
+		}
+	}
+
+	return results, nil
+}
+
+// targetInfo uses cquery to look up the executable and the runfiles of
+// the fuzz test in the configuration it was built in.
+func (b *Builder) targetInfo(fuzzTest string) (*targetInfo, error) {
+	args := append([]string{"cquery"}, b.Flags()...)
+	args = append(args, "--output=starlark", "--starlark:expr="+targetInfoExpr, "--", fuzzTest)
+	var out bytes.Buffer
+	err := b.run(&out, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseTargetInfo(out.Bytes())
+}
+
+func parseTargetInfo(cqueryOutput []byte) (*targetInfo, error) {
+	var infos []*targetInfo
+	scanner := bufio.NewScanner(bytes.NewReader(cqueryOutput))
+	// The runfiles of a target can make the line quite long
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		info := &targetInfo{}
+		err := json.Unmarshal([]byte(line), info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse cquery output %q", line)
+		}
+		infos = append(infos, info)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(infos) != 1 {
+		return nil, errors.Errorf("Expected cquery to return a single target, got %d", len(infos))
+	}
+	return infos[0], nil
+}
+
+// ListFuzzTests lists the labels of all fuzz tests of the Bazel
+// workspace
+func (b *Builder) ListFuzzTests() ([]string, error) {
+	var out bytes.Buffer
+	err := b.run(&out, "query", "--output=label", fuzzTestsQuery)
+	if err != nil {
+		return nil, err
+	}
+	var fuzzTests []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			fuzzTests = append(fuzzTests, line)
+		}
+	}
+	return fuzzTests, nil
+}
+
+func (b *Builder) run(stdout io.Writer, args ...string) error {
+	bazel, err := runfiles.Finder.BazelPath()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(bazel, args...)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = stdout
+	cmd.Stderr = b.Stderr
+	cmd.Env = b.env
+	log.Debugf("Working directory: %s", cmd.Dir)
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		// It's expected that the build might fail, so we print the
+		// error without the stack trace.
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
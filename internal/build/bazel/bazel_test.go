@@ -0,0 +1,160 @@
+package bazel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTargetInfo(t *testing.T) {
+	output := `{"package": "src/parser", "name": "parser_fuzz_test", "executable": "bazel-out/k8-opt/bin/src/parser/parser_fuzz_test", "runfiles": ["bazel-out/k8-opt/bin/src/parser/parser_fuzz_test", "src/parser/testdata/dict"]}
+`
+	info, err := parseTargetInfo([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, &targetInfo{
+		Package:    "src/parser",
+		Name:       "parser_fuzz_test",
+		Executable: "bazel-out/k8-opt/bin/src/parser/parser_fuzz_test",
+		Runfiles: []string{
+			"bazel-out/k8-opt/bin/src/parser/parser_fuzz_test",
+			"src/parser/testdata/dict",
+		},
+	}, info)
+
+	_, err = parseTargetInfo([]byte(output + output))
+	assert.Error(t, err)
+
+	_, err = parseTargetInfo([]byte("INFO: Analyzed target\n"))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	opts := &BuilderOptions{ProjectDir: t.TempDir(), Engine: "libfuzzer", Sanitizers: []string{"address", "undefined"}}
+	require.NoError(t, opts.Validate())
+
+	opts.Engine = "afl"
+	assert.Error(t, opts.Validate())
+
+	opts.Engine = "libfuzzer"
+	opts.Sanitizers = []string{"memory"}
+	assert.NoError(t, opts.Validate())
+
+	opts.Sanitizers = []string{"coverage"}
+	assert.Error(t, opts.Validate())
+}
+
+func TestFlags(t *testing.T) {
+	// The config of each sanitizer set must be defined in the bazelrc
+	// and expand to the flags of the engine and the sanitizers
+	rc := parseBazelrc(bazelrc())
+
+	tests := []struct {
+		sanitizers     []string
+		expectedConfig string
+		expected       []string
+		notExpected    []string
+	}{
+		{
+			sanitizers:     nil,
+			expectedConfig: "cifuzz-libfuzzer-none",
+			notExpected:    []string{"--copt=-fsanitize=address", "--copt=-fsanitize=undefined"},
+		},
+		{
+			sanitizers:     []string{"address"},
+			expectedConfig: "cifuzz-libfuzzer-address",
+			expected:       []string{"--copt=-fsanitize=address", "--copt=-fsanitize-recover=address"},
+			notExpected:    []string{"--copt=-fsanitize=undefined"},
+		},
+		{
+			sanitizers:     []string{"undefined", "address"},
+			expectedConfig: "cifuzz-libfuzzer-address-undefined",
+			expected:       []string{"--copt=-fsanitize=address", "--copt=-fsanitize=undefined", "--linkopt=-fsanitize-link-c++-runtime"},
+			notExpected:    []string{"--copt=-fsanitize=memory"},
+		},
+		{
+			sanitizers:     []string{"memory"},
+			expectedConfig: "cifuzz-libfuzzer-memory",
+			expected:       []string{"--copt=-fsanitize=memory", "--copt=-fsanitize-memory-track-origins"},
+			notExpected:    []string{"--copt=-fsanitize=address"},
+		},
+		{
+			sanitizers:     []string{"thread"},
+			expectedConfig: "cifuzz-libfuzzer-thread",
+			expected:       []string{"--copt=-fsanitize=thread", "--linkopt=-fsanitize=thread"},
+			notExpected:    []string{"--copt=-fsanitize=address"},
+		},
+		{
+			sanitizers:     []string{"leak"},
+			expectedConfig: "cifuzz-libfuzzer-leak",
+			expected:       []string{"--copt=-fsanitize=leak", "--linkopt=-fsanitize=leak"},
+			notExpected:    []string{"--copt=-fsanitize=address"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(configName("libfuzzer", tt.sanitizers), func(t *testing.T) {
+			b := &Builder{BuilderOptions: &BuilderOptions{Engine: "libfuzzer", Sanitizers: tt.sanitizers}}
+			assert.Equal(t, []string{"--config=" + tt.expectedConfig}, b.Flags())
+
+			require.Contains(t, rc, tt.expectedConfig)
+			flags := expandConfig(rc, tt.expectedConfig)
+			assert.Contains(t, flags, "--linkopt=-fsanitize=fuzzer")
+			assert.Contains(t, flags, "--copt=-DFUZZING_BUILD_MODE_UNSAFE_FOR_PRODUCTION")
+			for _, flag := range tt.expected {
+				assert.Contains(t, flags, flag)
+			}
+			for _, flag := range tt.notExpected {
+				assert.NotContains(t, flags, flag)
+			}
+		})
+	}
+}
+
+func TestImportsBazelrc(t *testing.T) {
+	projectDir := t.TempDir()
+	imported, err := importsBazelrc(projectDir)
+	require.NoError(t, err)
+	assert.False(t, imported)
+
+	bazelrcFile := filepath.Join(projectDir, ".bazelrc")
+	require.NoError(t, os.WriteFile(bazelrcFile, []byte("build --cxxopt=-std=c++17\n"), 0644))
+	imported, err = importsBazelrc(projectDir)
+	require.NoError(t, err)
+	assert.False(t, imported)
+
+	require.NoError(t, os.WriteFile(bazelrcFile, []byte("build --cxxopt=-std=c++17\n"+BazelrcImport+"\n"), 0644))
+	imported, err = importsBazelrc(projectDir)
+	require.NoError(t, err)
+	assert.True(t, imported)
+}
+
+// parseBazelrc returns the options of each config defined in the
+// bazelrc content
+func parseBazelrc(content string) map[string][]string {
+	configs := map[string][]string{}
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "build:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "build:"))
+		configs[fields[0]] = append(configs[fields[0]], fields[1:]...)
+	}
+	return configs
+}
+
+// expandConfig returns the options of the config with all referenced
+// configs expanded, like Bazel does
+func expandConfig(configs map[string][]string, name string) []string {
+	var flags []string
+	for _, flag := range configs[name] {
+		if strings.HasPrefix(flag, "--config=") {
+			flags = append(flags, expandConfig(configs, strings.TrimPrefix(flag, "--config="))...)
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
@@ -2,8 +2,14 @@ package build
 
 import (
 	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/runfiles"
 	"code-intelligence.com/cifuzz/util/envutil"
 )
 
@@ -54,3 +60,82 @@ func CommonBuildEnv() ([]string, error) {
 
 	return env, nil
 }
+
+// SetAFLCompilers sets the C/C++ compiler to the AFL++ compiler
+// wrappers, which add the coverage instrumentation used by AFL++.
+func SetAFLCompilers(env []string) ([]string, error) {
+	cc, err := runfiles.Finder.AFLClangPath()
+	if err != nil {
+		return nil, err
+	}
+	// The C++ compiler wrapper is installed next to the C compiler
+	// wrapper with a "++" suffix, e.g. afl-clang-fast++
+	env, err = envutil.Setenv(env, "CC", cc)
+	if err != nil {
+		return nil, err
+	}
+	env, err = envutil.Setenv(env, "CXX", cc+"++")
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// SetHonggfuzzCompilers sets the C/C++ compiler to the honggfuzz
+// compiler wrappers, which add the coverage instrumentation used by
+// honggfuzz and link the honggfuzz library.
+func SetHonggfuzzCompilers(env []string) ([]string, error) {
+	cc, err := runfiles.Finder.HonggfuzzClangPath()
+	if err != nil {
+		return nil, err
+	}
+	// The C++ compiler wrapper is installed next to the C compiler
+	// wrapper with a "++" suffix, i.e. hfuzz-clang++
+	env, err = envutil.Setenv(env, "CC", cc)
+	if err != nil {
+		return nil, err
+	}
+	env, err = envutil.Setenv(env, "CXX", cc+"++")
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+var javaClassNamePattern = regexp.MustCompile(`^([\p{L}_$][\p{L}\p{N}_$]*\.)*[\p{L}_$][\p{L}\p{N}_$]*$`)
+
+// ValidateJavaFuzzTest checks that the fuzz test is a fully qualified
+// Java class name, e.g. com.example.MyFuzzTest
+func ValidateJavaFuzzTest(fuzzTest string) error {
+	if !javaClassNamePattern.MatchString(fuzzTest) {
+		return errors.Errorf("Invalid Java fuzz test %q, must be a fully qualified class name, e.g. com.example.MyFuzzTest", fuzzTest)
+	}
+	return nil
+}
+
+// JavaSeedCorpus returns the default seed corpus directory of a Java
+// fuzz test, which is the "<class name>Inputs" directory in the test
+// resources of the class's package, e.g.
+// src/test/resources/com/example/MyFuzzTestInputs
+func JavaSeedCorpus(projectDir string, fuzzTest string) string {
+	return filepath.Join(projectDir, "src", "test", "resources", filepath.FromSlash(strings.ReplaceAll(fuzzTest, ".", "/"))+"Inputs")
+}
+
+// ExistingPaths returns the paths which exist. Build systems include
+// directories in the classpath which are only created when they contain
+// any files, which we don't want to pass to the fuzzer (in particular,
+// non-existing paths can't be mounted in the sandbox).
+func ExistingPaths(paths []string) ([]string, error) {
+	var res []string
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res = append(res, path)
+	}
+	return res, nil
+}
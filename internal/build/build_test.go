@@ -0,0 +1,38 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJavaFuzzTest(t *testing.T) {
+	assert.NoError(t, ValidateJavaFuzzTest("MyFuzzTest"))
+	assert.NoError(t, ValidateJavaFuzzTest("com.example.MyFuzzTest"))
+	assert.NoError(t, ValidateJavaFuzzTest("com.example.Outer$MyFuzzTest"))
+
+	assert.Error(t, ValidateJavaFuzzTest(""))
+	assert.Error(t, ValidateJavaFuzzTest("com/example/MyFuzzTest"))
+	assert.Error(t, ValidateJavaFuzzTest("com.example."))
+	assert.Error(t, ValidateJavaFuzzTest("com.1example.MyFuzzTest"))
+}
+
+func TestJavaSeedCorpus(t *testing.T) {
+	assert.Equal(t,
+		filepath.Join("project", "src", "test", "resources", "com", "example", "MyFuzzTestInputs"),
+		JavaSeedCorpus("project", "com.example.MyFuzzTest"),
+	)
+}
+
+func TestExistingPaths(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "classes")
+	require.NoError(t, os.Mkdir(existing, 0755))
+
+	paths, err := ExistingPaths([]string{existing, filepath.Join(dir, "resources")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{existing}, paths)
+}
@@ -0,0 +1,438 @@
+package build
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The environment variables which affect the result of a build
+var cacheRelevantEnvVars = []string{
+	"CC",
+	"CXX",
+	"CFLAGS",
+	"CXXFLAGS",
+	"CPPFLAGS",
+	"LDFLAGS",
+	"FUZZ_TEST_CFLAGS",
+	"FUZZ_TEST_LDFLAGS",
+}
+
+// The file extensions and names of the source and build files which are
+// considered as inputs of a build if no more precise list is available
+var (
+	sourceFileExtensions = []string{
+		".c", ".cc", ".cpp", ".cxx", ".c++", ".h", ".hh", ".hpp", ".hxx", ".inc", ".ipp", ".s", ".S",
+		".cmake", ".mk",
+	}
+	buildFileNames = []string{
+		"CMakeLists.txt", "Makefile", "makefile", "GNUmakefile", "meson.build", "meson_options.txt",
+		"configure", "configure.ac", "Makefile.am", "Makefile.in",
+	}
+)
+
+// CacheInputs are the inputs of a build which determine whether a
+// previous build of a fuzz test can be reused
+type CacheInputs struct {
+	Engine     string
+	Sanitizers []string
+	// The environment of the build commands
+	Env []string
+	// Additional values which affect the build, e.g. the build command
+	Extra []string
+	// The source and build files of the build
+	Files []string
+}
+
+// key returns a hash of all inputs except for the files, whose states
+// are stored separately to be able to check them cheaply
+func (i *CacheInputs) key() string {
+	h := sha256.New()
+	write := func(s string) {
+		// Separate the values with a null byte to avoid collisions
+		_, _ = io.WriteString(h, s+"\x00")
+	}
+	write(i.Engine)
+	write(strings.Join(i.Sanitizers, "+"))
+	write(CompilerVersion(i.Env))
+	for _, name := range cacheRelevantEnvVars {
+		write(name + "=" + envutil.Getenv(i.Env, name))
+	}
+	for _, extra := range i.Extra {
+		write(extra)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type fileState struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash"`
+}
+
+type cacheEntry struct {
+	FuzzTest string `json:"fuzz_test"`
+	Key      string `json:"key"`
+	// The states of the input files and the executable after the build
+	Files  map[string]*fileState `json:"files"`
+	Result *Result               `json:"result"`
+}
+
+// Cache stores the results of previous builds in the .cifuzz-build
+// directory, so that builds whose inputs haven't changed can be skipped
+type Cache struct {
+	path string
+}
+
+// NewCache returns the cache of the builder with the given name, e.g.
+// "cmake", in the project directory
+func NewCache(projectDir string, name string) *Cache {
+	return &Cache{path: filepath.Join(projectDir, ".cifuzz-build", "cache", name+".json")}
+}
+
+// Lookup returns the cached build results of the fuzz tests if all of
+// them were built before with the same inputs and their executables
+// haven't changed since. Otherwise, it returns nil.
+func (c *Cache) Lookup(inputs *CacheInputs, fuzzTests []string) (map[string]*Result, error) {
+	entries, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	key := inputs.key()
+	files := make(map[string]bool)
+	for _, file := range inputs.Files {
+		files[file] = true
+	}
+
+	results := make(map[string]*Result)
+	for _, fuzzTest := range fuzzTests {
+		entry, ok := entries[entryID(fuzzTest, key)]
+		if !ok || entry.Key != key || entry.Result == nil {
+			return nil, nil
+		}
+		// The executable is also checked, because other builds (e.g.
+		// of the build system "other") might write to the same path
+		if len(entry.Files) != len(files)+1 || entry.Files[entry.Result.Executable] == nil {
+			return nil, nil
+		}
+		for path, state := range entry.Files {
+			if path != entry.Result.Executable && !files[path] {
+				return nil, nil
+			}
+			unchanged, err := state.matches(path)
+			if err != nil {
+				return nil, err
+			}
+			if !unchanged {
+				log.Debugf("Build cache of %s is outdated because %s changed", fuzzTest, path)
+				return nil, nil
+			}
+		}
+		results[fuzzTest] = entry.Result
+	}
+	return results, nil
+}
+
+// Store adds the results of a successful build to the cache. It must be
+// called after the build, so that the state of files which are
+// generated by the build is stored.
+func (c *Cache) Store(inputs *CacheInputs, results map[string]*Result) error {
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+	key := inputs.key()
+	files := make(map[string]*fileState)
+	for _, file := range inputs.Files {
+		files[file], err = newFileState(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	for fuzzTest, result := range results {
+		entry := &cacheEntry{FuzzTest: fuzzTest, Key: key, Files: make(map[string]*fileState), Result: result}
+		for path, state := range files {
+			entry.Files[path] = state
+		}
+		entry.Files[result.Executable], err = newFileState(result.Executable)
+		if err != nil {
+			return err
+		}
+		// Builds with other inputs which wrote the same executable
+		// are outdated now
+		for id, other := range entries {
+			if other.FuzzTest == fuzzTest && other.Result != nil && other.Result.Executable == result.Executable {
+				delete(entries, id)
+			}
+		}
+		entries[entryID(fuzzTest, key)] = entry
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(c.path, data, 0644))
+}
+
+// entryID returns the ID of the cache entry of the fuzz test built with
+// the inputs of the given key. Builds of the same fuzz test with other
+// inputs, e.g. by 'cifuzz run' and 'cifuzz coverage', have separate
+// entries, so that they don't invalidate each other.
+func entryID(fuzzTest string, key string) string {
+	return fuzzTest + "@" + key
+}
+
+func (c *Cache) read() (map[string]*cacheEntry, error) {
+	entries := make(map[string]*cacheEntry)
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		// A corrupt cache is not an error, the fuzz tests are just
+		// rebuilt
+		log.Debugf("Ignoring invalid build cache %s: %v", c.path, err)
+		return make(map[string]*cacheEntry), nil
+	}
+	return entries, nil
+}
+
+func newFileState(path string) (*fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileState{Size: info.Size(), ModTime: info.ModTime(), Hash: hash}, nil
+}
+
+// matches returns true if the file still has the stored state. The
+// content is only hashed if the size or the modification time changed.
+func (s *fileState) matches(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if info.Size() != s.Size {
+		return false, nil
+	}
+	if info.ModTime().Equal(s.ModTime) {
+		return true, nil
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return false, err
+	}
+	return hash == s.Hash, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var (
+	compilerVersions   = make(map[string]string)
+	compilerVersionsMu sync.Mutex
+)
+
+// CompilerVersion returns the output of "$CC --version" in the given
+// environment, or an empty string if the compiler can't be executed.
+// The result is cached per compiler.
+func CompilerVersion(env []string) string {
+	cc := envutil.Getenv(env, "CC")
+	if cc == "" {
+		cc = "clang"
+	}
+	compilerVersionsMu.Lock()
+	defer compilerVersionsMu.Unlock()
+	if version, ok := compilerVersions[cc]; ok {
+		return version
+	}
+	cmd := exec.Command(cc, "--version")
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		log.Debugf("Failed to get the version of compiler %s: %v", cc, err)
+	}
+	compilerVersions[cc] = string(out)
+	return string(out)
+}
+
+// SourceFiles returns the absolute paths of the source and build files
+// in the directory, skipping hidden directories like .git and
+// .cifuzz-build
+func SourceFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if isSourceFile(d.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for i, file := range files {
+		files[i], err = filepath.Abs(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return files, nil
+}
+
+func isSourceFile(name string) bool {
+	for _, buildFileName := range buildFileNames {
+		if name == buildFileName {
+			return true
+		}
+	}
+	ext := filepath.Ext(name)
+	for _, sourceFileExtension := range sourceFileExtensions {
+		if ext == sourceFileExtension {
+			return true
+		}
+	}
+	return false
+}
+
+// DepfileDependencies returns the absolute paths of the files listed
+// in the Make-style depfiles (*.d) which the compiler created in the
+// build directory, i.e. all files which were read to compile the
+// objects of the build. Relative paths are resolved against the build
+// directory and skipped if they don't exist.
+func DepfileDependencies(buildDir string) ([]string, error) {
+	deps := make(map[string]bool)
+	err := filepath.WalkDir(buildDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".d" || !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, dep := range parseDepfile(string(data)) {
+			if !filepath.IsAbs(dep) {
+				dep = filepath.Join(buildDir, dep)
+			}
+			if exists, _ := fileutil.Exists(dep); exists {
+				deps[filepath.Clean(dep)] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var res []string
+	for dep := range deps {
+		res = append(res, dep)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// parseDepfile returns the prerequisites listed in a Make-style depfile
+// like the ones created by the -MD option of clang and gcc:
+//
+//	foo.o: /src/foo.c /src/foo.h \
+//	  /usr/include/stdio.h
+func parseDepfile(content string) []string {
+	var deps []string
+	// Join continued lines
+	content = strings.ReplaceAll(content, "\\\r\n", " ")
+	content = strings.ReplaceAll(content, "\\\n", " ")
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Find the colon which separates the targets from the
+		// prerequisites, which is followed by whitespace (in contrast
+		// to the colon after a Windows drive letter)
+		i := strings.Index(line, ": ")
+		if i == -1 {
+			if !strings.HasSuffix(line, ":") {
+				continue
+			}
+			i = len(line) - 1
+		}
+		var dep strings.Builder
+		rest := line[i+1:]
+		for j := 0; j < len(rest); j++ {
+			switch {
+			case rest[j] == '\\' && j+1 < len(rest) && rest[j+1] == ' ':
+				// Escaped space in a path
+				dep.WriteByte(' ')
+				j++
+			case rest[j] == ' ' || rest[j] == '\t':
+				if dep.Len() > 0 {
+					deps = append(deps, dep.String())
+					dep.Reset()
+				}
+			default:
+				dep.WriteByte(rest[j])
+			}
+		}
+		if dep.Len() > 0 {
+			deps = append(deps, dep.String())
+		}
+	}
+	return deps
+}
@@ -0,0 +1,141 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	projectDir := t.TempDir()
+	source := filepath.Join(projectDir, "fuzz_test.c")
+	require.NoError(t, os.WriteFile(source, []byte("int x;"), 0644))
+	executable := filepath.Join(projectDir, "fuzz_test")
+	require.NoError(t, os.WriteFile(executable, []byte("executable"), 0755))
+
+	inputs := &CacheInputs{
+		Engine:     "libfuzzer",
+		Sanitizers: []string{"address"},
+		Env:        []string{"CC=does-not-exist", "CFLAGS=-g"},
+		Files:      []string{source},
+	}
+	result := &Result{Executable: executable, Engine: "libfuzzer", Sanitizers: []string{"address"}}
+	cache := NewCache(projectDir, "test")
+
+	// Nothing was stored yet
+	results, err := cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Nil(t, results)
+
+	require.NoError(t, cache.Store(inputs, map[string]*Result{"fuzz_test": result}))
+	results, err = cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Result{"fuzz_test": result}, results)
+
+	// Other fuzz tests are not cached
+	results, err = cache.Lookup(inputs, []string{"fuzz_test", "other_fuzz_test"})
+	require.NoError(t, err)
+	assert.Nil(t, results)
+
+	// A different engine, sanitizers or environment invalidate the cache
+	for _, changed := range []*CacheInputs{
+		{Engine: "afl", Sanitizers: inputs.Sanitizers, Env: inputs.Env, Files: inputs.Files},
+		{Engine: inputs.Engine, Sanitizers: []string{"address", "undefined"}, Env: inputs.Env, Files: inputs.Files},
+		{Engine: inputs.Engine, Sanitizers: inputs.Sanitizers, Env: []string{"CC=does-not-exist", "CFLAGS=-O2"}, Files: inputs.Files},
+	} {
+		results, err = cache.Lookup(changed, []string{"fuzz_test"})
+		require.NoError(t, err)
+		assert.Nil(t, results)
+	}
+
+	// Builds of the fuzz test with different inputs are cached
+	// separately, e.g. the builds of 'cifuzz run' and 'cifuzz coverage'
+	coverageExecutable := filepath.Join(projectDir, "fuzz_test_coverage")
+	require.NoError(t, os.WriteFile(coverageExecutable, []byte("coverage executable"), 0755))
+	coverageInputs := &CacheInputs{Engine: "llvm-cov", Env: inputs.Env, Files: inputs.Files}
+	coverageResult := &Result{Executable: coverageExecutable, Engine: "llvm-cov"}
+	require.NoError(t, cache.Store(coverageInputs, map[string]*Result{"fuzz_test": coverageResult}))
+	results, err = cache.Lookup(coverageInputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Result{"fuzz_test": coverageResult}, results)
+	results, err = cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*Result{"fuzz_test": result}, results)
+
+	// Changing the modification time without changing the content
+	// doesn't invalidate the cache
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(source, later, later))
+	results, err = cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.NotNil(t, results)
+
+	// Changing a source file invalidates the cache
+	require.NoError(t, os.WriteFile(source, []byte("int y;"), 0644))
+	results, err = cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Nil(t, results)
+
+	// Changing the executable invalidates the cache
+	require.NoError(t, cache.Store(inputs, map[string]*Result{"fuzz_test": result}))
+	require.NoError(t, os.WriteFile(executable, []byte("other executable"), 0755))
+	results, err = cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Nil(t, results)
+
+	// Adding a source file invalidates the cache
+	require.NoError(t, cache.Store(inputs, map[string]*Result{"fuzz_test": result}))
+	header := filepath.Join(projectDir, "fuzz_test.h")
+	require.NoError(t, os.WriteFile(header, []byte{}, 0644))
+	inputs.Files = append(inputs.Files, header)
+	results, err = cache.Lookup(inputs, []string{"fuzz_test"})
+	require.NoError(t, err)
+	assert.Nil(t, results)
+}
+
+func TestSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"CMakeLists.txt",
+		filepath.Join("src", "parser.c"),
+		filepath.Join("src", "parser.h"),
+		filepath.Join("src", "README.md"),
+		filepath.Join(".cifuzz-build", "libfuzzer", "generated.c"),
+	} {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte{}, 0644))
+	}
+
+	files, err := SourceFiles(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "CMakeLists.txt"),
+		filepath.Join(dir, "src", "parser.c"),
+		filepath.Join(dir, "src", "parser.h"),
+	}, files)
+}
+
+func TestParseDepfile(t *testing.T) {
+	depfile := "CMakeFiles/fuzz_test.dir/fuzz_test.c.o: /src/fuzz_test.c /src/my\\ header.h \\\n" +
+		"  /usr/include/stdio.h\n"
+	assert.Equal(t, []string{"/src/fuzz_test.c", "/src/my header.h", "/usr/include/stdio.h"}, parseDepfile(depfile))
+}
+
+func TestDepfileDependencies(t *testing.T) {
+	buildDir := t.TempDir()
+	source := filepath.Join(buildDir, "fuzz_test.c")
+	require.NoError(t, os.WriteFile(source, []byte{}, 0644))
+	objDir := filepath.Join(buildDir, "CMakeFiles", "fuzz_test.dir")
+	require.NoError(t, os.MkdirAll(objDir, 0755))
+	depfile := "fuzz_test.c.o: " + source + " fuzz_test.c /does/not/exist.h\n"
+	require.NoError(t, os.WriteFile(filepath.Join(objDir, "fuzz_test.c.o.d"), []byte(depfile), 0644))
+
+	deps, err := DepfileDependencies(buildDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{source}, deps)
+}
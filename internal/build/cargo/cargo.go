@@ -0,0 +1,216 @@
+package cargo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// The flags which cargo-fuzz passes to rustc to add the coverage
+// instrumentation used by libFuzzer, see
+// https://github.com/rust-fuzz/cargo-fuzz/blob/main/src/project.rs
+var instrumentationFlags = []string{
+	"-Cpasses=sancov-module",
+	"-Cllvm-args=-sanitizer-coverage-level=4",
+	"-Cllvm-args=-sanitizer-coverage-inline-8bit-counters",
+	"-Cllvm-args=-sanitizer-coverage-pc-table",
+	"-Cllvm-args=-sanitizer-coverage-trace-compares",
+	// Prevent branches from being merged, which would reduce the
+	// coverage feedback
+	"-Cllvm-args=-simplifycfg-branch-fold-threshold=0",
+	"--cfg", "fuzzing",
+	// Let the fuzz targets panic on failed debug assertions and
+	// integer overflows, like in debug builds
+	"-Cdebug-assertions",
+	"-Coverflow_checks",
+	"-Cforce-frame-pointers",
+}
+
+// The sanitizers which are supported by rustc
+var supportedSanitizers = []string{"address", "memory", "thread", "leak"}
+
+var hostPattern = regexp.MustCompile(`(?m)^host: (\S+)$`)
+
+type BuilderOptions struct {
+	ProjectDir string
+	Engine     string
+	Sanitizers []string
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	// Check that the project dir is set
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	// Check that the project dir exists and can be accessed
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// The fuzz targets of cargo-fuzz link libFuzzer via the
+	// libfuzzer-sys crate
+	if opts.Engine != "libfuzzer" {
+		return errors.Errorf("Engine %q is not supported for Rust projects", opts.Engine)
+	}
+	return nil
+}
+
+// Builder builds the fuzz targets of the fuzz crate which is created by
+// cargo-fuzz (i.e. fuzz/Cargo.toml) with the same flags that cargo-fuzz
+// uses, so that they can be run by our libFuzzer runner.
+type Builder struct {
+	*BuilderOptions
+	env []string
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Builder{BuilderOptions: opts}
+
+	err = os.MkdirAll(b.BuildDir(), 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	b.env, err = build.CommonBuildEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *Builder) BuildDir() string {
+	return filepath.Join(b.ProjectDir, ".cifuzz-build", "cargo", b.Engine, strings.Join(b.Sanitizers, "+"))
+}
+
+// Build builds the specified fuzz targets, which are the names of the
+// binaries of the fuzz crate, and returns their build results.
+func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
+	// Like cargo-fuzz, we pass the target triple explicitly, which
+	// causes cargo to not pass the RUSTFLAGS to build scripts and
+	// procedural macros, which must not be instrumented.
+	target, err := hostTarget()
+	if err != nil {
+		return nil, err
+	}
+
+	sanitizers := b.rustSanitizers()
+	rustFlags := append([]string{}, instrumentationFlags...)
+	for _, sanitizer := range sanitizers {
+		rustFlags = append(rustFlags, "-Zsanitizer="+sanitizer)
+	}
+	// Keep the flags specified by the user
+	if userFlags := os.Getenv("RUSTFLAGS"); userFlags != "" {
+		rustFlags = append(rustFlags, userFlags)
+	}
+	env, err := envutil.Setenv(b.env, "RUSTFLAGS", strings.Join(rustFlags, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"build",
+		"--manifest-path", filepath.Join(b.ProjectDir, "fuzz", "Cargo.toml"),
+		"--release",
+		"--target", target,
+		"--target-dir", b.BuildDir(),
+	}
+	for _, fuzzTest := range fuzzTests {
+		args = append(args, "--bin", fuzzTest)
+	}
+	cargo, err := runfiles.Finder.CargoPath()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(cargo, args...)
+	cmd.Dir = b.ProjectDir
+	// Redirect the build command's stdout to stderr to only have
+	// reports printed to stdout
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	cmd.Env = env
+	log.Debugf("Working directory: %s", cmd.Dir)
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		// It's expected that the build might fail, so we print the
+		// error without the stack trace.
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	results := map[string]*build.Result{}
+	for _, fuzzTest := range fuzzTests {
+		results[fuzzTest] = &build.Result{
+			Executable: filepath.Join(b.BuildDir(), target, "release", fuzzTest),
+			// cargo-fuzz stores the corpus of each fuzz target in
+			// fuzz/corpus/<fuzz target>
+			SeedCorpus: filepath.Join(b.ProjectDir, "fuzz", "corpus", fuzzTest),
+			BuildDir:   b.BuildDir(),
+			Engine:     b.Engine,
+			Sanitizers: sanitizers,
+		}
+	}
+	return results, nil
+}
+
+// rustSanitizers returns the sanitizers which are supported by rustc.
+// The other sanitizers are ignored, in particular UBSan, because the
+// undefined behavior it detects is prevented by safe Rust and the
+// overflow checks.
+func (b *Builder) rustSanitizers() []string {
+	var sanitizers []string
+	for _, sanitizer := range b.Sanitizers {
+		if !stringutil.Contains(supportedSanitizers, sanitizer) {
+			log.Debugf("Sanitizer %q is not supported for Rust, ignoring it", sanitizer)
+			continue
+		}
+		sanitizers = append(sanitizers, sanitizer)
+	}
+	return sanitizers
+}
+
+// hostTarget returns the target triple of the host, e.g.
+// x86_64-unknown-linux-gnu
+func hostTarget() (string, error) {
+	rustc, err := runfiles.Finder.RustcPath()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(rustc, "-vV")
+	log.Debugf("Command: %s", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return "", cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return parseHostTarget(out)
+}
+
+func parseHostTarget(rustcVersion []byte) (string, error) {
+	match := hostPattern.FindSubmatch(bytes.ReplaceAll(rustcVersion, []byte("\r\n"), []byte("\n")))
+	if match == nil {
+		return "", errors.Errorf("Failed to determine the host target from the rustc version:\n%s", rustcVersion)
+	}
+	return string(match[1]), nil
+}
@@ -0,0 +1,30 @@
+package cargo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHostTarget(t *testing.T) {
+	rustcVersion := `rustc 1.66.0-nightly (57f097ea2 2022-10-01)
+binary: rustc
+commit-hash: 57f097ea25f2c05f424fc9b9dc50dbd6d399845c
+commit-date: 2022-10-01
+host: x86_64-unknown-linux-gnu
+release: 1.66.0-nightly
+LLVM version: 15.0.2
+`
+	target, err := parseHostTarget([]byte(rustcVersion))
+	require.NoError(t, err)
+	assert.Equal(t, "x86_64-unknown-linux-gnu", target)
+
+	_, err = parseHostTarget([]byte("rustc 1.66.0-nightly\n"))
+	assert.Error(t, err)
+}
+
+func TestRustSanitizers(t *testing.T) {
+	b := &Builder{BuilderOptions: &BuilderOptions{Sanitizers: []string{"address", "undefined"}}}
+	assert.Equal(t, []string{"address"}, b.rustSanitizers())
+}
@@ -71,6 +71,19 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 		return nil, err
 	}
 
+	// CMake only picks up the compiler when the build directory is
+	// configured for the first time, which is fine because the engine
+	// is part of the build directory path.
+	switch b.Engine {
+	case "afl":
+		b.env, err = build.SetAFLCompilers(b.env)
+	case "honggfuzz":
+		b.env, err = build.SetHonggfuzzCompilers(b.env)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	return b, nil
 }
 
@@ -194,9 +207,68 @@ func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
 		}
 	}
 
+	// A failure to update the cache doesn't affect the build results
+	err = b.storeCachedResults(results)
+	if err != nil {
+		log.Warnf("Failed to update the build cache: %v", err)
+	}
+
 	return results, nil
 }
 
+// FindCachedResults returns the results of a previous build of the fuzz
+// tests if none of the inputs of the build changed since. Otherwise, it
+// returns nil. This doesn't require the build directory to be
+// configured, so the configure step can be skipped as well.
+func (b *Builder) FindCachedResults(fuzzTests []string) (map[string]*build.Result, error) {
+	inputs, err := b.cacheInputs()
+	if err != nil {
+		return nil, err
+	}
+	return build.NewCache(b.ProjectDir, "cmake").Lookup(inputs, fuzzTests)
+}
+
+func (b *Builder) storeCachedResults(results map[string]*build.Result) error {
+	inputs, err := b.cacheInputs()
+	if err != nil {
+		return err
+	}
+	return build.NewCache(b.ProjectDir, "cmake").Store(inputs, results)
+}
+
+// cacheInputs returns the inputs of the build. The source files are
+// taken from the depfiles which the compiler created in the build
+// directory, which also include the headers. In addition, all CMake
+// files are inputs, because they define how the sources are built.
+func (b *Builder) cacheInputs() (*build.CacheInputs, error) {
+	files, err := build.DepfileDependencies(b.BuildDir())
+	if err != nil {
+		return nil, err
+	}
+	// If there are no depfiles, e.g. because the Ninja generator is
+	// used, all source files are considered as inputs
+	haveDepfiles := len(files) > 0
+	sourceFiles, err := build.SourceFiles(b.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range sourceFiles {
+		if !haveDepfiles || filepath.Base(file) == "CMakeLists.txt" || filepath.Ext(file) == ".cmake" {
+			files = append(files, file)
+		}
+	}
+	return &build.CacheInputs{
+		Engine:     b.Engine,
+		Sanitizers: b.Sanitizers,
+		Env:        b.env,
+		Extra: []string{
+			cmakeBuildConfiguration,
+			fmt.Sprintf("FindRuntimeDeps=%t", b.FindRuntimeDeps),
+		},
+		Files: files,
+	}, nil
+}
+
 // findFuzzTestExecutable uses the info files emitted by the CMake integration
 // in the configure step to look up the absolute path of a fuzz test's
 // executable.
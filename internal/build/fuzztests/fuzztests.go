@@ -0,0 +1,251 @@
+package fuzztests
+
+import (
+	"io"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/bazel"
+	"code-intelligence.com/cifuzz/internal/build/cargo"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/golang"
+	"code-intelligence.com/cifuzz/internal/build/gradle"
+	"code-intelligence.com/cifuzz/internal/build/maven"
+	"code-intelligence.com/cifuzz/internal/build/meson"
+	"code-intelligence.com/cifuzz/internal/build/other"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// BuildOptions are the options for building fuzz tests with the
+// builder of the project's build system
+type BuildOptions struct {
+	ProjectDir   string
+	BuildSystem  string
+	BuildCommand string
+	BuildOutput  string
+	Engine       string
+	Sanitizers   []string
+	Stdout       io.Writer
+	Stderr       io.Writer
+
+	// The names of the fuzz tests to build
+	FuzzTests []string
+	// Build all fuzz tests of the project instead of FuzzTests. Only
+	// supported for CMake, Bazel and Meson projects.
+	All bool
+	// Build the fuzz tests even if the cached build results are up to
+	// date. Caching is only supported for CMake and "other" projects.
+	Rebuild bool
+}
+
+// Build builds the fuzz tests with the builder of the build system and
+// returns the names of the fuzz tests which were built, which are all
+// fuzz tests of the project if opts.All is set, and their build results
+// by fuzz test.
+func Build(opts *BuildOptions) ([]string, map[string]*build.Result, error) {
+	fuzzTests := opts.FuzzTests
+
+	if opts.BuildSystem == config.BuildSystemCMake {
+		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Engine:     opts.Engine,
+			Sanitizers: opts.Sanitizers,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		// The fuzz tests are only known after the configure step if
+		// all fuzz tests should be built
+		configured := false
+		if opts.All {
+			err = builder.Configure()
+			if err != nil {
+				return nil, nil, err
+			}
+			configured = true
+			fuzzTests, err = builder.ListFuzzTests()
+			if err != nil {
+				return nil, nil, err
+			}
+			err = checkFuzzTestsFound(fuzzTests)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if !opts.Rebuild {
+			buildResults, err := builder.FindCachedResults(fuzzTests)
+			if err != nil {
+				return nil, nil, err
+			}
+			if buildResults != nil {
+				log.Info("Fuzz tests are up to date, skipping the build (use --rebuild to force it)")
+				return fuzzTests, buildResults, nil
+			}
+		}
+		if !configured {
+			err = builder.Configure()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		// Build all fuzz tests at once, which allows the build system
+		// to parallelize the build
+		buildResults, err := builder.Build(fuzzTests)
+		return fuzzTests, buildResults, err
+	} else if opts.BuildSystem == config.BuildSystemBazel {
+		builder, err := bazel.NewBuilder(&bazel.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Engine:     opts.Engine,
+			Sanitizers: opts.Sanitizers,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if opts.All {
+			fuzzTests, err = builder.ListFuzzTests()
+			if err != nil {
+				return nil, nil, err
+			}
+			err = checkFuzzTestsFound(fuzzTests)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		buildResults, err := builder.Build(fuzzTests)
+		return fuzzTests, buildResults, err
+	} else if opts.BuildSystem == config.BuildSystemMeson {
+		builder, err := meson.NewBuilder(&meson.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Engine:     opts.Engine,
+			Sanitizers: opts.Sanitizers,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		err = builder.Configure()
+		if err != nil {
+			return nil, nil, err
+		}
+		if opts.All {
+			fuzzTests, err = builder.ListFuzzTests()
+			if err != nil {
+				return nil, nil, err
+			}
+			err = checkFuzzTestsFound(fuzzTests)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		buildResults, err := builder.Build(fuzzTests)
+		return fuzzTests, buildResults, err
+	} else if opts.BuildSystem == config.BuildSystemGo {
+		builder, err := golang.NewBuilder(&golang.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Engine:     opts.Engine,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		buildResults := map[string]*build.Result{}
+		for _, fuzzTest := range fuzzTests {
+			buildResults[fuzzTest], err = builder.Build(fuzzTest)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return fuzzTests, buildResults, nil
+	} else if opts.BuildSystem == config.BuildSystemCargo {
+		builder, err := cargo.NewBuilder(&cargo.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Engine:     opts.Engine,
+			Sanitizers: opts.Sanitizers,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		buildResults, err := builder.Build(fuzzTests)
+		return fuzzTests, buildResults, err
+	} else if opts.BuildSystem == config.BuildSystemMaven {
+		builder, err := maven.NewBuilder(&maven.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		buildResults, err := builder.Build(fuzzTests)
+		return fuzzTests, buildResults, err
+	} else if opts.BuildSystem == config.BuildSystemGradle {
+		builder, err := gradle.NewBuilder(&gradle.BuilderOptions{
+			ProjectDir: opts.ProjectDir,
+			Stdout:     opts.Stdout,
+			Stderr:     opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		buildResults, err := builder.Build(fuzzTests)
+		return fuzzTests, buildResults, err
+	} else if opts.BuildSystem == config.BuildSystemOther {
+		if runtime.GOOS == "windows" {
+			return nil, nil, errors.New("CMake is the only supported build system on Windows")
+		}
+		builder, err := other.NewBuilder(&other.BuilderOptions{
+			ProjectDir:   opts.ProjectDir,
+			BuildCommand: opts.BuildCommand,
+			BuildOutput:  opts.BuildOutput,
+			Engine:       opts.Engine,
+			Sanitizers:   opts.Sanitizers,
+			Stdout:       opts.Stdout,
+			Stderr:       opts.Stderr,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		defer builder.Cleanup()
+		buildResults := map[string]*build.Result{}
+		for _, fuzzTest := range fuzzTests {
+			if !opts.Rebuild {
+				buildResults[fuzzTest], err = builder.FindCachedResult(fuzzTest)
+				if err != nil {
+					return nil, nil, err
+				}
+				if buildResults[fuzzTest] != nil {
+					log.Infof("%s is up to date, skipping the build (use --rebuild to force it)", fuzzTest)
+					continue
+				}
+			}
+			buildResults[fuzzTest], err = builder.Build(fuzzTest)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return fuzzTests, buildResults, nil
+	} else {
+		return nil, nil, errors.Errorf("Unsupported build system \"%s\"", opts.BuildSystem)
+	}
+}
+
+func checkFuzzTestsFound(fuzzTests []string) error {
+	if len(fuzzTests) == 0 {
+		err := errors.New("The project doesn't contain any fuzz tests")
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
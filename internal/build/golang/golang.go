@@ -0,0 +1,178 @@
+package golang
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+)
+
+type BuilderOptions struct {
+	ProjectDir string
+	// Either "go" to build a test binary for Go's native fuzzing engine
+	// or "libfuzzer" to build a libFuzzer fuzz target
+	Engine string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	// Check that the project dir is set
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	// Check that the project dir exists and can be accessed
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if opts.Engine != "go" && opts.Engine != "libfuzzer" {
+		return errors.Errorf("Engine %q is not supported for Go projects", opts.Engine)
+	}
+	return nil
+}
+
+// Builder builds native Go fuzz tests, i.e. functions of the form
+// FuzzXxx(f *testing.F) in _test.go files.
+type Builder struct {
+	*BuilderOptions
+	env []string
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Builder{BuilderOptions: opts}
+
+	err = os.MkdirAll(b.BuildDir(), 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	b.env, err = build.CommonBuildEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *Builder) BuildDir() string {
+	return filepath.Join(b.ProjectDir, ".cifuzz-build", "go", b.Engine)
+}
+
+// ParseFuzzTest splits the name of a Go fuzz test into the directory
+// of its package, relative to the project directory, and the name of
+// the fuzz function. Fuzz tests are specified as "<package dir>/FuzzXxx"
+// or as "FuzzXxx" if the package is in the project directory.
+func ParseFuzzTest(fuzzTest string) (pkgDir string, name string, err error) {
+	pkgDir, name = path.Split(filepath.ToSlash(fuzzTest))
+	if !strings.HasPrefix(name, "Fuzz") {
+		return "", "", errors.Errorf("Invalid Go fuzz test %q, the name of the fuzz function must start with \"Fuzz\"", fuzzTest)
+	}
+	pkgDir = path.Clean("./" + pkgDir)
+	if pkgDir == ".." || strings.HasPrefix(pkgDir, "../") {
+		return "", "", errors.Errorf("Invalid Go fuzz test %q, the package must be inside the project directory", fuzzTest)
+	}
+	return filepath.FromSlash(pkgDir), name, nil
+}
+
+// Build builds the specified fuzz test for the engine of the builder
+func (b *Builder) Build(fuzzTest string) (*build.Result, error) {
+	pkgDir, name, err := ParseFuzzTest(fuzzTest)
+	if err != nil {
+		return nil, err
+	}
+	// Use a path relative to the project directory (which is the
+	// working directory of the go commands) to specify the package, so
+	// that it doesn't have to be in GOPATH.
+	pkg := "./" + filepath.ToSlash(pkgDir)
+
+	executable := filepath.Join(b.BuildDir(), pkgDir, name)
+	err = os.MkdirAll(filepath.Dir(executable), 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if b.Engine == "go" {
+		err = b.buildNative(pkg, name, executable)
+	} else {
+		err = b.buildLibFuzzer(pkg, name, executable)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &build.Result{
+		Executable: executable,
+		// The seed corpus of native Go fuzz tests is stored in the
+		// testdata directory of the package
+		SeedCorpus: filepath.Join(b.ProjectDir, pkgDir, "testdata", "fuzz", name),
+		BuildDir:   b.BuildDir(),
+		Engine:     b.Engine,
+	}, nil
+}
+
+// buildNative builds a test binary with coverage instrumentation for
+// Go's native fuzzing engine
+func (b *Builder) buildNative(pkg, name, executable string) error {
+	goPath, err := runfiles.Finder.GoPath()
+	if err != nil {
+		return err
+	}
+	return b.run(goPath, "test", "-c", "-fuzz=^"+name+"$", "-o", executable, pkg)
+}
+
+// buildLibFuzzer builds a libFuzzer fuzz target from a native Go fuzz
+// test, by building the package as an archive with go-118-fuzz-build
+// and linking it with libFuzzer
+func (b *Builder) buildLibFuzzer(pkg, name, executable string) error {
+	goFuzzBuild, err := runfiles.Finder.GoFuzzBuildPath()
+	if err != nil {
+		return err
+	}
+	archive := executable + ".a"
+	err = b.run(goFuzzBuild, "-o", archive, "-func", name, pkg)
+	if err != nil {
+		return err
+	}
+
+	clang, err := runfiles.Finder.ClangPath()
+	if err != nil {
+		return err
+	}
+	return b.run(clang, "-fsanitize=fuzzer", archive, "-o", executable)
+}
+
+func (b *Builder) run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = b.ProjectDir
+	// Redirect the build command's stdout to stderr to only have
+	// reports printed to stdout
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	cmd.Env = b.env
+	log.Debugf("Working directory: %s", cmd.Dir)
+	log.Debugf("Command: %s", cmd.String())
+	err := cmd.Run()
+	if err != nil {
+		// It's expected that the build might fail, so we print the
+		// error without the stack trace.
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
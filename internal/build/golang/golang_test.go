@@ -0,0 +1,31 @@
+package golang
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFuzzTest(t *testing.T) {
+	pkgDir, name, err := ParseFuzzTest("FuzzParse")
+	require.NoError(t, err)
+	assert.Equal(t, ".", pkgDir)
+	assert.Equal(t, "FuzzParse", name)
+
+	pkgDir, name, err = ParseFuzzTest("pkg/parser/FuzzParse")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("pkg", "parser"), pkgDir)
+	assert.Equal(t, "FuzzParse", name)
+
+	pkgDir, _, err = ParseFuzzTest("./pkg/parser/FuzzParse")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("pkg", "parser"), pkgDir)
+
+	_, _, err = ParseFuzzTest("pkg/parser/TestParse")
+	assert.Error(t, err)
+
+	_, _, err = ParseFuzzTest("../other/FuzzParse")
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package gradle
+
+import (
+	_ "embed"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+//go:embed classpath.gradle
+var classpathInitScript string
+
+type BuilderOptions struct {
+	ProjectDir string
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	// Check that the project dir is set
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	// Check that the project dir exists and can be accessed
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Builder compiles the test classes of a Gradle project and resolves
+// the classpath with which the Java fuzz tests are run.
+type Builder struct {
+	*BuilderOptions
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Builder{BuilderOptions: opts}
+
+	err = os.MkdirAll(b.BuildDir(), 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return b, nil
+}
+
+func (b *Builder) BuildDir() string {
+	return filepath.Join(b.ProjectDir, ".cifuzz-build", "gradle")
+}
+
+// Build compiles the project's test classes and returns the build
+// results of the specified fuzz tests, which are the fully qualified
+// names of the fuzz test classes. All fuzz tests share the runtime
+// classpath of the project's test source set, which is stored in the
+// runtime dependencies of the build results.
+func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
+	for _, fuzzTest := range fuzzTests {
+		err := build.ValidateJavaFuzzTest(fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Gradle doesn't provide a command to print the classpath, so we
+	// add a task which writes it to a file via an init script
+	initScript := filepath.Join(b.BuildDir(), "classpath.gradle")
+	err := os.WriteFile(initScript, []byte(classpathInitScript), 0644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	classpathFile := filepath.Join(b.BuildDir(), "classpath")
+	err = b.runGradle(
+		"--init-script", initScript,
+		"-PcifuzzClasspathFile="+classpathFile,
+		"cifuzzWriteTestClasspath",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(classpathFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var classpath []string
+	if trimmed := strings.TrimSpace(string(content)); trimmed != "" {
+		classpath = filepath.SplitList(trimmed)
+	}
+	classpath, err = build.ExistingPaths(classpath)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]*build.Result{}
+	for _, fuzzTest := range fuzzTests {
+		results[fuzzTest] = &build.Result{
+			SeedCorpus:  build.JavaSeedCorpus(b.ProjectDir, fuzzTest),
+			BuildDir:    b.BuildDir(),
+			Engine:      "jazzer",
+			RuntimeDeps: classpath,
+		}
+	}
+	return results, nil
+}
+
+func (b *Builder) runGradle(args ...string) error {
+	gradle, err := b.gradleCommand()
+	if err != nil {
+		return err
+	}
+	// Don't print colors and progress bars, which clutter the output
+	// of cifuzz
+	args = append([]string{"--console=plain"}, args...)
+	cmd := exec.Command(gradle, args...)
+	cmd.Dir = b.ProjectDir
+	// Redirect the build command's stdout to stderr to only have
+	// reports printed to stdout
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	log.Debugf("Working directory: %s", cmd.Dir)
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		// It's expected that the build might fail, so we print the
+		// error without the stack trace.
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
+
+// gradleCommand returns the Gradle wrapper of the project if it has
+// one, else the gradle executable from the PATH.
+func (b *Builder) gradleCommand() (string, error) {
+	wrapper := filepath.Join(b.ProjectDir, "gradlew")
+	exists, err := fileutil.Exists(wrapper)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return wrapper, nil
+	}
+	return runfiles.Finder.GradlePath()
+}
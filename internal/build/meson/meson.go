@@ -0,0 +1,533 @@
+package meson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// Note: Keep in sync with the flags used by the "other" build system
+var commonCFlags = []string{
+	// Keep debug symbols
+	"-g",
+	// Do optimizations which don't harm debugging
+	"-Og",
+	// To get good stack frames for better debugging
+	"-fno-omit-frame-pointer",
+	// Conventional macro to conditionally compile out fuzzer road blocks
+	// See https://llvm.org/docs/LibFuzzer.html#fuzzer-friendly-build-mode
+	"-DFUZZING_BUILD_MODE_UNSAFE_FOR_PRODUCTION",
+}
+
+// A target as printed by "meson introspect --targets"
+type target struct {
+	Name string `json:"name"`
+	// One of "executable", "static library", "shared library",
+	// "shared module", "custom", "run" or "jar"
+	Type string `json:"type"`
+	// Absolute path of the meson.build file which defines the target
+	DefinedIn string `json:"defined_in"`
+	// Absolute paths of the files produced by the target
+	Filename []string `json:"filename"`
+}
+
+type BuilderOptions struct {
+	ProjectDir string
+	Engine     string
+	Sanitizers []string
+	Stdout     io.Writer
+	Stderr     io.Writer
+
+	FindRuntimeDeps bool
+}
+
+func (opts *BuilderOptions) Validate() error {
+	// Check that the project dir is set
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	// Check that the project dir exists and can be accessed
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	switch opts.Engine {
+	case "libfuzzer", "afl", "honggfuzz":
+		for _, sanitizer := range opts.Sanitizers {
+			if !stringutil.Contains(config.SupportedSanitizers, sanitizer) {
+				return errors.Errorf("Sanitizer %q is not supported for Meson projects", sanitizer)
+			}
+		}
+	case "replayer":
+		if !stringutil.Equal(opts.Sanitizers, []string{"coverage"}) {
+			return errors.Errorf("Invalid sanitizers for engine %q: %q", opts.Engine, opts.Sanitizers)
+		}
+	default:
+		return errors.Errorf("Engine %q is not supported for Meson projects", opts.Engine)
+	}
+	return nil
+}
+
+// Builder builds fuzz tests which are defined as executable targets in
+// a Meson project. Each combination of engine and sanitizers is built
+// in a separate build directory, which is configured with the flags
+// needed for fuzzing.
+type Builder struct {
+	*BuilderOptions
+	env []string
+	// The properties which are written to the Meson native file and
+	// must be passed to the fuzz test executables in the meson.build
+	// files via meson.get_external_property()
+	properties map[string][]string
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Builder{BuilderOptions: opts}
+
+	// Ensure that the build directory exists.
+	err = os.MkdirAll(b.BuildDir(), 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	b.env, err = build.CommonBuildEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	// Meson only picks up the compiler and the flags from the
+	// environment when the build directory is configured for the first
+	// time, which is fine because the engine and the sanitizers are
+	// part of the build directory path.
+	switch b.Engine {
+	case "afl":
+		b.env, err = build.SetAFLCompilers(b.env)
+	case "honggfuzz":
+		b.env, err = build.SetHonggfuzzCompilers(b.env)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = b.setFuzzingEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *Builder) BuildDir() string {
+	// Like with CMake, the options of an existing build directory are
+	// not changed by configuring it again, so we encode the engine and
+	// the sanitizers in the path to the build directory.
+	sanitizersSegment := strings.Join(b.Sanitizers, "+")
+	if sanitizersSegment == "" {
+		sanitizersSegment = "none"
+	}
+	return filepath.Join(
+		b.ProjectDir,
+		".cifuzz-build",
+		"meson",
+		b.Engine,
+		sanitizersSegment,
+	)
+}
+
+// Configure runs "meson setup" for the build directory of the builder,
+// or reconfigures it if it was already set up before.
+func (b *Builder) Configure() error {
+	isConfigured, err := fileutil.Exists(filepath.Join(b.BuildDir(), "meson-private", "coredata.dat"))
+	if err != nil {
+		return err
+	}
+	// Meson reads the native file again when the build directory is
+	// reconfigured, so we always write it to pick up changes, e.g. of
+	// the cifuzz installation path
+	err = b.writeNativeFile()
+	if err != nil {
+		return err
+	}
+	var args []string
+	if isConfigured {
+		args = []string{"setup", "--reconfigure", b.BuildDir()}
+	} else {
+		args = append([]string{"setup"}, b.setupOptions()...)
+		args = append(args, b.BuildDir())
+	}
+	return b.run(b.Stderr, args...)
+}
+
+// nativeFile returns the path of the Meson machine file which contains
+// the properties needed by the fuzz tests
+func (b *Builder) nativeFile() string {
+	return filepath.Join(b.BuildDir(), "cifuzz-native.ini")
+}
+
+func (b *Builder) writeNativeFile() error {
+	err := os.WriteFile(b.nativeFile(), []byte(nativeFileContent(b.properties)), 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// nativeFileContent returns the content of a Meson machine file which
+// defines the given properties as arrays of strings
+func nativeFileContent(properties map[string][]string) string {
+	var keys []string
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("[properties]\n")
+	for _, key := range keys {
+		var values []string
+		for _, value := range properties[key] {
+			values = append(values, mesonString(value))
+		}
+		sb.WriteString(key + " = [" + strings.Join(values, ", ") + "]\n")
+	}
+	return sb.String()
+}
+
+// mesonString returns the value as a quoted Meson string literal
+func mesonString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// The values of the b_sanitize option which are supported by all Meson
+// versions
+var bSanitizeValues = []string{"address", "undefined", "memory", "thread", "leak", "address,undefined"}
+
+// useBSanitize returns true if the sanitizers can be enabled via the
+// b_sanitize option. Other combinations of sanitizers are enabled via
+// CFLAGS and LDFLAGS instead.
+func (b *Builder) useBSanitize() bool {
+	return stringutil.Contains(bSanitizeValues, strings.Join(b.Sanitizers, ","))
+}
+
+// setupOptions returns the options which are passed to "meson setup"
+// when the build directory is created
+func (b *Builder) setupOptions() []string {
+	bSanitize := "none"
+	if b.Engine != "replayer" && b.useBSanitize() {
+		bSanitize = strings.Join(b.Sanitizers, ",")
+	}
+	return []string{
+		// Don't let Meson add optimization or debug flags, we set the
+		// ones we need via CFLAGS and CXXFLAGS
+		"--buildtype=plain",
+		"--native-file=" + b.nativeFile(),
+		"-Db_sanitize=" + bSanitize,
+		// Libraries built with sanitizers have undefined references to
+		// the sanitizer runtime, which is only linked into executables
+		"-Db_lundef=false",
+	}
+}
+
+// Build builds the specified fuzz tests, which are the names of
+// executable targets, and returns their build results
+func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
+	if b.Engine == "replayer" {
+		err := b.buildReplayer()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	targets, err := b.introspectTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	fuzzTestTargets := make(map[string]*target)
+	var targetSpecs []string
+	for _, fuzzTest := range fuzzTests {
+		t, err := findExecutableTarget(targets, fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+		fuzzTestTargets[fuzzTest] = t
+		spec, err := b.targetSpec(t)
+		if err != nil {
+			return nil, err
+		}
+		targetSpecs = append(targetSpecs, spec)
+	}
+
+	// Build all fuzz tests at once, which allows ninja to parallelize
+	// the build
+	args := append([]string{"compile", "-C", b.BuildDir()}, targetSpecs...)
+	err = b.run(b.Stderr, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var runtimeDeps []string
+	if b.FindRuntimeDeps {
+		runtimeDeps, err = sharedLibraries(targets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make(map[string]*build.Result)
+	for fuzzTest, t := range fuzzTestTargets {
+		results[fuzzTest] = &build.Result{
+			Executable:  t.Filename[0],
+			SeedCorpus:  filepath.Join(filepath.Dir(t.DefinedIn), t.Name+"_seed_corpus"),
+			BuildDir:    b.BuildDir(),
+			Engine:      b.Engine,
+			Sanitizers:  b.Sanitizers,
+			RuntimeDeps: runtimeDeps,
+		}
+	}
+	return results, nil
+}
+
+// ListFuzzTests lists all executable targets which have "fuzz" in their
+// name after Configure has been run.
+func (b *Builder) ListFuzzTests() ([]string, error) {
+	var out bytes.Buffer
+	err := b.run(&out, "introspect", "--targets", b.BuildDir())
+	if err != nil {
+		return nil, err
+	}
+	return ParseFuzzTests(out.Bytes())
+}
+
+// ParseFuzzTests returns the names of the executable targets which have
+// "fuzz" in their name from the output of "meson introspect --targets",
+// which Meson also stores in meson-info/intro-targets.json in the build
+// directory.
+func ParseFuzzTests(introspection []byte) ([]string, error) {
+	targets, err := parseTargets(introspection)
+	if err != nil {
+		return nil, err
+	}
+	var fuzzTests []string
+	for _, t := range targets {
+		if t.Type == "executable" && strings.Contains(t.Name, "fuzz") && !stringutil.Contains(fuzzTests, t.Name) {
+			fuzzTests = append(fuzzTests, t.Name)
+		}
+	}
+	return fuzzTests, nil
+}
+
+// targetSpec returns the argument which is passed to "meson compile" to
+// build the target. The path of the target's subdirectory is added to
+// the name to avoid ambiguities with targets of the same name in other
+// subdirectories.
+func (b *Builder) targetSpec(t *target) (string, error) {
+	subdir, err := filepath.Rel(b.ProjectDir, filepath.Dir(t.DefinedIn))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if subdir == "." {
+		return t.Name + ":executable", nil
+	}
+	return filepath.ToSlash(subdir) + "/" + t.Name + ":executable", nil
+}
+
+func (b *Builder) introspectTargets() ([]*target, error) {
+	var out bytes.Buffer
+	err := b.run(&out, "introspect", "--targets", b.BuildDir())
+	if err != nil {
+		return nil, err
+	}
+	return parseTargets(out.Bytes())
+}
+
+func parseTargets(introspection []byte) ([]*target, error) {
+	var targets []*target
+	err := json.Unmarshal(introspection, &targets)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse output of meson introspect")
+	}
+	return targets, nil
+}
+
+// findExecutableTarget returns the executable target with the given name
+func findExecutableTarget(targets []*target, name string) (*target, error) {
+	var found *target
+	for _, t := range targets {
+		if t.Type != "executable" || t.Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, errors.Errorf("Meson project defines multiple executables named %q", name)
+		}
+		found = t
+	}
+	if found == nil {
+		return nil, errors.Errorf("Meson project doesn't define an executable named %q", name)
+	}
+	if len(found.Filename) == 0 {
+		return nil, errors.Errorf("Meson introspection doesn't contain the executable of %q", name)
+	}
+	return found, nil
+}
+
+// sharedLibraries returns the absolute paths of the shared libraries
+// and modules built by the project which exist in the build directory.
+// Meson's introspection doesn't include the link dependencies of a
+// target, so all of them are returned.
+func sharedLibraries(targets []*target) ([]string, error) {
+	var libraries []string
+	for _, t := range targets {
+		if t.Type != "shared library" && t.Type != "shared module" {
+			continue
+		}
+		for _, filename := range t.Filename {
+			exists, err := fileutil.Exists(filename)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				libraries = append(libraries, filename)
+			}
+		}
+	}
+	return libraries, nil
+}
+
+// buildReplayer builds the replayer without coverage instrumentation,
+// which is linked into the fuzz tests for coverage builds
+func (b *Builder) buildReplayer() error {
+	replayerSource, err := runfiles.Finder.ReplayerSourcePath()
+	if err != nil {
+		return err
+	}
+	clang, err := runfiles.Finder.ClangPath()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(clang, "-fPIC", "-c", replayerSource, "-o", b.replayerObject())
+	cmd.Stdout = b.Stderr
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (b *Builder) replayerObject() string {
+	return filepath.Join(b.BuildDir(), "replayer.o")
+}
+
+// setFuzzingEnv sets CFLAGS, CXXFLAGS and LDFLAGS, which Meson uses for
+// all targets, and the fuzz_test_c_args and fuzz_test_link_args
+// properties of the native file, which must be passed to the fuzz test
+// executables in the meson.build files.
+func (b *Builder) setFuzzingEnv() error {
+	cflags := append([]string{}, commonCFlags...)
+	var ldflags []string
+	var fuzzTestLDFlags []string
+	switch b.Engine {
+	case "libfuzzer":
+		// Compile with edge coverage and compare instrumentation. We
+		// use fuzzer-no-link here instead of -fsanitize=fuzzer because
+		// Meson also passes the CFLAGS to the linker, which would cause
+		// errors if the build includes tools which have a main function.
+		cflags = append(cflags, "-fsanitize=fuzzer-no-link")
+		fuzzTestLDFlags = []string{"-fsanitize=fuzzer"}
+	case "afl":
+		// With "-fsanitize=fuzzer", the AFL++ compiler wrappers link the
+		// AFL++ driver for libFuzzer-style fuzz tests.
+		fuzzTestLDFlags = []string{"-fsanitize=fuzzer"}
+	case "replayer":
+		cflags = append(cflags, "-fprofile-instr-generate", "-fcoverage-mapping")
+		fuzzTestLDFlags = []string{b.replayerObject()}
+	}
+	// The sanitizers themselves are enabled via the b_sanitize option
+	// if possible
+	if b.Engine != "replayer" && len(b.Sanitizers) > 0 && !b.useBSanitize() {
+		cflags = append(cflags, "-fsanitize="+strings.Join(b.Sanitizers, ","))
+		ldflags = append(ldflags, "-fsanitize="+strings.Join(b.Sanitizers, ","))
+	}
+	if stringutil.Contains(b.Sanitizers, "address") {
+		cflags = append(cflags, "-fsanitize-recover=address", "-fsanitize-address-use-after-scope")
+	}
+	if stringutil.Contains(b.Sanitizers, "memory") {
+		// Report where uninitialized values were created
+		cflags = append(cflags, "-fsanitize-memory-track-origins")
+	}
+	if stringutil.Contains(b.Sanitizers, "undefined") {
+		// To avoid issues with clang (not clang++) and UBSan, see
+		// https://github.com/bazelbuild/bazel/issues/11122#issuecomment-896613570
+		ldflags = append(ldflags, "-fsanitize-link-c++-runtime")
+	}
+
+	cifuzzIncludePath, err := runfiles.Finder.CIFuzzIncludePath()
+	if err != nil {
+		return err
+	}
+	b.properties = map[string][]string{
+		"fuzz_test_c_args":    {"-I" + cifuzzIncludePath},
+		"fuzz_test_link_args": fuzzTestLDFlags,
+	}
+	vars := map[string][]string{
+		"CFLAGS":   cflags,
+		"CXXFLAGS": cflags,
+		"LDFLAGS":  ldflags,
+	}
+	for key, value := range vars {
+		b.env, err = envutil.Setenv(b.env, key, strings.Join(value, " "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Builder) run(stdout io.Writer, args ...string) error {
+	meson, err := runfiles.Finder.MesonPath()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(meson, args...)
+	cmd.Dir = b.ProjectDir
+	// Redirect the build command's stdout to stderr to only have
+	// reports printed to stdout
+	cmd.Stdout = stdout
+	cmd.Stderr = b.Stderr
+	cmd.Env = b.env
+	log.Debugf("Working directory: %s", cmd.Dir)
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		// It's expected that meson might fail due to user configuration,
+		// so we print the error without the stack trace.
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
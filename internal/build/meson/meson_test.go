@@ -0,0 +1,122 @@
+package meson
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTargets(t *testing.T) {
+	introspection := `[
+  {
+    "name": "parser_fuzz_test",
+    "id": "e9c3b6d@@parser_fuzz_test@exe",
+    "type": "executable",
+    "defined_in": "/src/project/fuzz/meson.build",
+    "filename": ["/src/project/build/fuzz/parser_fuzz_test"],
+    "build_by_default": true,
+    "installed": false
+  },
+  {
+    "name": "parser",
+    "id": "25a6634@@parser@sha",
+    "type": "shared library",
+    "defined_in": "/src/project/meson.build",
+    "filename": ["/src/project/build/libparser.so"],
+    "build_by_default": true,
+    "installed": true
+  }
+]`
+	targets, err := parseTargets([]byte(introspection))
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	assert.Equal(t, &target{
+		Name:      "parser_fuzz_test",
+		Type:      "executable",
+		DefinedIn: "/src/project/fuzz/meson.build",
+		Filename:  []string{"/src/project/build/fuzz/parser_fuzz_test"},
+	}, targets[0])
+
+	_, err = parseTargets([]byte("not json"))
+	assert.Error(t, err)
+
+	fuzzTests, err := ParseFuzzTests([]byte(introspection))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"parser_fuzz_test"}, fuzzTests)
+}
+
+func TestFindExecutableTarget(t *testing.T) {
+	targets := []*target{
+		{Name: "parser", Type: "shared library", Filename: []string{"libparser.so"}},
+		{Name: "parser_fuzz_test", Type: "executable", Filename: []string{"parser_fuzz_test"}},
+		{Name: "tool", Type: "executable", Filename: []string{"a/tool"}},
+		{Name: "tool", Type: "executable", Filename: []string{"b/tool"}},
+	}
+
+	found, err := findExecutableTarget(targets, "parser_fuzz_test")
+	require.NoError(t, err)
+	assert.Equal(t, targets[1], found)
+
+	_, err = findExecutableTarget(targets, "parser")
+	assert.Error(t, err)
+
+	_, err = findExecutableTarget(targets, "tool")
+	assert.Error(t, err)
+}
+
+func TestTargetSpec(t *testing.T) {
+	b := &Builder{BuilderOptions: &BuilderOptions{ProjectDir: filepath.FromSlash("/src/project")}}
+
+	spec, err := b.targetSpec(&target{Name: "fuzz_test", DefinedIn: filepath.FromSlash("/src/project/meson.build")})
+	require.NoError(t, err)
+	assert.Equal(t, "fuzz_test:executable", spec)
+
+	spec, err = b.targetSpec(&target{Name: "fuzz_test", DefinedIn: filepath.FromSlash("/src/project/fuzz/parser/meson.build")})
+	require.NoError(t, err)
+	assert.Equal(t, "fuzz/parser/fuzz_test:executable", spec)
+}
+
+func TestSharedLibraries(t *testing.T) {
+	buildDir := t.TempDir()
+	library := filepath.Join(buildDir, "libparser.so")
+	require.NoError(t, os.WriteFile(library, []byte{}, 0644))
+
+	targets := []*target{
+		{Name: "parser", Type: "shared library", Filename: []string{library}},
+		{Name: "plugin", Type: "shared module", Filename: []string{filepath.Join(buildDir, "plugin.so")}},
+		{Name: "util", Type: "static library", Filename: []string{filepath.Join(buildDir, "libutil.a")}},
+	}
+	libraries, err := sharedLibraries(targets)
+	require.NoError(t, err)
+	assert.Equal(t, []string{library}, libraries)
+}
+
+func TestSetupOptions(t *testing.T) {
+	b := &Builder{BuilderOptions: &BuilderOptions{Engine: "libfuzzer", Sanitizers: []string{"address", "undefined"}}}
+	assert.Contains(t, b.setupOptions(), "-Db_sanitize=address,undefined")
+	assert.Contains(t, b.setupOptions(), "-Db_lundef=false")
+
+	b = &Builder{BuilderOptions: &BuilderOptions{Engine: "replayer", Sanitizers: []string{"coverage"}}}
+	assert.Contains(t, b.setupOptions(), "-Db_sanitize=none")
+
+	// Older Meson versions don't support this combination of sanitizers
+	// in b_sanitize, so they are enabled via CFLAGS and LDFLAGS instead
+	b = &Builder{BuilderOptions: &BuilderOptions{Engine: "libfuzzer", Sanitizers: []string{"memory", "undefined"}}}
+	assert.Contains(t, b.setupOptions(), "-Db_sanitize=none")
+}
+
+func TestNativeFileContent(t *testing.T) {
+	content := nativeFileContent(map[string][]string{
+		"fuzz_test_link_args": {"-fsanitize=fuzzer"},
+		"fuzz_test_c_args":    {`-I/opt/it's\cifuzz/include`},
+		"empty":               nil,
+	})
+	assert.Equal(t, `[properties]
+empty = []
+fuzz_test_c_args = ['-I/opt/it\'s\\cifuzz/include']
+fuzz_test_link_args = ['-fsanitize=fuzzer']
+`, content)
+}
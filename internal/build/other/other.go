@@ -3,16 +3,18 @@ package other
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strings"
 
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/sharedlibs"
+	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
@@ -22,11 +24,17 @@ import (
 )
 
 type BuilderOptions struct {
+	// The project directory, in which the build cache is stored. If
+	// not set, the build results are not cached.
+	ProjectDir   string
 	BuildCommand string
-	Engine       string
-	Sanitizers   []string
-	Stdout       io.Writer
-	Stderr       io.Writer
+	// The path of the fuzz test executable which is built by the build
+	// command. If not set, the executable is searched for.
+	BuildOutput string
+	Engine      string
+	Sanitizers  []string
+	Stdout      io.Writer
+	Stderr      io.Writer
 }
 
 type Builder struct {
@@ -53,13 +61,20 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 	// Set CFLAGS, CXXFLAGS, LDFLAGS, and FUZZ_TEST_LDFLAGS which must
 	// be passed to the build commands by the build system.
 	switch opts.Engine {
-	case "libfuzzer":
+	case "libfuzzer", "afl", "honggfuzz":
 		for _, sanitizer := range opts.Sanitizers {
-			if sanitizer != "address" && sanitizer != "undefined" {
+			if !stringutil.Contains(config.SupportedSanitizers, sanitizer) {
 				panic(fmt.Sprintf("Invalid sanitizer for engine %q: %q", opts.Engine, sanitizer))
 			}
 		}
-		err = b.setLibFuzzerEnv()
+		switch opts.Engine {
+		case "afl":
+			err = b.setAFLEnv()
+		case "honggfuzz":
+			err = b.setHonggfuzzEnv()
+		default:
+			err = b.setLibFuzzerEnv()
+		}
 	case "replayer":
 		if !stringutil.Equal(opts.Sanitizers, []string{"coverage"}) {
 			panic(fmt.Sprintf("Invalid sanitizers for engine %q: %q", opts.Engine, opts.Sanitizers))
@@ -124,7 +139,7 @@ func (b *Builder) Build(fuzzTest string) (*build.Result, error) {
 	// For the build system type "other", we expect the  the default
 	// seed corpus next to the fuzzer executable.
 	seedCorpus := executable + "_seed_corpus"
-	runtimeDeps, err := b.findSharedLibraries(fuzzTest)
+	runtimeDeps, err := findSharedLibraries(executable)
 	if err != nil {
 		return nil, err
 	}
@@ -132,13 +147,79 @@ func (b *Builder) Build(fuzzTest string) (*build.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &build.Result{
+	result := &build.Result{
 		Executable:  executable,
 		SeedCorpus:  seedCorpus,
 		BuildDir:    buildDir,
 		Engine:      b.Engine,
 		Sanitizers:  b.Sanitizers,
 		RuntimeDeps: runtimeDeps,
+	}
+
+	if b.ProjectDir != "" {
+		// A failure to update the cache doesn't affect the build result
+		err = b.storeCachedResult(fuzzTest, result)
+		if err != nil {
+			log.Warnf("Failed to update the build cache: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Cleanup removes the temporary build directory, which is required if
+// Build is not called because the result was found in the cache
+func (b *Builder) Cleanup() {
+	fileutil.Cleanup(b.buildDir)
+}
+
+// FindCachedResult returns the result of a previous build of the fuzz
+// test if none of the inputs of the build changed since. Otherwise, it
+// returns nil.
+func (b *Builder) FindCachedResult(fuzzTest string) (*build.Result, error) {
+	if b.ProjectDir == "" {
+		return nil, nil
+	}
+	inputs, err := b.cacheInputs()
+	if err != nil {
+		return nil, err
+	}
+	results, err := build.NewCache(b.ProjectDir, "other").Lookup(inputs, []string{fuzzTest})
+	if err != nil || results == nil {
+		return nil, err
+	}
+	return results[fuzzTest], nil
+}
+
+func (b *Builder) storeCachedResult(fuzzTest string, result *build.Result) error {
+	inputs, err := b.cacheInputs()
+	if err != nil {
+		return err
+	}
+	return build.NewCache(b.ProjectDir, "other").Store(inputs, map[string]*build.Result{fuzzTest: result})
+}
+
+// cacheInputs returns the inputs of the build. We don't know which
+// files the build command reads, so all source and build files in the
+// working directory, in which the build command is executed, are
+// considered as inputs.
+func (b *Builder) cacheInputs() (*build.CacheInputs, error) {
+	files, err := build.SourceFiles(".")
+	if err != nil {
+		return nil, err
+	}
+	// The environment contains the path of the temporary build
+	// directory, which is different for each build
+	env := make([]string, len(b.env))
+	for i, e := range b.env {
+		env[i] = strings.ReplaceAll(e, b.buildDir, "$CIFUZZ_BUILD_DIR")
+	}
+	return &build.CacheInputs{
+		Engine:     b.Engine,
+		Sanitizers: b.Sanitizers,
+		Env:        env,
+		Extra:      []string{b.BuildCommand, b.BuildOutput},
+		Files:      files,
 	}, nil
 }
 
@@ -154,12 +235,50 @@ var commonCFlags = []string{
 	"-DFUZZING_BUILD_MODE_UNSAFE_FOR_PRODUCTION",
 }
 
-func (b *Builder) setLibFuzzerEnv() error {
-	var err error
+// sanitizerCFlags returns the compiler flags which are needed to build
+// with the given sanitizers
+func sanitizerCFlags(sanitizers []string) []string {
+	if len(sanitizers) == 0 {
+		return nil
+	}
+	// Build with instrumentation for the sanitizers and link in their
+	// runtime
+	flags := []string{"-fsanitize=" + strings.Join(sanitizers, ",")}
+	if stringutil.Contains(sanitizers, config.SanitizerAddress) {
+		flags = append(flags,
+			// To support recovering from ASan findings
+			"-fsanitize-recover=address",
+			// Use additional error detectors for use-after-scope bugs
+			// TODO: Evaluate the slow down caused by this flag
+			// TODO: Check if there are other additional error detectors
+			//       which we want to use
+			"-fsanitize-address-use-after-scope",
+		)
+	}
+	if stringutil.Contains(sanitizers, config.SanitizerMemory) {
+		// Report where uninitialized values were created, which is
+		// usually more helpful than where they were used
+		flags = append(flags, "-fsanitize-memory-track-origins")
+	}
+	return flags
+}
 
-	// Set CFLAGS and CXXFLAGS. Note that these flags must not contain
-	// spaces, because the environment variables are space separated.
-	//
+// sanitizerLDFlags returns the linker flags which are needed to link
+// the runtimes of the given sanitizers
+func sanitizerLDFlags(sanitizers []string) []string {
+	if len(sanitizers) == 0 {
+		return nil
+	}
+	flags := []string{"-fsanitize=" + strings.Join(sanitizers, ",")}
+	if stringutil.Contains(sanitizers, config.SanitizerUndefined) {
+		// To avoid issues with clang (not clang++) and UBSan, see
+		// https://github.com/bazelbuild/bazel/issues/11122#issuecomment-896613570
+		flags = append(flags, "-fsanitize-link-c++-runtime")
+	}
+	return flags
+}
+
+func (b *Builder) setLibFuzzerEnv() error {
 	// Note: Keep in sync with tools/cmake/CIFuzz/share/CIFuzz/CIFuzzFunctions.cmake
 	cflags := append(commonCFlags, []string{
 		// ----- Flags used to build with libFuzzer -----
@@ -168,19 +287,54 @@ func (b *Builder) setLibFuzzerEnv() error {
 		// CFLAGS are often also passed to the linker, which would cause
 		// errors if the build includes tools which have a main function.
 		"-fsanitize=fuzzer-no-link",
-
-		// ----- Flags used to build with ASan -----
-		// Build with instrumentation for ASan and UBSan and link in
-		// their runtime
-		"-fsanitize=address,undefined",
-		// To support recovering from ASan findings
-		"-fsanitize-recover=address",
-		// Use additional error detectors for use-after-scope bugs
-		// TODO: Evaluate the slow down caused by this flag
-		// TODO: Check if there are other additional error detectors
-		//       which we want to use
-		"-fsanitize-address-use-after-scope",
 	}...)
+	cflags = append(cflags, sanitizerCFlags(b.Sanitizers)...)
+
+	// Link the libFuzzer runtime into the fuzz test
+	return b.setFuzzingEnv(cflags, "-fsanitize=fuzzer")
+}
+
+func (b *Builder) setAFLEnv() error {
+	var err error
+
+	// The AFL++ compiler wrappers add the coverage instrumentation
+	// themselves, so in contrast to libFuzzer, no additional flags
+	// are needed for that.
+	b.env, err = build.SetAFLCompilers(b.env)
+	if err != nil {
+		return err
+	}
+
+	cflags := append(commonCFlags, sanitizerCFlags(b.Sanitizers)...)
+
+	// With "-fsanitize=fuzzer", the AFL++ compiler wrappers link the
+	// AFL++ driver for libFuzzer-style fuzz tests.
+	return b.setFuzzingEnv(cflags, "-fsanitize=fuzzer")
+}
+
+func (b *Builder) setHonggfuzzEnv() error {
+	var err error
+
+	// The honggfuzz compiler wrappers add the coverage instrumentation
+	// and always link libhfuzz, which provides a main function calling
+	// LLVMFuzzerTestOneInput, so no additional flags are needed.
+	b.env, err = build.SetHonggfuzzCompilers(b.env)
+	if err != nil {
+		return err
+	}
+
+	cflags := append(commonCFlags, sanitizerCFlags(b.Sanitizers)...)
+
+	return b.setFuzzingEnv(cflags, "")
+}
+
+// setFuzzingEnv sets the environment variables which are used to build
+// a fuzz test with one of the fuzzing engines and the sanitizers.
+func (b *Builder) setFuzzingEnv(cflags []string, fuzzTestLDFlags string) error {
+	var err error
+
+	// Set CFLAGS and CXXFLAGS. Note that these flags must not contain
+	// spaces, because the environment variables are space separated.
 	b.env, err = envutil.Setenv(b.env, "CFLAGS", strings.Join(cflags, " "))
 	if err != nil {
 		return err
@@ -190,15 +344,7 @@ func (b *Builder) setLibFuzzerEnv() error {
 		return err
 	}
 
-	ldflags := []string{
-		// ----- Flags used to build with ASan -----
-		// Link ASan and UBSan runtime
-		"-fsanitize=address,undefined",
-		// To avoid issues with clang (not clang++) and UBSan, see
-		// https://github.com/bazelbuild/bazel/issues/11122#issuecomment-896613570
-		"-fsanitize-link-c++-runtime",
-	}
-	b.env, err = envutil.Setenv(b.env, "LDFLAGS", strings.Join(ldflags, " "))
+	b.env, err = envutil.Setenv(b.env, "LDFLAGS", strings.Join(sanitizerLDFlags(b.Sanitizers), " "))
 	if err != nil {
 		return err
 	}
@@ -215,9 +361,8 @@ func (b *Builder) setLibFuzzerEnv() error {
 	}
 
 	// Users should pass the environment variable FUZZ_TEST_LDFLAGS to
-	// the linker command building the fuzz test. For libfuzzer, we set
-	// it to "-fsanitize=fuzzer" to build a libfuzzer binary.
-	b.env, err = envutil.Setenv(b.env, "FUZZ_TEST_LDFLAGS", "-fsanitize=fuzzer")
+	// the linker command building the fuzz test.
+	b.env, err = envutil.Setenv(b.env, "FUZZ_TEST_LDFLAGS", fuzzTestLDFlags)
 	if err != nil {
 		return err
 	}
@@ -258,7 +403,27 @@ func (b *Builder) setCoverageEnv() error {
 	return nil
 }
 
+// findFuzzTestExecutable returns the absolute path of the executable
+// of the fuzz test, which is either specified via the build output
+// option, by the fuzz test itself if it's a path, or found by searching
+// the working directory for an executable with the name of the fuzz
+// test.
 func (b *Builder) findFuzzTestExecutable(fuzzTest string) (string, error) {
+	if b.BuildOutput != "" {
+		exists, err := fileutil.Exists(b.BuildOutput)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return "", errors.Errorf("The build output %s of fuzz test %s doesn't exist", b.BuildOutput, fuzzTest)
+		}
+		executable, err := filepath.Abs(b.BuildOutput)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return executable, nil
+	}
+
 	if exists, _ := fileutil.Exists(fuzzTest); exists {
 		executable, err := filepath.Abs(fuzzTest)
 		if err != nil {
@@ -267,68 +432,70 @@ func (b *Builder) findFuzzTestExecutable(fuzzTest string) (string, error) {
 		return executable, nil
 	}
 
-	var executable string
-	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+	name := fuzzTest
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	var executables []string
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		if info.IsDir() {
+		if d.IsDir() {
+			// Skip hidden directories like .git and .cifuzz-build,
+			// which don't contain the output of the build command
+			if path != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != name || !d.Type().IsRegular() {
 			return nil
 		}
-		if runtime.GOOS == "windows" {
-			if info.Name() == fuzzTest+".exe" {
-				executable = path
+		if runtime.GOOS != "windows" {
+			// Verify that the executable candidate has some executable
+			// bit set and is an actual binary, to not pick up scripts or
+			// other files which have the same name as the fuzz test
+			info, err := d.Info()
+			if err != nil {
+				return errors.WithStack(err)
 			}
-		} else {
-			// As a heuristic, verify that the executable candidate has some
-			// executable bit set - it may not be sufficient to actually execute
-			// it as the current user.
-			if info.Name() == fuzzTest && (info.Mode()&0111 != 0) {
-				executable = path
+			if info.Mode()&0111 == 0 || !sharedlibs.IsBinary(path) {
+				return nil
 			}
 		}
+		executables = append(executables, path)
 		return nil
 	})
 	if err != nil {
 		return "", err
 	}
-	if executable == "" {
+	if len(executables) == 0 {
 		return "", errors.Errorf("Could not find executable for fuzz test %s", fuzzTest)
 	}
-	executable, err = filepath.Abs(executable)
+	if len(executables) > 1 {
+		return "", errors.Errorf(`Found multiple executables for fuzz test %s:
+  %s
+Specify the path of the executable via the "build-output" setting`, fuzzTest, strings.Join(executables, "\n  "))
+	}
+	executable, err := filepath.Abs(executables[0])
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 	return executable, nil
 }
 
-var sharedLibraryRegex = regexp.MustCompile(`^.+\.((so)|(dylib))(\.\d\w*)*$`)
-
-func (b *Builder) findSharedLibraries(fuzzTest string) ([]string, error) {
-	// TODO: Only return those libraries which are actually used, and which
-	//       might live outside of the project directory, by parsing the
-	//       shared object dependencies of the executable (we could use
-	//       cmake for that or do it ourselves in Go).
-	var sharedObjects []string
-	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		if info.IsDir() {
-			return nil
-		}
-		// Ignore shared objects in .dSYM directories, to avoid llvm-cov
-		// failing with:
-		//
-		//    Failed to load coverage: Unsupported coverage format version
-		//
-		if strings.Contains(path, "dSYM") {
-			return nil
-		}
-		if sharedLibraryRegex.MatchString(info.Name()) {
-			sharedObjects = append(sharedObjects, path)
-		}
-		return nil
-	})
-	return sharedObjects, err
+// findSharedLibraries returns the shared libraries which are loaded by
+// the dynamic loader for the executable. It prints a warning if any
+// dependency couldn't be resolved.
+func findSharedLibraries(executable string) ([]string, error) {
+	resolved, unresolved, err := sharedlibs.Find(executable)
+	if err != nil {
+		return nil, err
+	}
+	if len(unresolved) > 0 {
+		log.Warnf("The following shared library dependencies of %s could not be resolved:\n  %s",
+			executable, strings.Join(unresolved, "\n  "))
+	}
+	return resolved, nil
 }
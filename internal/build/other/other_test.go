@@ -0,0 +1,91 @@
+package other
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyExecutable copies the test binary, which is an actual executable
+// binary, to the given path
+func copyExecutable(t *testing.T, path string) {
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	src, err := os.Open(executable)
+	require.NoError(t, err)
+	defer src.Close()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0755)
+	require.NoError(t, err)
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	require.NoError(t, err)
+}
+
+func TestFindFuzzTestExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The build system type \"other\" is not supported on Windows")
+	}
+	dir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(oldWd)) }()
+	dir, err = os.Getwd()
+	require.NoError(t, err)
+
+	copyExecutable(t, filepath.Join("build", "my_fuzz_test"))
+	// Scripts, non-executable files and files in hidden directories
+	// are not considered to be the fuzz test executable
+	require.NoError(t, os.MkdirAll("scripts", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("scripts", "my_fuzz_test"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join("src", "my_fuzz_test"), 0755))
+	copyExecutable(t, filepath.Join(".cifuzz-build", "my_fuzz_test"))
+
+	b := &Builder{BuilderOptions: &BuilderOptions{}}
+	executable, err := b.findFuzzTestExecutable("my_fuzz_test")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "build", "my_fuzz_test"), executable)
+
+	// Multiple executables with the name of the fuzz test are ambiguous
+	copyExecutable(t, filepath.Join("out", "my_fuzz_test"))
+	_, err = b.findFuzzTestExecutable("my_fuzz_test")
+	assert.Error(t, err)
+
+	// The build output is used without searching for the executable
+	b.BuildOutput = filepath.Join("out", "my_fuzz_test")
+	executable, err = b.findFuzzTestExecutable("my_fuzz_test")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "out", "my_fuzz_test"), executable)
+
+	b.BuildOutput = filepath.Join("out", "does_not_exist")
+	_, err = b.findFuzzTestExecutable("my_fuzz_test")
+	assert.Error(t, err)
+}
+
+func TestSanitizerFlags(t *testing.T) {
+	assert.Equal(t, []string{
+		"-fsanitize=address,undefined",
+		"-fsanitize-recover=address",
+		"-fsanitize-address-use-after-scope",
+	}, sanitizerCFlags([]string{"address", "undefined"}))
+	assert.Equal(t, []string{
+		"-fsanitize=address,undefined",
+		"-fsanitize-link-c++-runtime",
+	}, sanitizerLDFlags([]string{"address", "undefined"}))
+
+	assert.Equal(t, []string{
+		"-fsanitize=memory",
+		"-fsanitize-memory-track-origins",
+	}, sanitizerCFlags([]string{"memory"}))
+	assert.Equal(t, []string{"-fsanitize=memory"}, sanitizerLDFlags([]string{"memory"}))
+
+	assert.Equal(t, []string{"-fsanitize=thread,undefined"}, sanitizerCFlags([]string{"thread", "undefined"}))
+	assert.Empty(t, sanitizerCFlags(nil))
+	assert.Empty(t, sanitizerLDFlags(nil))
+}
@@ -0,0 +1,434 @@
+// Package sharedlibs finds the shared libraries which are loaded by the
+// dynamic loader for an executable, by reading the dynamic section of
+// ELF files and the load commands of Mach-O files, similar to what
+// ldd and otool -L print.
+package sharedlibs
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+var elfMagic = []byte("\x7fELF")
+
+// The magic numbers of thin and fat Mach-O files, in both byte orders
+var machoMagics = [][]byte{
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+// IsBinary returns true if the file is an ELF or Mach-O file
+func IsBinary(path string) bool {
+	magic, err := readMagic(path)
+	if err != nil {
+		return false
+	}
+	return isELF(magic) || isMachO(magic)
+}
+
+// Find returns the absolute paths of the shared libraries which the
+// dynamic loader loads for the given executable, including the
+// transitive dependencies. The names of the libraries which couldn't
+// be found are returned as unresolved.
+func Find(executable string) (resolved []string, unresolved []string, err error) {
+	executable, err = filepath.Abs(executable)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	magic, err := readMagic(executable)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isELF(magic) {
+		return findELF(executable)
+	}
+	if isMachO(magic) {
+		return findMachO(executable)
+	}
+	return nil, nil, errors.Errorf("%s is neither an ELF nor a Mach-O file", executable)
+}
+
+func readMagic(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	_, err = io.ReadFull(f, magic)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return magic, nil
+}
+
+func isELF(magic []byte) bool {
+	return bytes.Equal(magic, elfMagic)
+}
+
+func isMachO(magic []byte) bool {
+	for _, m := range machoMagics {
+		if bytes.Equal(magic, m) {
+			return true
+		}
+	}
+	return false
+}
+
+type elfObject struct {
+	path    string
+	class   elf.Class
+	machine elf.Machine
+	needed  []string
+	rpath   []string
+	runpath []string
+}
+
+func readELF(path string) (*elfObject, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	obj := &elfObject{path: path, class: f.Class, machine: f.Machine}
+	obj.needed, err = f.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rpath, err := f.DynString(elf.DT_RPATH)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	runpath, err := f.DynString(elf.DT_RUNPATH)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	obj.rpath = searchPaths(rpath, path)
+	obj.runpath = searchPaths(runpath, path)
+	return obj, nil
+}
+
+// searchPaths splits the values of DT_RPATH or DT_RUNPATH entries into
+// directories and expands $ORIGIN to the directory of the object
+func searchPaths(values []string, objectPath string) []string {
+	origin := filepath.Dir(objectPath)
+	if resolved, err := filepath.EvalSymlinks(objectPath); err == nil {
+		origin = filepath.Dir(resolved)
+	}
+	var dirs []string
+	for _, value := range values {
+		for _, dir := range strings.Split(value, ":") {
+			if dir == "" {
+				continue
+			}
+			dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+			dir = strings.ReplaceAll(dir, "$ORIGIN", origin)
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// findELF resolves the DT_NEEDED entries of the executable and its
+// dependencies in the same order as the glibc dynamic loader: DT_RPATH
+// of the loading objects (unless the object has a DT_RUNPATH),
+// LD_LIBRARY_PATH, DT_RUNPATH and the default library directories.
+func findELF(executable string) ([]string, []string, error) {
+	exe, err := readELF(executable)
+	if err != nil {
+		return nil, nil, err
+	}
+	ldLibraryPath := filepath.SplitList(os.Getenv("LD_LIBRARY_PATH"))
+	defaultDirs := defaultLibraryDirs()
+
+	type item struct {
+		obj *elfObject
+		// The DT_RPATH entries of the objects which caused this object
+		// to be loaded, which are also searched for its dependencies
+		inheritedRPath []string
+	}
+
+	var resolved, unresolved []string
+	// Like the dynamic loader, we load each library name only once
+	seen := make(map[string]bool)
+	queue := []item{{obj: exe}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		obj := current.obj
+
+		rpath := append(append([]string{}, obj.rpath...), current.inheritedRPath...)
+		var dirs []string
+		if len(obj.runpath) == 0 {
+			dirs = append(dirs, rpath...)
+		}
+		dirs = append(dirs, ldLibraryPath...)
+		dirs = append(dirs, obj.runpath...)
+		dirs = append(dirs, defaultDirs...)
+
+		for _, name := range obj.needed {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			lib := findELFLibrary(name, dirs, exe)
+			if lib == nil {
+				unresolved = append(unresolved, name)
+				continue
+			}
+			resolved = append(resolved, lib.path)
+			queue = append(queue, item{obj: lib, inheritedRPath: rpath})
+		}
+	}
+	return resolved, unresolved, nil
+}
+
+// findELFLibrary returns the first library with the given name in the
+// directories which matches the class and machine of the executable
+func findELFLibrary(name string, dirs []string, exe *elfObject) *elfObject {
+	var candidates []string
+	if strings.Contains(name, "/") {
+		candidates = []string{name}
+	} else {
+		for _, dir := range dirs {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+	for _, candidate := range candidates {
+		if exists, _ := fileutil.Exists(candidate); !exists {
+			continue
+		}
+		candidate, err := filepath.Abs(candidate)
+		if err != nil {
+			continue
+		}
+		lib, err := readELF(candidate)
+		if err != nil {
+			continue
+		}
+		if lib.class != exe.class || lib.machine != exe.machine {
+			continue
+		}
+		return lib
+	}
+	return nil
+}
+
+// defaultLibraryDirs returns the directories which the dynamic loader
+// searches after the ones specified by the executable and the
+// environment, i.e. the ones configured in /etc/ld.so.conf, followed
+// by the trusted directories.
+func defaultLibraryDirs() []string {
+	dirs := parseLdSoConf("/etc/ld.so.conf", make(map[string]bool))
+	return append(dirs, "/lib64", "/usr/lib64", "/lib", "/usr/lib")
+}
+
+// parseLdSoConf returns the directories listed in the ld.so.conf file,
+// including the ones of the files which are included. Errors are
+// ignored, because the file is not required to exist.
+func parseLdSoConf(path string, visited map[string]bool) []string {
+	if visited[path] {
+		return nil
+	}
+	visited[path] = true
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "include ") {
+			pattern := strings.TrimSpace(strings.TrimPrefix(line, "include "))
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				dirs = append(dirs, parseLdSoConf(match, visited)...)
+			}
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+type machoObject struct {
+	path   string
+	dylibs []string
+	rpath  []string
+}
+
+func readMachO(path string) (*machoObject, error) {
+	f, closer, err := openMachO(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	obj := &machoObject{path: path}
+	obj.dylibs, err = f.ImportedLibraries()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, load := range f.Loads {
+		if rpath, ok := load.(*macho.Rpath); ok {
+			obj.rpath = append(obj.rpath, rpath.Path)
+		}
+	}
+	return obj, nil
+}
+
+// openMachO opens a thin Mach-O file or the architecture of a fat
+// Mach-O file which matches the current architecture. The returned
+// closer must be closed after the file was read.
+func openMachO(path string) (*macho.File, io.Closer, error) {
+	fat, err := macho.OpenFat(path)
+	if err == nil {
+		if len(fat.Arches) == 0 {
+			fat.Close()
+			return nil, nil, errors.Errorf("%s doesn't contain any architecture", path)
+		}
+		arch := fat.Arches[0].File
+		for _, a := range fat.Arches {
+			if (a.Cpu == macho.CpuAmd64 && runtime.GOARCH == "amd64") ||
+				(a.Cpu == macho.CpuArm64 && runtime.GOARCH == "arm64") {
+				arch = a.File
+			}
+		}
+		return arch, fat, nil
+	}
+	if !errors.Is(err, macho.ErrNotFat) {
+		return nil, nil, errors.WithStack(err)
+	}
+	f, err := macho.Open(path)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	return f, f, nil
+}
+
+// findMachO resolves the dylibs loaded by the executable and its
+// dependencies. The @rpath, @loader_path and @executable_path prefixes
+// are expanded like by dyld. System libraries which are only contained
+// in the dyld shared cache are returned without resolving their
+// dependencies.
+func findMachO(executable string) ([]string, []string, error) {
+	exe, err := readMachO(executable)
+	if err != nil {
+		return nil, nil, err
+	}
+	executableDir := filepath.Dir(executable)
+
+	type item struct {
+		obj *machoObject
+		// The LC_RPATH entries of the objects which caused this object
+		// to be loaded, which are also searched for its dependencies
+		inheritedRPath []string
+	}
+
+	var resolved, unresolved []string
+	seen := make(map[string]bool)
+	queue := []item{{obj: exe}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		obj := current.obj
+
+		expand := func(path string) string {
+			path = strings.Replace(path, "@executable_path", executableDir, 1)
+			return strings.Replace(path, "@loader_path", filepath.Dir(obj.path), 1)
+		}
+		var rpath []string
+		for _, dir := range obj.rpath {
+			rpath = append(rpath, expand(dir))
+		}
+		rpath = append(rpath, current.inheritedRPath...)
+
+		for _, name := range obj.dylibs {
+			var candidates []string
+			if strings.HasPrefix(name, "@rpath/") {
+				for _, dir := range rpath {
+					candidates = append(candidates, filepath.Join(dir, strings.TrimPrefix(name, "@rpath/")))
+				}
+			} else {
+				candidates = []string{expand(name)}
+			}
+
+			lib := findMachOLibrary(candidates)
+			if lib == nil {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				if isMacOSSystemLibrary(name) {
+					resolved = append(resolved, name)
+				} else {
+					unresolved = append(unresolved, name)
+				}
+				continue
+			}
+			if seen[lib.path] {
+				continue
+			}
+			seen[lib.path] = true
+			resolved = append(resolved, lib.path)
+			queue = append(queue, item{obj: lib, inheritedRPath: rpath})
+		}
+	}
+	return resolved, unresolved, nil
+}
+
+// findMachOLibrary returns the first of the candidate paths which is a
+// Mach-O file
+func findMachOLibrary(candidates []string) *machoObject {
+	for _, candidate := range candidates {
+		if exists, _ := fileutil.Exists(candidate); !exists {
+			continue
+		}
+		candidate, err := filepath.Abs(candidate)
+		if err != nil {
+			continue
+		}
+		lib, err := readMachO(candidate)
+		if err != nil {
+			continue
+		}
+		return lib
+	}
+	return nil
+}
+
+// Since macOS 11, the system libraries don't exist as files anymore
+// but are only contained in the dyld shared cache
+func isMacOSSystemLibrary(path string) bool {
+	return strings.HasPrefix(path, "/usr/lib/") || strings.HasPrefix(path, "/System/Library/")
+}
@@ -0,0 +1,73 @@
+package sharedlibs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchPaths(t *testing.T) {
+	dir := t.TempDir()
+	object := filepath.Join(dir, "fuzz_test")
+	require.NoError(t, os.WriteFile(object, []byte{}, 0755))
+	// The temporary directory might be a symlink, e.g. on macOS
+	dir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	dirs := searchPaths([]string{"$ORIGIN/../lib:${ORIGIN}", "/opt/lib"}, object)
+	assert.Equal(t, []string{dir + "/../lib", dir, "/opt/lib"}, dirs)
+}
+
+func TestParseLdSoConf(t *testing.T) {
+	dir := t.TempDir()
+	confDir := filepath.Join(dir, "ld.so.conf.d")
+	require.NoError(t, os.MkdirAll(confDir, 0755))
+	conf := filepath.Join(dir, "ld.so.conf")
+	require.NoError(t, os.WriteFile(conf, []byte("# comment\n/usr/local/lib\ninclude ld.so.conf.d/*.conf\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "x86_64.conf"), []byte("/lib/x86_64-linux-gnu # multiarch\n"), 0644))
+
+	dirs := parseLdSoConf(conf, make(map[string]bool))
+	assert.Equal(t, []string{"/usr/local/lib", "/lib/x86_64-linux-gnu"}, dirs)
+
+	assert.Empty(t, parseLdSoConf(filepath.Join(dir, "does-not-exist"), make(map[string]bool)))
+}
+
+func TestIsBinary(t *testing.T) {
+	textFile := filepath.Join(t.TempDir(), "fuzz_test")
+	require.NoError(t, os.WriteFile(textFile, []byte("#!/bin/sh\n"), 0755))
+	assert.False(t, IsBinary(textFile))
+
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		assert.True(t, IsBinary(executable))
+	}
+}
+
+func TestFind_ELF(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ELF files are only available on Linux")
+	}
+	// Use a dynamically linked executable which is available on all
+	// Linux systems
+	resolved, unresolved, err := Find("/bin/sh")
+	require.NoError(t, err)
+	if len(resolved) == 0 && len(unresolved) == 0 {
+		t.Skip("/bin/sh is statically linked")
+	}
+	assert.Empty(t, unresolved)
+
+	var foundLibc bool
+	for _, lib := range resolved {
+		assert.True(t, filepath.IsAbs(lib), lib)
+		if strings.HasPrefix(filepath.Base(lib), "libc.") {
+			foundLibc = true
+		}
+	}
+	assert.True(t, foundLibc, "libc not found in %v", resolved)
+}
@@ -15,9 +15,11 @@ import (
 
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/meson"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/artifact"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/vcs"
 	"code-intelligence.com/cifuzz/util/fileutil"
@@ -65,6 +67,7 @@ type bundleCmd struct {
 type bundleOpts struct {
 	fuzzTests  []string
 	outputPath string
+	sanitizers []string
 }
 
 type configureVariant struct {
@@ -72,6 +75,14 @@ type configureVariant struct {
 	Sanitizers []string
 }
 
+// configurableBuilder is implemented by the builders of all build
+// systems which are supported by cifuzz bundle
+type configurableBuilder interface {
+	Configure() error
+	ListFuzzTests() ([]string, error)
+	Build(fuzzTests []string) (map[string]*build.Result, error)
+}
+
 func New(conf *config.Config) *cobra.Command {
 	opts := &bundleOpts{}
 	cmd := &cobra.Command{
@@ -81,10 +92,18 @@ func New(conf *config.Config) *cobra.Command {
 		ValidArgsFunction: completion.ValidFuzzTests,
 		Args:              cobra.ArbitraryArgs,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if conf.BuildSystem != config.BuildSystemCMake {
-				return errors.New("cifuzz bundle currently only supports CMake projects")
+			if conf.BuildSystem != config.BuildSystemCMake && conf.BuildSystem != config.BuildSystemMeson {
+				return errors.New("cifuzz bundle currently only supports CMake and Meson projects")
 			}
 			opts.fuzzTests = args
+			if len(opts.sanitizers) == 0 {
+				opts.sanitizers = conf.Sanitizers
+			} else {
+				err := config.ValidateSanitizers(opts.sanitizers)
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+			}
 			return nil
 		},
 		RunE: func(c *cobra.Command, args []string) error {
@@ -98,6 +117,7 @@ func New(conf *config.Config) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&opts.outputPath, "output", "o", "", "Output path of the artifact (.tar.gz)")
+	cmd.Flags().StringSliceVar(&opts.sanitizers, "sanitizers", nil, "Comma-separated list of sanitizers to build the fuzz tests with.\nDefaults to the sanitizers configured in cifuzz.yaml.")
 
 	return cmd
 }
@@ -111,16 +131,6 @@ func (c *bundleCmd) run() (err error) {
 		}
 	}
 
-	// TODO: Do not hardcode these values.
-	sanitizers := []string{"address"}
-	// UBSan is not supported by MSVC
-	// TODO: Not needed anymore when sanitizers are configurable,
-	//       then we do want to fail if the user explicitly asked for
-	//       UBSan.
-	if runtime.GOOS != "windows" {
-		sanitizers = append(sanitizers, "undefined")
-	}
-
 	allVariantBuildResults, err := c.buildAllVariants()
 	if err != nil {
 		return err
@@ -210,16 +220,9 @@ func (c *bundleCmd) run() (err error) {
 
 func (c *bundleCmd) buildAllVariants() ([]map[string]*build.Result, error) {
 	fuzzingVariant := configureVariant{
-		// TODO: Do not hardcode these values.
+		// TODO: Do not hardcode this value.
 		Engine:     "libfuzzer",
-		Sanitizers: []string{"address"},
-	}
-	// UBSan is not supported by MSVC.
-	// TODO: Not needed anymore when sanitizers are configurable,
-	//       then we do want to fail if the user explicitly asked for
-	//       UBSan.
-	if runtime.GOOS != "windows" {
-		fuzzingVariant.Sanitizers = append(fuzzingVariant.Sanitizers, "undefined")
+		Sanitizers: c.opts.sanitizers,
 	}
 	configureVariants := []configureVariant{fuzzingVariant}
 
@@ -234,14 +237,7 @@ func (c *bundleCmd) buildAllVariants() ([]map[string]*build.Result, error) {
 
 	var allVariantBuildResults []map[string]*build.Result
 	for _, variant := range configureVariants {
-		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
-			ProjectDir:      c.config.ProjectDir,
-			Engine:          variant.Engine,
-			Sanitizers:      variant.Sanitizers,
-			Stdout:          c.OutOrStdout(),
-			Stderr:          c.ErrOrStderr(),
-			FindRuntimeDeps: true,
-		})
+		builder, err := c.newBuilder(variant)
 		if err != nil {
 			return nil, err
 		}
@@ -279,6 +275,35 @@ func (c *bundleCmd) buildAllVariants() ([]map[string]*build.Result, error) {
 	return allVariantBuildResults, nil
 }
 
+func (c *bundleCmd) newBuilder(variant configureVariant) (configurableBuilder, error) {
+	if c.config.BuildSystem == config.BuildSystemMeson {
+		builder, err := meson.NewBuilder(&meson.BuilderOptions{
+			ProjectDir:      c.config.ProjectDir,
+			Engine:          variant.Engine,
+			Sanitizers:      variant.Sanitizers,
+			Stdout:          c.OutOrStdout(),
+			Stderr:          c.ErrOrStderr(),
+			FindRuntimeDeps: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder, nil
+	}
+	builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+		ProjectDir:      c.config.ProjectDir,
+		Engine:          variant.Engine,
+		Sanitizers:      variant.Sanitizers,
+		Stdout:          c.OutOrStdout(),
+		Stderr:          c.ErrOrStderr(),
+		FindRuntimeDeps: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builder, nil
+}
+
 func assembleArtifacts(fuzzTest string, buildResult *build.Result, projectDir string) (
 	fuzzers []*artifact.Fuzzer,
 	archiveManifest map[string]string,
@@ -407,6 +432,13 @@ depsLoop:
 		fuzzer.Sanitizer = strings.ToUpper(sanitizer)
 		fuzzers = append(fuzzers, &fuzzer)
 	}
+	if len(fuzzers) == 0 {
+		// Standalone UBSan builds are added as libFuzzer fuzzers
+		// without a sanitizer
+		fuzzer := baseFuzzerInfo
+		fuzzer.Engine = "LIBFUZZER"
+		fuzzers = append(fuzzers, &fuzzer)
+	}
 
 	return
 }
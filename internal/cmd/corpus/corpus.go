@@ -0,0 +1,233 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/fuzztests"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+)
+
+// corpusOptions are the options shared by the corpus subcommands,
+// which all have to build the fuzz test to execute it on the corpus.
+type corpusOptions struct {
+	BuildSystem  string   `mapstructure:"build-system"`
+	BuildCommand string   `mapstructure:"build-command"`
+	BuildOutput  string   `mapstructure:"build-output"`
+	EngineArgs   []string `mapstructure:"engine-args"`
+	FuzzTestArgs []string `mapstructure:"fuzz-test-args"`
+	Sanitizers   []string `mapstructure:"sanitizers"`
+	UseSandbox   bool     `mapstructure:"use-sandbox"`
+
+	ProjectDir string
+	fuzzTest   string
+	rebuild    bool
+}
+
+func (opts *corpusOptions) validate() error {
+	var err error
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = config.ValidateBuildSystem(opts.BuildSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The corpus is managed with libFuzzer, which can't execute the
+	// fuzz tests of Java projects
+	if config.IsJavaBuildSystem(opts.BuildSystem) {
+		msg := fmt.Sprintf("Build system \"%s\" is not supported by this command", opts.BuildSystem)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	// To build with other build systems, a build command must be provided
+	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
+		msg := `Flag "build-command" must be set when using the build system type "other"`
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if len(opts.Sanitizers) == 0 {
+		opts.Sanitizers = config.DefaultSanitizers()
+	} else {
+		err = config.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	return nil
+}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "corpus",
+		Short: "Manage the corpus of fuzz tests",
+		Long: "The generated corpus of a fuzz test is stored in the .cifuzz-corpus\n" +
+			"directory of the project. Use the subcommands to import inputs into it\n" +
+			"and to keep it small.",
+		Args: cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newMinimizeCmd())
+	cmd.AddCommand(newImportCmd())
+
+	return cmd
+}
+
+// addCorpusFlags adds the flags of the options shared by the corpus
+// subcommands to cmd.
+func addCorpusFlags(cmd *cobra.Command, opts *corpusOptions) {
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in bindCorpusFlags.
+	cmd.Flags().String("build-command", "", `The command to build the fuzz test. Example: "make clean && make my-fuzz-test"`)
+	cmd.Flags().String("build-output", "", "The path of the fuzz test executable built by the build command,\nif the build system type is \"other\". Example: \"build/my_fuzz_test\"")
+	cmd.Flags().StringArray("engine-arg", nil, "Command-line argument to pass to the fuzzing engine.\nSee https://llvm.org/docs/LibFuzzer.html#options.")
+	cmd.Flags().StringArray("fuzz-test-arg", nil, "Command-line argument to pass to the fuzz test.")
+	cmd.Flags().StringSlice("sanitizers", nil, fmt.Sprintf("Comma-separated list of sanitizers to build the fuzz test with.\nValid sanitizers: %s. Default: %s.", strings.Join(config.SupportedSanitizers, ", "), strings.Join(config.DefaultSanitizers(), ",")))
+	cmd.Flags().BoolVar(&opts.rebuild, "rebuild", false, "Build the fuzz test even if its sources didn't change since the last build.")
+	cmd.Flags().Bool("use-sandbox", false, "By default, fuzz tests are executed in a sandbox to prevent accidental damage to the system.\nUse --use-sandbox=false to run the fuzz test unsandboxed.\nOnly supported on Linux.")
+	viper.SetDefault("use-sandbox", runtime.GOOS == "linux")
+}
+
+// bindCorpusFlags binds the viper keys to the flags added by
+// addCorpusFlags. This must be called in the PreRunE function, because
+// calling it earlier would re-bind viper keys which were bound to the
+// flags of other commands before.
+func bindCorpusFlags(cmd *cobra.Command) {
+	cmdutils.ViperMustBindPFlag("build-command", cmd.Flags().Lookup("build-command"))
+	cmdutils.ViperMustBindPFlag("build-output", cmd.Flags().Lookup("build-output"))
+	cmdutils.ViperMustBindPFlag("engine-args", cmd.Flags().Lookup("engine-arg"))
+	cmdutils.ViperMustBindPFlag("fuzz-test-args", cmd.Flags().Lookup("fuzz-test-arg"))
+	cmdutils.ViperMustBindPFlag("sanitizers", cmd.Flags().Lookup("sanitizers"))
+	cmdutils.ViperMustBindPFlag("use-sandbox", cmd.Flags().Lookup("use-sandbox"))
+}
+
+func buildFuzzTest(cmd *cobra.Command, opts *corpusOptions) (*build.Result, error) {
+	log.Infof("Building %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(opts.fuzzTest))
+
+	_, buildResults, err := fuzztests.Build(&fuzztests.BuildOptions{
+		ProjectDir:   opts.ProjectDir,
+		BuildSystem:  opts.BuildSystem,
+		BuildCommand: opts.BuildCommand,
+		BuildOutput:  opts.BuildOutput,
+		Engine:       string(config.LIBFUZZER),
+		Sanitizers:   opts.Sanitizers,
+		Stdout:       cmd.OutOrStdout(),
+		Stderr:       cmd.ErrOrStderr(),
+		FuzzTests:    []string{opts.fuzzTest},
+		Rebuild:      opts.rebuild,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buildResults[opts.fuzzTest], nil
+}
+
+// merge uses libFuzzer to merge those inputs of the input directories
+// into the output directory which add coverage. It returns the number
+// of inputs which crashed the fuzz test, those are not merged. The
+// optional control file allows resuming an interrupted merge.
+func merge(opts *corpusOptions, buildResult *build.Result, outputDir string, inputDirs []string, controlFile string) (int, error) {
+	// Ensure that symlinks are resolved to be able to add minijail
+	// bindings for the corpus dirs.
+	outputDir, err := filepath.EvalSymlinks(outputDir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	var resolvedInputDirs []string
+	for _, dir := range inputDirs {
+		dir, err = filepath.EvalSymlinks(dir)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		resolvedInputDirs = append(resolvedInputDirs, dir)
+	}
+	if controlFile != "" {
+		controlFileDir, err := filepath.EvalSymlinks(filepath.Dir(controlFile))
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		controlFile = filepath.Join(controlFileDir, filepath.Base(controlFile))
+	}
+
+	handler := &findingCounter{}
+	runner := libfuzzer.NewRunner(&libfuzzer.RunnerOptions{
+		FuzzTarget:    buildResult.Executable,
+		EngineArgs:    opts.EngineArgs,
+		FuzzTestArgs:  opts.FuzzTestArgs,
+		ReportHandler: handler,
+		UseMinijail:   opts.UseSandbox,
+		Verbose:       viper.GetBool("verbose"),
+		KeepColor:     true,
+	})
+	err = runner.Merge(context.Background(), outputDir, resolvedInputDirs, controlFile)
+	if err != nil {
+		var execErr *cmdutils.ExecError
+		if errors.As(err, &execErr) {
+			// It's expected that libFuzzer might fail due to user
+			// configuration, so we print the error without the stack trace.
+			log.Error(err)
+			return 0, cmdutils.ErrSilent
+		}
+		return 0, err
+	}
+	return handler.numFindings, nil
+}
+
+// findingCounter is a report.Handler which counts the findings it
+// receives. libFuzzer skips inputs which crash the fuzz test when
+// merging corpora, so we only have to tell the user about them.
+type findingCounter struct {
+	numFindings int
+}
+
+func (c *findingCounter) Handle(r *report.Report) error {
+	if r.Finding != nil {
+		c.numFindings++
+	}
+	return nil
+}
+
+// corpusStats returns the number of files in the corpus directory and
+// their total size in bytes.
+func corpusStats(dir string) (numFiles int, size int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		numFiles++
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	return numFiles, size, nil
+}
@@ -0,0 +1,160 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+)
+
+func TestMinimizeCmd(t *testing.T) {
+	_, err := cmdutils.ExecuteCommand(t, newMinimizeCmd(), os.Stdin)
+	assert.Error(t, err)
+}
+
+func TestCorpusStats(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), []byte("foo"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b"), []byte("barbaz"), 0644))
+
+	numFiles, size, err := corpusStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, numFiles)
+	assert.EqualValues(t, 9, size)
+}
+
+func TestReplaceDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "corpus")
+	newDir := filepath.Join(tmpDir, "corpus-minimized")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old"), nil, 0644))
+	require.NoError(t, os.Mkdir(newDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(newDir, "new"), nil, 0644))
+
+	oldDir := filepath.Join(tmpDir, "corpus-old")
+
+	err := replaceDir(dir, newDir, oldDir)
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(dir, "new"))
+	assert.NoFileExists(t, filepath.Join(dir, "old"))
+	assert.NoDirExists(t, newDir)
+	assert.NoDirExists(t, oldDir)
+}
+
+func TestRecoverCorpus(t *testing.T) {
+	projectDir := t.TempDir()
+	corpusDir := cmdutils.GeneratedCorpusDir(projectDir, "my_fuzz_test")
+	stagingDir := minimizeStagingDir(projectDir, "my_fuzz_test")
+
+	// Interrupted after moving the corpus out of the way
+	require.NoError(t, os.MkdirAll(filepath.Join(stagingDir, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "old", "input"), nil, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(stagingDir, "minimized"), 0755))
+
+	err := recoverCorpus(corpusDir, stagingDir)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(corpusDir, "input"))
+	assert.NoDirExists(t, stagingDir)
+
+	// Interrupted after replacing the corpus, but before removing the
+	// original one
+	require.NoError(t, os.MkdirAll(filepath.Join(stagingDir, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "old", "stale"), nil, 0644))
+
+	err = recoverCorpus(corpusDir, stagingDir)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(corpusDir, "input"))
+	assert.NoFileExists(t, filepath.Join(corpusDir, "stale"))
+	assert.NoDirExists(t, stagingDir)
+
+	// Nothing to recover
+	err = recoverCorpus(corpusDir, stagingDir)
+	require.NoError(t, err)
+}
+
+func TestImportCmd(t *testing.T) {
+	_, err := cmdutils.ExecuteCommand(t, newImportCmd(), os.Stdin, "my_fuzz_test")
+	assert.Error(t, err)
+}
+
+func TestStageImport(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	importDir := filepath.Join(t.TempDir(), "import")
+	stagingDir := filepath.Join(importDir, "inputs")
+
+	// The target already contains an input with the same content as
+	// one of the source inputs, under a different name
+	require.NoError(t, os.WriteFile(filepath.Join(targetDir, "existing"), []byte("foo"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "a"), []byte("foo"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(sourceDir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "sub", "b"), []byte("bar"), 0644))
+	// Duplicates within the source are only imported once
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "c"), []byte("bar"), 0644))
+
+	numInputs, numNew, resumed, err := stageImport(sourceDir, targetDir, importDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, numInputs)
+	assert.Equal(t, 1, numNew)
+	assert.False(t, resumed)
+
+	// The SHA-1 hash of "bar"
+	content, err := os.ReadFile(filepath.Join(stagingDir, "62cdb7020ff920e5aa642c3d4066950dd1f01f4d"))
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(content))
+
+	numImported, err := copyInputs(stagingDir, targetDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numImported)
+	assert.FileExists(t, filepath.Join(targetDir, "62cdb7020ff920e5aa642c3d4066950dd1f01f4d"))
+}
+
+func TestStageImport_Resume(t *testing.T) {
+	sourceDir := t.TempDir()
+	targetDir := t.TempDir()
+	importDir := filepath.Join(t.TempDir(), "import")
+	stagingDir := filepath.Join(importDir, "inputs")
+	controlFile := filepath.Join(importDir, "merge-control")
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "a"), []byte("foo"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "b"), []byte("bar"), 0644))
+
+	_, numNew, resumed, err := stageImport(sourceDir, targetDir, importDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, numNew)
+	assert.False(t, resumed)
+
+	// Interrupt the merge after libFuzzer created the control file
+	require.NoError(t, os.WriteFile(controlFile, []byte("2\n0\n"), 0644))
+	// The SHA-1 hash of "foo"
+	staged := filepath.Join(stagingDir, "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33")
+	stagedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(staged, stagedAt, stagedAt))
+
+	// Importing the same inputs again keeps the staged inputs and the
+	// control file, so that libFuzzer resumes the merge
+	numInputs, numNew, resumed, err := stageImport(sourceDir, targetDir, importDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, numInputs)
+	assert.Equal(t, 2, numNew)
+	assert.True(t, resumed)
+	assert.FileExists(t, controlFile)
+	info, err := os.Stat(staged)
+	require.NoError(t, err)
+	assert.True(t, stagedAt.Equal(info.ModTime()))
+
+	// Importing other inputs starts over
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "c"), []byte("baz"), 0644))
+	_, numNew, resumed, err = stageImport(sourceDir, targetDir, importDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, numNew)
+	assert.False(t, resumed)
+	assert.NoFileExists(t, controlFile)
+}
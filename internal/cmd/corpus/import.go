@@ -0,0 +1,372 @@
+package corpus
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/artifact"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type importCmd struct {
+	*cobra.Command
+	opts *corpusOptions
+
+	source       string
+	seedCorpus   bool
+	coverageOnly bool
+}
+
+func newImportCmd() *cobra.Command {
+	opts := &corpusOptions{}
+	var seedCorpus, coverageOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "import [flags] <fuzz test> <dir|archive>",
+		Short: "Import inputs into the corpus of a fuzz test",
+		Long: "Copies the inputs from a directory, a .tar.gz or a .zip archive into the\n" +
+			"generated corpus of the fuzz test, skipping inputs which are already part\n" +
+			"of the corpus. With --coverage-only, the fuzz test is built and only those\n" +
+			"inputs are imported which add coverage to the corpus.",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completion.ValidFuzzTests(cmd, args, toComplete)
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		Args: cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			bindCorpusFlags(cmd)
+
+			projectDir, err := config.ParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.ProjectDir = projectDir
+
+			opts.fuzzTest = args[0]
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := importCmd{
+				Command:      c,
+				opts:         opts,
+				source:       args[1],
+				seedCorpus:   seedCorpus,
+				coverageOnly: coverageOnly,
+			}
+			return cmd.run()
+		},
+	}
+
+	addCorpusFlags(cmd, opts)
+	cmd.Flags().BoolVar(&seedCorpus, "seed-corpus", false, "Import the inputs into the default seed corpus of the fuzz test\ninstead of the generated corpus.")
+	cmd.Flags().BoolVar(&coverageOnly, "coverage-only", false, "Only import inputs which add coverage to the corpus.")
+
+	return cmd
+}
+
+func (c *importCmd) run() error {
+	sourceDir, cleanup, err := c.sourceDir()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// The fuzz test only has to be built to determine its seed corpus
+	// directory and to execute it on the inputs
+	var buildResult *build.Result
+	if c.seedCorpus || c.coverageOnly {
+		buildResult, err = buildFuzzTest(c.Command, c.opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	var targetDir string
+	if c.seedCorpus {
+		targetDir = buildResult.SeedCorpus
+	} else {
+		targetDir = cmdutils.GeneratedCorpusDir(c.opts.ProjectDir, c.opts.fuzzTest)
+	}
+	err = os.MkdirAll(targetDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Copy the inputs which are not part of the corpus yet to a staging
+	// directory, so that we can either move them to the corpus or let
+	// libFuzzer decide which of them add coverage
+	importDir := importStagingDir(c.opts.ProjectDir, c.opts.fuzzTest)
+	stagingDir := filepath.Join(importDir, "inputs")
+	controlFile := filepath.Join(importDir, "merge-control")
+	numInputs, numNew, resumed, err := stageImport(sourceDir, targetDir, importDir)
+	if err != nil {
+		return err
+	}
+	// The staged inputs and the control file are kept if the merge
+	// fails, to be able to resume it
+	keepStaged := false
+	defer func() {
+		if !keepStaged {
+			fileutil.Cleanup(importDir)
+		}
+	}()
+	numDuplicates := numInputs - numNew
+
+	var numImported int
+	if c.coverageOnly && numNew > 0 {
+		numBefore, _, err := corpusStats(targetDir)
+		if err != nil {
+			return err
+		}
+		if resumed {
+			log.Info("Resuming the interrupted import")
+		}
+		numCrashes, err := merge(c.opts, buildResult, targetDir, []string{stagingDir}, controlFile)
+		if err != nil {
+			keepStaged = true
+			return err
+		}
+		if numCrashes > 0 {
+			log.Warnf("Skipped %d inputs which crash the fuzz test", numCrashes)
+		}
+		numAfter, _, err := corpusStats(targetDir)
+		if err != nil {
+			return err
+		}
+		numImported = numAfter - numBefore
+	} else {
+		numImported, err = copyInputs(stagingDir, targetDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	msg := "Imported %d of %d inputs into %s (%d duplicates"
+	args := []interface{}{numImported, numInputs, fileutil.PrettifyPath(targetDir), numDuplicates}
+	if c.coverageOnly {
+		msg += ", %d without new coverage"
+		args = append(args, numNew-numImported)
+	}
+	log.Successf(msg+")", args...)
+	return nil
+}
+
+// importStagingDir returns the directory in which the inputs to import
+// into the corpus of the fuzz test are staged
+func importStagingDir(projectDir, fuzzTest string) string {
+	return filepath.Join(projectDir, ".cifuzz-build", "corpus-import", fuzzTest)
+}
+
+// sourceDir returns the directory which contains the inputs to import,
+// extracting the source first if it's an archive. The returned cleanup
+// function removes the extracted files.
+func (c *importCmd) sourceDir() (string, func(), error) {
+	noop := func() {}
+	if fileutil.IsDir(c.source) {
+		return c.source, noop, nil
+	}
+
+	var extract func(dir string) error
+	switch {
+	case strings.HasSuffix(c.source, ".tar.gz") || strings.HasSuffix(c.source, ".tgz"):
+		extract = func(dir string) error {
+			file, err := os.Open(c.source)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			defer file.Close()
+			return artifact.ExtractArchive(file, dir)
+		}
+	case strings.HasSuffix(c.source, ".zip"):
+		extract = func(dir string) error {
+			return artifact.ExtractZipArchive(c.source, dir)
+		}
+	default:
+		exists, err := fileutil.Exists(c.source)
+		if err != nil {
+			return "", nil, err
+		}
+		if !exists {
+			err = errors.Errorf("%s does not exist", c.source)
+			log.Error(err, err.Error())
+			return "", nil, cmdutils.ErrSilent
+		}
+		msg := "%s is neither a directory nor a .tar.gz or .zip archive"
+		return "", nil, cmdutils.WrapIncorrectUsageError(errors.Errorf(msg, c.source))
+	}
+
+	dir, err := os.MkdirTemp("", "cifuzz-corpus-archive-")
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	cleanup := func() { fileutil.Cleanup(dir) }
+	err = extract(dir)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// stageImport copies all files below sourceDir whose content doesn't
+// exist in targetDir yet to the "inputs" directory in importDir, named
+// after the SHA-1 hash of their content like libFuzzer names the inputs
+// it adds to a corpus. It returns the total number of inputs and the
+// number of new ones.
+//
+// If the inputs of an interrupted import are still staged together
+// with the merge control file of libFuzzer and the new inputs are the
+// same, they are kept as they are, so that libFuzzer can resume the
+// merge, and resumed is true. Otherwise, the import starts over.
+func stageImport(sourceDir, targetDir, importDir string) (numInputs int, numNew int, resumed bool, err error) {
+	numInputs, inputs, err := newInputs(sourceDir, targetDir)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	stagingDir := filepath.Join(importDir, "inputs")
+
+	resumed, err = isStaged(importDir, inputs)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if resumed {
+		return numInputs, len(inputs), true, nil
+	}
+
+	err = os.RemoveAll(importDir)
+	if err != nil {
+		return 0, 0, false, errors.WithStack(err)
+	}
+	err = os.MkdirAll(stagingDir, 0755)
+	if err != nil {
+		return 0, 0, false, errors.WithStack(err)
+	}
+	for hash, path := range inputs {
+		err = copyFile(path, filepath.Join(stagingDir, hash))
+		if err != nil {
+			return 0, 0, false, err
+		}
+	}
+	return numInputs, len(inputs), false, nil
+}
+
+// isStaged returns true if importDir contains the merge control file of
+// an interrupted import and exactly the given inputs.
+func isStaged(importDir string, inputs map[string]string) (bool, error) {
+	exists, err := fileutil.Exists(filepath.Join(importDir, "merge-control"))
+	if err != nil || !exists {
+		return false, err
+	}
+	entries, err := os.ReadDir(filepath.Join(importDir, "inputs"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if len(entries) != len(inputs) {
+		return false, nil
+	}
+	for _, entry := range entries {
+		if _, ok := inputs[entry.Name()]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// newInputs returns the paths of all files below sourceDir whose
+// content doesn't exist in targetDir yet by the SHA-1 hash of their
+// content and the total number of files below sourceDir.
+func newInputs(sourceDir, targetDir string) (int, map[string]string, error) {
+	hashes := map[string]bool{}
+	err := filepath.WalkDir(targetDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		hash, err := fileutil.SHA1(path)
+		if err != nil {
+			return err
+		}
+		hashes[hash] = true
+		return nil
+	})
+	if err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+
+	numInputs := 0
+	inputs := map[string]string{}
+	err = filepath.WalkDir(sourceDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Symlinks are skipped, they could point to arbitrary files
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		numInputs++
+		hash, err := fileutil.SHA1(path)
+		if err != nil {
+			return err
+		}
+		if hashes[hash] {
+			return nil
+		}
+		hashes[hash] = true
+		inputs[hash] = path
+		return nil
+	})
+	if err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+	return numInputs, inputs, nil
+}
+
+// copyInputs copies all files from sourceDir to targetDir and returns
+// the number of copied files.
+func copyInputs(sourceDir, targetDir string) (int, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		// The staging directory might be on a different file system
+		// than the corpus, so we can't just rename the files.
+		err = copyFile(filepath.Join(sourceDir, entry.Name()), filepath.Join(targetDir, entry.Name()))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return errors.WithStack(err)
+}
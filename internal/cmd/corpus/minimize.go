@@ -0,0 +1,200 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type minimizeCmd struct {
+	*cobra.Command
+	opts *corpusOptions
+}
+
+func newMinimizeCmd() *cobra.Command {
+	opts := &corpusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "minimize [flags] <fuzz test>",
+		Short: "Minimize the generated corpus of a fuzz test",
+		Long: "Builds the fuzz test and uses libFuzzer to remove all inputs from the\n" +
+			"generated corpus which don't add coverage compared to the other inputs.",
+		ValidArgsFunction: completion.ValidFuzzTests,
+		Args:              cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			bindCorpusFlags(cmd)
+
+			projectDir, err := config.ParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.ProjectDir = projectDir
+
+			opts.fuzzTest = args[0]
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := minimizeCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	addCorpusFlags(cmd, opts)
+
+	return cmd
+}
+
+func (c *minimizeCmd) run() error {
+	corpusDir := cmdutils.GeneratedCorpusDir(c.opts.ProjectDir, c.opts.fuzzTest)
+	stagingDir := minimizeStagingDir(c.opts.ProjectDir, c.opts.fuzzTest)
+
+	// Recover from a previous run which was interrupted while
+	// replacing the corpus
+	err := recoverCorpus(corpusDir, stagingDir)
+	if err != nil {
+		return err
+	}
+
+	exists, err := fileutil.Exists(corpusDir)
+	if err != nil {
+		return err
+	}
+	numFilesBefore := 0
+	var sizeBefore int64
+	if exists {
+		numFilesBefore, sizeBefore, err = corpusStats(corpusDir)
+		if err != nil {
+			return err
+		}
+	}
+	if numFilesBefore == 0 {
+		log.Infof("The generated corpus of %s is empty, nothing to minimize", c.opts.fuzzTest)
+		return nil
+	}
+
+	buildResult, err := buildFuzzTest(c.Command, c.opts)
+	if err != nil {
+		return err
+	}
+
+	// Merge the corpus into a fresh directory in the project, so that
+	// it can be renamed to replace the original corpus. The directory
+	// is outside of .cifuzz-corpus to not be mistaken for the corpus
+	// of a fuzz test.
+	minimizedDir := filepath.Join(stagingDir, "minimized")
+	err = os.MkdirAll(minimizedDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Only the minimized corpus is removed on errors, the staging dir
+	// might still contain the original corpus if restoring it failed
+	defer fileutil.Cleanup(minimizedDir)
+
+	log.Infof("Minimizing corpus of %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(c.opts.fuzzTest))
+	numCrashes, err := merge(c.opts, buildResult, minimizedDir, []string{corpusDir}, "")
+	if err != nil {
+		return err
+	}
+	if numCrashes > 0 {
+		log.Warnf("Removed %d inputs which crash the fuzz test from the corpus", numCrashes)
+	}
+
+	numFilesAfter, sizeAfter, err := corpusStats(minimizedDir)
+	if err != nil {
+		return err
+	}
+	if numFilesAfter == 0 {
+		// libFuzzer always keeps at least one input of a non-empty
+		// corpus unless all of them crash, in which case keeping the
+		// original corpus is more useful.
+		err = errors.Errorf("Minimizing the corpus of %s produced an empty corpus, keeping the original one", c.opts.fuzzTest)
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+
+	err = replaceDir(corpusDir, minimizedDir, filepath.Join(stagingDir, "old"))
+	if err != nil {
+		return err
+	}
+	fileutil.Cleanup(stagingDir)
+
+	log.Successf("Minimized corpus of %s from %d inputs (%s) to %d inputs (%s)",
+		c.opts.fuzzTest,
+		numFilesBefore, fileutil.PrettifySize(sizeBefore),
+		numFilesAfter, fileutil.PrettifySize(sizeAfter))
+	return nil
+}
+
+// minimizeStagingDir returns the directory in which the minimized
+// corpus of the fuzz test is created before it replaces the original
+// corpus
+func minimizeStagingDir(projectDir, fuzzTest string) string {
+	return filepath.Join(projectDir, ".cifuzz-build", "corpus-minimize", fuzzTest)
+}
+
+// replaceDir replaces dir with newDir, moving dir to oldDir while doing
+// so. If renaming newDir fails, the original directory is restored. If
+// the process is interrupted in between, recoverCorpus restores the
+// original directory on the next run.
+func replaceDir(dir, newDir, oldDir string) error {
+	err := os.Rename(dir, oldDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.Rename(newDir, dir)
+	if err != nil {
+		restoreErr := os.Rename(oldDir, dir)
+		if restoreErr != nil {
+			log.Warnf("Failed to restore %s from %s: %v", dir, oldDir, restoreErr)
+		}
+		return errors.WithStack(err)
+	}
+
+	err = os.RemoveAll(oldDir)
+	return errors.WithStack(err)
+}
+
+// recoverCorpus cleans up the staging dir of a previous minimize run
+// which was interrupted. If the corpus was already moved out of the way
+// but not yet replaced by the minimized corpus, it's restored.
+func recoverCorpus(corpusDir, stagingDir string) error {
+	exists, err := fileutil.Exists(stagingDir)
+	if err != nil || !exists {
+		return err
+	}
+
+	oldDir := filepath.Join(stagingDir, "old")
+	oldExists, err := fileutil.Exists(oldDir)
+	if err != nil {
+		return err
+	}
+	corpusExists, err := fileutil.Exists(corpusDir)
+	if err != nil {
+		return err
+	}
+	if oldExists && !corpusExists {
+		log.Warnf("Restoring the corpus %s from an interrupted minimization", fileutil.PrettifyPath(corpusDir))
+		err = os.MkdirAll(filepath.Dir(corpusDir), 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = os.Rename(oldDir, corpusDir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	log.Debugf("Removing stale staging directory %s", stagingDir)
+	err = os.RemoveAll(stagingDir)
+	return errors.WithStack(err)
+}
@@ -14,6 +14,7 @@ import (
 
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/meson"
 	"code-intelligence.com/cifuzz/internal/build/other"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
@@ -29,12 +30,14 @@ import (
 type coverageOptions struct {
 	BuildSystem    string   `mapstructure:"build-system"`
 	BuildCommand   string   `mapstructure:"build-command"`
+	BuildOutput    string   `mapstructure:"build-output"`
 	SeedCorpusDirs []string `mapstructure:"seed-corpus-dirs"`
 	FuzzTestArgs   []string `mapstructure:"fuzz-test-args"`
 	UseSandbox     bool     `mapstructure:"use-sandbox"`
 
 	ProjectDir string
 	fuzzTest   string
+	rebuild    bool
 }
 
 func (opts *coverageOptions) validate() error {
@@ -88,6 +91,7 @@ func New() *cobra.Command {
 			// function, because that would re-bind viper keys which
 			// were bound to the flags of other commands before.
 			cmdutils.ViperMustBindPFlag("build-command", cmd.Flags().Lookup("build-command"))
+			cmdutils.ViperMustBindPFlag("build-output", cmd.Flags().Lookup("build-output"))
 			cmdutils.ViperMustBindPFlag("seed-corpus-dirs", cmd.Flags().Lookup("seed-corpus"))
 			cmdutils.ViperMustBindPFlag("fuzz-test-args", cmd.Flags().Lookup("fuzz-test-arg"))
 			cmdutils.ViperMustBindPFlag("use-sandbox", cmd.Flags().Lookup("use-sandbox"))
@@ -110,10 +114,12 @@ func New() *cobra.Command {
 	// Note: If a flag should be configurable via cifuzz.yaml as well,
 	// bind it to viper in the PreRunE function.
 	cmd.Flags().String("build-command", "", `The command to build the fuzz test. Example: "make clean && make my-fuzz-test"`)
+	cmd.Flags().String("build-output", "", "The path of the fuzz test executable built by the build command,\nif the build system type is \"other\". Example: \"build/my_fuzz_test\"")
 	cmd.Flags().StringArrayP("seed-corpus", "s", nil, "Directory containing sample inputs for the code under test.\nSee https://llvm.org/docs/LibFuzzer.html#corpus and\nhttps://aflplus.plus/docs/fuzzing_in_depth/#a-collecting-inputs.")
 	cmd.Flags().StringArray("fuzz-test-arg", nil, "Command-line argument to pass to the fuzz test.")
 	cmd.Flags().Bool("use-sandbox", false, "By default, fuzz tests are executed in a sandbox to prevent accidental damage to the system.\nUse --use-sandbox=false to run the fuzz test unsandboxed.\nOnly supported on Linux.")
 	viper.SetDefault("use-sandbox", runtime.GOOS == "linux")
+	cmd.Flags().BoolVar(&opts.rebuild, "rebuild", false, "Build the fuzz test even if its sources didn't change since the last build.")
 
 	return cmd
 }
@@ -181,6 +187,39 @@ func (c *coverageCmd) buildFuzzTest() (*build.Result, error) {
 		if err != nil {
 			return nil, err
 		}
+		if !c.opts.rebuild {
+			buildResults, err := builder.FindCachedResults([]string{c.opts.fuzzTest})
+			if err != nil {
+				return nil, err
+			}
+			if buildResults != nil {
+				log.Info("Fuzz test is up to date, skipping the build (use --rebuild to force it)")
+				return buildResults[c.opts.fuzzTest], nil
+			}
+		}
+		err = builder.Configure()
+		if err != nil {
+			return nil, err
+		}
+		buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return buildResults[c.opts.fuzzTest], nil
+	} else if c.opts.BuildSystem == config.BuildSystemMeson {
+		builder, err := meson.NewBuilder(&meson.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Engine:     "replayer",
+			Sanitizers: []string{"coverage"},
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+			// We want the runtime deps in the build result because we
+			// pass them to the llvm-cov command.
+			FindRuntimeDeps: true,
+		})
+		if err != nil {
+			return nil, err
+		}
 		err = builder.Configure()
 		if err != nil {
 			return nil, err
@@ -195,7 +234,9 @@ func (c *coverageCmd) buildFuzzTest() (*build.Result, error) {
 			return nil, errors.New("CMake is the only supported build system on Windows")
 		}
 		builder, err := other.NewBuilder(&other.BuilderOptions{
+			ProjectDir:   c.opts.ProjectDir,
 			BuildCommand: c.opts.BuildCommand,
+			BuildOutput:  c.opts.BuildOutput,
 			Engine:       "replayer",
 			Sanitizers:   []string{"coverage"},
 			Stdout:       c.OutOrStdout(),
@@ -204,6 +245,17 @@ func (c *coverageCmd) buildFuzzTest() (*build.Result, error) {
 		if err != nil {
 			return nil, err
 		}
+		defer builder.Cleanup()
+		if !c.opts.rebuild {
+			buildResult, err := builder.FindCachedResult(c.opts.fuzzTest)
+			if err != nil {
+				return nil, err
+			}
+			if buildResult != nil {
+				log.Info("Fuzz test is up to date, skipping the build (use --rebuild to force it)")
+				return buildResult, nil
+			}
+		}
 		buildResult, err := builder.Build(c.opts.fuzzTest)
 		if err != nil {
 			return nil, err
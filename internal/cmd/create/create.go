@@ -2,6 +2,7 @@ package create
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -25,6 +26,8 @@ type cmdOpts struct {
 // map of supported test types -> label:value
 var supportedTestTypes = map[string]string{
 	"C/C++": string(config.CPP),
+	"Go":    string(config.GO),
+	"Java":  string(config.JAVA),
 }
 
 func New(config *config.Config) *cobra.Command {
@@ -75,7 +78,7 @@ func run(cmd *cobra.Command, args []string, opts *cmdOpts) (err error) {
 Note: Fuzz tests can be put anywhere in your repository, but it makes sense
 to keep them close to the tested code - just like regular unit tests.`)
 
-	printBuildSystemInstructions(opts.config.BuildSystem, filepath.Base(opts.outputPath))
+	printBuildSystemInstructions(opts.config, opts.outputPath)
 
 	return
 }
@@ -93,9 +96,10 @@ func getTestType(args []string) (config.FuzzTestType, error) {
 	return config.FuzzTestType(userSelectedType), nil
 }
 
-func printBuildSystemInstructions(buildSystem, filename string) {
+func printBuildSystemInstructions(conf *config.Config, outputPath string) {
 	// Printing build system instructions is best-effort: Do not fail on errors.
-	if buildSystem == config.BuildSystemCMake {
+	filename := filepath.Base(outputPath)
+	if conf.BuildSystem == config.BuildSystemCMake {
 		log.Printf(`
 Create a CMake target for the fuzz test as follows - it behaves just like
 a regular add_executable(...):
@@ -103,5 +107,33 @@ a regular add_executable(...):
     add_fuzz_test(%s %s)
 
 `, strings.TrimSuffix(filename, filepath.Ext(filename)), filename)
+	} else if conf.BuildSystem == config.BuildSystemGo && strings.HasSuffix(filename, "_test.go") {
+		// Go fuzz tests are specified by the package directory relative
+		// to the project directory and the name of the fuzz function
+		pkgDir := filepath.Dir(outputPath)
+		if absDir, err := filepath.Abs(pkgDir); err == nil && conf.ProjectDir != "" {
+			if relDir, err := filepath.Rel(conf.ProjectDir, absDir); err == nil {
+				pkgDir = relDir
+			}
+		}
+		log.Printf(`
+Run the fuzz test with:
+
+    cifuzz run %s
+
+`, path.Join(filepath.ToSlash(pkgDir), stubs.GoFuzzTestName(outputPath)))
+	} else if config.IsJavaBuildSystem(conf.BuildSystem) && strings.HasSuffix(filename, ".java") {
+		log.Printf(`
+The fuzz test requires the Jazzer API, add it as a test dependency of
+your project:
+
+    com.code-intelligence:jazzer-api
+
+Java fuzz tests are specified by the fully qualified name of their
+class. Run the fuzz test with:
+
+    cifuzz run %s
+
+`, stubs.JavaFuzzTestClass(outputPath))
 	}
 }
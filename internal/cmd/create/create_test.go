@@ -46,3 +46,23 @@ func TestCreateCmd_InvalidType(t *testing.T) {
 func TestCreateCmd_OutDir(t *testing.T) {
 	t.Skip()
 }
+
+func TestCreateCmd_Go(t *testing.T) {
+	args := []string{
+		"go",
+		"--output",
+		filepath.Join(baseTempDir, "parser_test.go"),
+	}
+	_, err := cmdutils.ExecuteCommand(t, New(config.NewConfig()), os.Stdin, args...)
+	assert.NoError(t, err)
+}
+
+func TestCreateCmd_Java(t *testing.T) {
+	args := []string{
+		"java",
+		"--output",
+		filepath.Join(baseTempDir, "ParserFuzzTest.java"),
+	}
+	_, err := cmdutils.ExecuteCommand(t, New(config.NewConfig()), os.Stdin, args...)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,67 @@
+package findings
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func newDeleteCmd(opts *findingsOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <finding>...",
+		Short:             "Delete findings",
+		Long:              "Deletes the given findings and their crashing inputs.",
+		ValidArgsFunction: completion.ValidFindings,
+		Args:              cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDelete(cmd, opts, args)
+		},
+	}
+}
+
+func runDelete(cmd *cobra.Command, opts *findingsOpts, names []string) error {
+	// Check that all findings exist before deleting any of them, to
+	// avoid only deleting some of them due to a typo.
+	for _, name := range names {
+		_, err := report.LoadFinding(opts.config.ProjectDir, name)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Errorf(err, "Finding %s does not exist", name)
+			return cmdutils.ErrSilent
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return deleteFindings(cmd, opts, names)
+}
+
+// deleteFindings deletes the findings with the given names and prints
+// which findings were deleted.
+func deleteFindings(cmd *cobra.Command, opts *findingsOpts, names []string) error {
+	for _, name := range names {
+		err := report.DeleteFinding(opts.config.ProjectDir, name)
+		if err != nil {
+			return err
+		}
+		if !opts.printJSON {
+			log.Successf("Deleted finding %s", name)
+		}
+	}
+
+	if opts.printJSON {
+		// Print an empty JSON array instead of null if nothing was
+		// deleted
+		if names == nil {
+			names = []string{}
+		}
+		return printJSON(cmd.OutOrStdout(), names)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package findings
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const formatSARIF = "sarif"
+
+var supportedExportFormats = []string{formatSARIF}
+
+type exportOpts struct {
+	format string
+	output string
+}
+
+func newExportCmd(opts *findingsOpts) *cobra.Command {
+	exportOpts := &exportOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "export [flags] [<finding>...]",
+		Short: "Export findings in a format for other tools",
+		Long: "Exports the given findings, or all findings if none are given, in a\n" +
+			"format which can be processed by other tools, for example SARIF for\n" +
+			"code scanning integrations.",
+		ValidArgsFunction: completion.ValidFindings,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd, opts, exportOpts, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&exportOpts.format, "format", formatSARIF, fmt.Sprintf("The format of the export. Valid formats: %s.", strings.Join(supportedExportFormats, ", ")))
+	cmd.Flags().StringVarP(&exportOpts.output, "output", "o", "", "The file to write the export to. The default is to write to stdout.")
+
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, opts *findingsOpts, exportOpts *exportOpts, names []string) error {
+	if !stringutil.Contains(supportedExportFormats, exportOpts.format) {
+		err := errors.Errorf("Invalid format \"%s\", valid formats are: %s",
+			exportOpts.format, strings.Join(supportedExportFormats, ", "))
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+
+	var findings []*report.Finding
+	var err error
+	if len(names) == 0 {
+		findings, err = report.ListFindings(opts.config.ProjectDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, name := range names {
+			finding, err := report.LoadFinding(opts.config.ProjectDir, name)
+			if errors.Is(err, os.ErrNotExist) {
+				log.Errorf(err, "Finding %s does not exist", name)
+				return cmdutils.ErrSilent
+			}
+			if err != nil {
+				return err
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if exportOpts.output != "" {
+		file, err := os.Create(exportOpts.output)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch exportOpts.format {
+	case formatSARIF:
+		err = report.WriteSARIF(out, findings, opts.config.ProjectDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	if exportOpts.output != "" {
+		log.Successf("Exported %d findings to %s", len(findings), fileutil.PrettifyPath(exportOpts.output))
+	}
+	return nil
+}
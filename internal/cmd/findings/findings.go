@@ -0,0 +1,78 @@
+package findings
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// The format in which timestamps of findings are printed
+const timeFormat = "2006-01-02 15:04:05"
+
+type findingsOpts struct {
+	printJSON bool
+
+	config *config.Config
+}
+
+func New(conf *config.Config) *cobra.Command {
+	opts := &findingsOpts{config: conf}
+
+	cmd := &cobra.Command{
+		Use:   "findings",
+		Short: "List, show and manage the findings of the project",
+		Long: "Findings are stored in the .cifuzz-findings directory of the project.\n" +
+			"Use the subcommands to list, inspect and delete them.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Listing the findings is the most common use case, so
+			// that's what we do if no subcommand was specified.
+			return runList(cmd, opts)
+		},
+	}
+
+	cmd.PersistentFlags().BoolVar(&opts.printJSON, "json", false, "Print output as JSON")
+
+	cmd.AddCommand(newListCmd(opts))
+	cmd.AddCommand(newShowCmd(opts))
+	cmd.AddCommand(newDeleteCmd(opts))
+	cmd.AddCommand(newPruneCmd(opts))
+	cmd.AddCommand(newExportCmd(opts))
+
+	return cmd
+}
+
+func printJSON(out io.Writer, v interface{}) error {
+	jsonString, err := stringutil.ToJsonString(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, jsonString)
+	return err
+}
+
+func severityString(f *report.Finding) string {
+	if f.MoreDetails == nil || f.MoreDetails.Severity == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f", f.MoreDetails.Severity.Score)
+}
+
+func createdAtString(f *report.Finding) string {
+	if f.CreatedAt.IsZero() {
+		return "n/a"
+	}
+	return f.CreatedAt.Local().Format(timeFormat)
+}
+
+func valueOrNA(s string) string {
+	if s == "" {
+		return "n/a"
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package findings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+func setupProject(t *testing.T) *config.Config {
+	projectDir, err := os.MkdirTemp("", "findings-test-")
+	require.NoError(t, err)
+	t.Cleanup(func() { fileutil.Cleanup(projectDir) })
+
+	input := filepath.Join(projectDir, "input")
+	err = os.WriteFile(input, []byte("FUZZ"), 0644)
+	require.NoError(t, err)
+
+	findings := []*report.Finding{
+		{
+			Name:      "old_finding",
+			Type:      report.ErrorType_CRASH,
+			Details:   "heap-buffer-overflow",
+			FuzzTest:  "my_fuzz_test",
+			CreatedAt: time.Now().Add(-48 * time.Hour),
+			InputFile: input,
+			Logs:      []string{"==1==ERROR: AddressSanitizer: heap-buffer-overflow"},
+		},
+		{
+			Name:      "new_finding",
+			Type:      report.ErrorType_RUNTIME_ERROR,
+			Details:   "undefined behavior",
+			FuzzTest:  "other_fuzz_test",
+			CreatedAt: time.Now(),
+		},
+	}
+	for _, f := range findings {
+		require.NoError(t, f.Save(projectDir))
+	}
+
+	conf := config.NewConfig()
+	conf.ProjectDir = projectDir
+	return conf
+}
+
+func TestFindingsCmd_List(t *testing.T) {
+	conf := setupProject(t)
+
+	out, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "old_finding")
+	assert.Contains(t, out, "heap-buffer-overflow")
+	assert.Contains(t, out, "other_fuzz_test")
+
+	out, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "list", "--json")
+	require.NoError(t, err)
+	var findings []*report.Finding
+	require.NoError(t, json.Unmarshal([]byte(out), &findings))
+	require.Len(t, findings, 2)
+	assert.Equal(t, "old_finding", findings[0].Name)
+	assert.Equal(t, "new_finding", findings[1].Name)
+}
+
+func TestFindingsCmd_Show(t *testing.T) {
+	conf := setupProject(t)
+
+	out, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "show", "old_finding")
+	require.NoError(t, err)
+	assert.Contains(t, out, "AddressSanitizer: heap-buffer-overflow")
+	// The hexdump of the crashing input
+	assert.Contains(t, out, "46 55 5a 5a")
+
+	out, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "show", "old_finding", "--json")
+	require.NoError(t, err)
+	finding := &report.Finding{}
+	require.NoError(t, json.Unmarshal([]byte(out), finding))
+	assert.Equal(t, []byte("FUZZ"), finding.InputData)
+
+	_, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "show", "does_not_exist")
+	assert.Error(t, err)
+}
+
+func TestFindingsCmd_ShowErrorDetails(t *testing.T) {
+	conf := setupProject(t)
+	finding := &report.Finding{
+		Name:    "leaky_finding",
+		Type:    report.ErrorType_CRASH,
+		Details: "detected memory leaks",
+		MoreDetails: &report.ErrorDetails{
+			Id: "memory-leak",
+			Leaks: []*report.Leak{{
+				Direct:     true,
+				Bytes:      32,
+				Objects:    1,
+				StackTrace: []*report.StackFrame{{Function: "parse_header", File: "/src/http.c", Line: 110}},
+			}},
+		},
+	}
+	require.NoError(t, finding.Save(conf.ProjectDir))
+
+	out, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "show", "leaky_finding")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Direct leak of 32 byte(s) in 1 object(s) allocated from:")
+	assert.Contains(t, out, "#0 parse_header /src/http.c:110")
+
+	// The leaks are part of the JSON output
+	out, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "show", "leaky_finding", "--json")
+	require.NoError(t, err)
+	loaded := &report.Finding{}
+	require.NoError(t, json.Unmarshal([]byte(out), loaded))
+	require.NotNil(t, loaded.MoreDetails)
+	assert.Equal(t, finding.MoreDetails.Leaks, loaded.MoreDetails.Leaks)
+}
+
+func TestFindingsCmd_Delete(t *testing.T) {
+	conf := setupProject(t)
+
+	_, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "delete", "old_finding", "does_not_exist")
+	require.Error(t, err)
+	assert.DirExists(t, filepath.Join(report.FindingsDir(conf.ProjectDir), "old_finding"))
+
+	_, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "delete", "old_finding")
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(report.FindingsDir(conf.ProjectDir), "old_finding"))
+	assert.DirExists(t, filepath.Join(report.FindingsDir(conf.ProjectDir), "new_finding"))
+}
+
+func TestFindingsCmd_Prune(t *testing.T) {
+	conf := setupProject(t)
+
+	// Pruning without any filter is not allowed
+	_, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "prune")
+	require.Error(t, err)
+
+	out, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "prune", "--older-than", "24h", "--json")
+	require.NoError(t, err)
+	var deleted []string
+	require.NoError(t, json.Unmarshal([]byte(out), &deleted))
+	assert.Equal(t, []string{"old_finding"}, deleted)
+
+	_, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "prune", "--fuzz-test", "other_fuzz_test")
+	require.NoError(t, err)
+	findings, err := report.ListFindings(conf.ProjectDir)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestFindingsCmd_Export(t *testing.T) {
+	conf := setupProject(t)
+
+	out, err := cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "export", "--format", "sarif", "old_finding")
+	require.NoError(t, err)
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &sarif))
+	assert.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs, 1)
+	require.Len(t, sarif.Runs[0].Results, 1)
+	assert.Equal(t, "CRASH", sarif.Runs[0].Results[0].RuleID)
+
+	_, err = cmdutils.ExecuteCommand(t, New(conf), os.Stdin, "export", "--format", "unknown")
+	assert.Error(t, err)
+}
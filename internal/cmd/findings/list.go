@@ -0,0 +1,83 @@
+package findings
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// The maximum number of characters of the details which are printed
+// in the table of findings
+const maxDetailsLength = 50
+
+func newListCmd(opts *findingsOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the findings of the project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(cmd, opts)
+		},
+	}
+}
+
+func runList(cmd *cobra.Command, opts *findingsOpts) error {
+	findings, err := report.ListFindings(opts.config.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	if opts.printJSON {
+		// Print an empty JSON array instead of null if there are no
+		// findings
+		if findings == nil {
+			findings = []*report.Finding{}
+		}
+		return printJSON(cmd.OutOrStdout(), findings)
+	}
+
+	if len(findings) == 0 {
+		log.Info("This project doesn't have any findings yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	_, err = fmt.Fprintln(w, "NAME\tTYPE\tDETAILS\tSEVERITY\tFIRST SEEN\tFUZZ TEST")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, f := range findings {
+		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			f.Name,
+			valueOrNA(string(f.Type)),
+			valueOrNA(truncate(f.Details, maxDetailsLength)),
+			severityString(f),
+			createdAtString(f),
+			valueOrNA(f.FuzzTest),
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(w.Flush())
+}
+
+// truncate returns the first line of s, shortened to at most n
+// characters.
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i != -1 {
+		s = s[:i]
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
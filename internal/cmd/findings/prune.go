@@ -0,0 +1,81 @@
+package findings
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+type pruneOpts struct {
+	olderThan time.Duration
+	fuzzTest  string
+	all       bool
+}
+
+func newPruneCmd(opts *findingsOpts) *cobra.Command {
+	pruneOpts := &pruneOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete all findings which match the given filters",
+		Long: "Deletes all findings which match the given filters. At least one of\n" +
+			"--older-than, --fuzz-test or --all must be specified.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrune(cmd, opts, pruneOpts)
+		},
+	}
+
+	cmd.Flags().DurationVar(&pruneOpts.olderThan, "older-than", 0, "Only delete findings which were first seen longer ago than the given duration.\nExample: 168h")
+	cmd.Flags().StringVar(&pruneOpts.fuzzTest, "fuzz-test", "", "Only delete findings of the given fuzz test")
+	cmd.Flags().BoolVar(&pruneOpts.all, "all", false, "Delete all findings")
+
+	return cmd
+}
+
+func runPrune(cmd *cobra.Command, opts *findingsOpts, pruneOpts *pruneOpts) error {
+	if pruneOpts.olderThan == 0 && pruneOpts.fuzzTest == "" && !pruneOpts.all {
+		err := errors.New(`At least one of the flags "older-than", "fuzz-test" or "all" must be set`)
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+
+	findings, err := report.ListFindings(opts.config.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, f := range findings {
+		if pruneOpts.matches(f) {
+			names = append(names, f.Name)
+		}
+	}
+
+	err = deleteFindings(cmd, opts, names)
+	if err != nil {
+		return err
+	}
+	if !opts.printJSON {
+		log.Infof("Deleted %d findings", len(names))
+	}
+	return nil
+}
+
+func (opts *pruneOpts) matches(f *report.Finding) bool {
+	if opts.fuzzTest != "" && f.FuzzTest != opts.fuzzTest {
+		return false
+	}
+	if opts.olderThan != 0 {
+		// Findings without a timestamp were created by an older
+		// version of cifuzz, so they are older than any others.
+		if !f.CreatedAt.IsZero() && time.Since(f.CreatedAt) <= opts.olderThan {
+			return false
+		}
+	}
+	return true
+}
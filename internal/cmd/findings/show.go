@@ -0,0 +1,177 @@
+package findings
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func newShowCmd(opts *findingsOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:               "show <finding>",
+		Short:             "Show the details of a finding",
+		Long:              "Shows the details and logs of a finding and a hexdump of its crashing input.",
+		ValidArgsFunction: completion.ValidFindings,
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShow(cmd, opts, args[0])
+		},
+	}
+}
+
+func runShow(cmd *cobra.Command, opts *findingsOpts, name string) error {
+	finding, err := report.LoadFinding(opts.config.ProjectDir, name)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Errorf(err, "Finding %s does not exist", name)
+		return cmdutils.ErrSilent
+	}
+	if err != nil {
+		return err
+	}
+
+	input, err := readInput(finding)
+	if err != nil {
+		return err
+	}
+
+	if opts.printJSON {
+		// Include the crashing input in the JSON output, it's stored
+		// separately from the finding.json.
+		finding.InputData = input
+		return printJSON(cmd.OutOrStdout(), finding)
+	}
+
+	out := cmd.OutOrStdout()
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	lines := []string{
+		"Name:\t" + finding.Name,
+		"Type:\t" + valueOrNA(string(finding.Type)),
+		"Details:\t" + valueOrNA(finding.Details),
+		"Location:\t" + valueOrNA(strings.TrimPrefix(finding.CrashSummary(), "crashed in ")),
+		"Severity:\t" + severityString(finding),
+		"First seen:\t" + createdAtString(finding),
+		"Fuzz test:\t" + valueOrNA(finding.FuzzTest),
+		"Run:\t" + valueOrNA(finding.RunID),
+	}
+	for _, line := range lines {
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = printStackTrace(out, "Stack trace:", finding.StackTrace)
+	if err != nil {
+		return err
+	}
+	if details := finding.MoreDetails; details != nil {
+		for _, leak := range details.Leaks {
+			err = printStackTrace(out, leak.Description()+" allocated from:", leak.StackTrace)
+			if err != nil {
+				return err
+			}
+		}
+		for i, access := range details.Accesses {
+			title := "Access"
+			if i > 0 {
+				title = "Previous access"
+			}
+			title = fmt.Sprintf("%s: %s by %s", title, access.Description(), access.Thread)
+			err = printStackTrace(out, title, access.StackTrace)
+			if err != nil {
+				return err
+			}
+		}
+		if origin := details.Origin; origin != nil {
+			err = printStackTrace(out, "Uninitialized value was created by "+origin.Description+":", origin.StackTrace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(finding.Logs) > 0 {
+		_, err = fmt.Fprintf(out, "\n%s\n%s\n", pterm.Bold.Sprint("Logs:"), strings.Join(finding.Logs, "\n"))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if finding.InputFile != "" || len(input) > 0 {
+		_, err = fmt.Fprintf(out, "\n%s\n%s", pterm.Bold.Sprintf("Crashing input (%d bytes):", len(input)), hex.Dump(input))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// printStackTrace prints the title and the frames of the stack trace,
+// if it's not empty
+func printStackTrace(out io.Writer, title string, stackTrace []*report.StackFrame) error {
+	if len(stackTrace) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(out, "\n%s\n", pterm.Bold.Sprint(title))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, frame := range stackTrace {
+		_, err = fmt.Fprintf(out, "    #%d %s\n", i, formatFrame(frame))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func formatFrame(frame *report.StackFrame) string {
+	var parts []string
+	if frame.Function != "" {
+		parts = append(parts, frame.Function)
+	}
+	if frame.File != "" {
+		location := frame.File
+		if frame.Line != 0 {
+			location += fmt.Sprintf(":%d", frame.Line)
+		}
+		if frame.Column != 0 {
+			location += fmt.Sprintf(":%d", frame.Column)
+		}
+		parts = append(parts, location)
+	}
+	if frame.Module != "" {
+		parts = append(parts, "("+frame.Module+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// readInput returns the crashing input of the finding, which is
+// either stored in a separate file or in the finding itself.
+func readInput(finding *report.Finding) ([]byte, error) {
+	if finding.InputFile == "" {
+		return finding.InputData, nil
+	}
+	input, err := os.ReadFile(finding.InputFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return input, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"code-intelligence.com/cifuzz/internal/build/bazel"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
@@ -75,6 +76,12 @@ add_library(...) or add_executable(...) calls:
 
     find_package(cifuzz)
     enable_fuzz_testing()`)
+	} else if cfg.BuildSystem == config.BuildSystemBazel {
+		log.Print(`
+Make the build options which cifuzz uses for fuzz tests available to
+Bazel by adding the following line to the .bazelrc of your workspace:
+
+    ` + bazel.BazelrcImport)
 	}
 
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/meson"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
@@ -58,19 +59,25 @@ func (c *reloadCmd) run() error {
 	}
 
 	if conf.BuildSystem == config.BuildSystemCMake {
-		return c.reloadCMake()
-	} else if conf.BuildSystem == config.BuildSystemOther {
-		// Nothing to reload for other build system
+		return c.reloadCMake(conf.Sanitizers)
+	} else if conf.BuildSystem == config.BuildSystemMeson {
+		return c.reloadMeson(conf.Sanitizers)
+	} else if conf.BuildSystem == config.BuildSystemBazel ||
+		conf.BuildSystem == config.BuildSystemGo ||
+		conf.BuildSystem == config.BuildSystemCargo ||
+		config.IsJavaBuildSystem(conf.BuildSystem) ||
+		conf.BuildSystem == config.BuildSystemOther {
+		// Nothing to reload for Bazel, Go, Rust, Java and other build
+		// systems
 		return nil
 	} else {
 		return errors.Errorf("Unsupported build system \"%s\"", conf.BuildSystem)
 	}
 }
 
-func (c *reloadCmd) reloadCMake() error {
-	// TODO: Make these configurable
+func (c *reloadCmd) reloadCMake(sanitizers []string) error {
+	// TODO: Make this configurable
 	engine := "libfuzzer"
-	sanitizers := []string{"address", "undefined"}
 
 	builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
 		ProjectDir: c.projectDir,
@@ -89,3 +96,21 @@ func (c *reloadCmd) reloadCMake() error {
 	}
 	return nil
 }
+
+func (c *reloadCmd) reloadMeson(sanitizers []string) error {
+	// TODO: Make this configurable
+	engine := "libfuzzer"
+
+	builder, err := meson.NewBuilder(&meson.BuilderOptions{
+		ProjectDir: c.projectDir,
+		Engine:     engine,
+		Sanitizers: sanitizers,
+		Stdout:     c.OutOrStdout(),
+		Stderr:     c.ErrOrStderr(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return builder.Configure()
+}
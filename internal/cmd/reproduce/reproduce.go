@@ -0,0 +1,302 @@
+package reproduce
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/fuzztests"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+)
+
+type reproduceOptions struct {
+	BuildSystem  string   `mapstructure:"build-system"`
+	BuildCommand string   `mapstructure:"build-command"`
+	BuildOutput  string   `mapstructure:"build-output"`
+	EngineArgs   []string `mapstructure:"engine-args"`
+	FuzzTestArgs []string `mapstructure:"fuzz-test-args"`
+	Sanitizers   []string `mapstructure:"sanitizers"`
+	UseSandbox   bool     `mapstructure:"use-sandbox"`
+
+	ProjectDir  string
+	findingName string
+	fuzzTest    string
+}
+
+func (opts *reproduceOptions) validate() error {
+	var err error
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = config.ValidateBuildSystem(opts.BuildSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Findings are reproduced with libFuzzer, which can't execute the
+	// fuzz tests of Java projects
+	if config.IsJavaBuildSystem(opts.BuildSystem) {
+		msg := fmt.Sprintf("Build system \"%s\" is not supported by this command", opts.BuildSystem)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	// To build with other build systems, a build command must be provided
+	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
+		msg := `Flag "build-command" must be set when using the build system type "other"`
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if len(opts.Sanitizers) == 0 {
+		opts.Sanitizers = config.DefaultSanitizers()
+	} else {
+		err = config.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	return nil
+}
+
+// The possible outcomes of reproducing a finding
+type result int
+
+const (
+	// The fuzz test still produces the same finding
+	resultReproduced result = iota
+	// The fuzz test produces a finding which differs from the stored one
+	resultDiffers
+	// The fuzz test doesn't produce a finding anymore
+	resultFixed
+)
+
+type reproduceCmd struct {
+	*cobra.Command
+	opts *reproduceOptions
+}
+
+func New() *cobra.Command {
+	opts := &reproduceOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "reproduce [flags] <finding>",
+		Short: "Reproduce a finding with a fresh build of the fuzz test",
+		Long: "Rebuilds the fuzz test which produced the finding and executes it on the\n" +
+			"finding's crashing input to check whether the finding still reproduces,\n" +
+			"now looks different or is fixed.",
+		ValidArgsFunction: completion.ValidFindings,
+		Args:              cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			cmdutils.ViperMustBindPFlag("build-command", cmd.Flags().Lookup("build-command"))
+			cmdutils.ViperMustBindPFlag("build-output", cmd.Flags().Lookup("build-output"))
+			cmdutils.ViperMustBindPFlag("engine-args", cmd.Flags().Lookup("engine-arg"))
+			cmdutils.ViperMustBindPFlag("fuzz-test-args", cmd.Flags().Lookup("fuzz-test-arg"))
+			cmdutils.ViperMustBindPFlag("sanitizers", cmd.Flags().Lookup("sanitizers"))
+			cmdutils.ViperMustBindPFlag("use-sandbox", cmd.Flags().Lookup("use-sandbox"))
+
+			projectDir, err := config.ParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			opts.ProjectDir = projectDir
+
+			opts.findingName = args[0]
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := reproduceCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	cmd.Flags().String("build-command", "", `The command to build the fuzz test. Example: "make clean && make my-fuzz-test"`)
+	cmd.Flags().String("build-output", "", "The path of the fuzz test executable built by the build command,\nif the build system type is \"other\". Example: \"build/my_fuzz_test\"")
+	cmd.Flags().StringArray("engine-arg", nil, "Command-line argument to pass to the fuzzing engine.\nSee https://llvm.org/docs/LibFuzzer.html#options.")
+	cmd.Flags().StringArray("fuzz-test-arg", nil, "Command-line argument to pass to the fuzz test.")
+	cmd.Flags().StringSlice("sanitizers", nil, fmt.Sprintf("Comma-separated list of sanitizers to build the fuzz test with.\nValid sanitizers: %s. Default: %s.", strings.Join(config.SupportedSanitizers, ", "), strings.Join(config.DefaultSanitizers(), ",")))
+	cmd.Flags().Bool("use-sandbox", false, "By default, fuzz tests are executed in a sandbox to prevent accidental damage to the system.\nUse --use-sandbox=false to run the fuzz test unsandboxed.\nOnly supported on Linux.")
+	viper.SetDefault("use-sandbox", runtime.GOOS == "linux")
+	cmd.Flags().StringVar(&opts.fuzzTest, "fuzz-test", "", "The fuzz test which produced the finding.\nOnly required for findings which don't record their fuzz test.")
+
+	return cmd
+}
+
+func (c *reproduceCmd) run() error {
+	finding, err := report.LoadFinding(c.opts.ProjectDir, c.opts.findingName)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Errorf(err, "Finding %s does not exist in %s", c.opts.findingName, report.FindingsDir(c.opts.ProjectDir))
+		return cmdutils.ErrSilent
+	}
+	if err != nil {
+		return err
+	}
+	if finding.InputFile == "" {
+		err = errors.Errorf("Finding %s has no crashing input", finding.Name)
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+
+	if c.opts.fuzzTest == "" {
+		c.opts.fuzzTest = finding.FuzzTest
+	}
+	if c.opts.fuzzTest == "" {
+		msg := `Finding %s does not record the fuzz test which produced it,
+please specify it via the "fuzz-test" flag`
+		return cmdutils.WrapIncorrectUsageError(errors.Errorf(msg, finding.Name))
+	}
+
+	buildResult, err := c.buildFuzzTest()
+	if err != nil {
+		return err
+	}
+
+	reproduced, err := c.runFuzzTest(buildResult, finding.InputFile)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && c.opts.UseSandbox {
+			return cmdutils.WrapCouldBeSandboxError(err)
+		}
+		return err
+	}
+
+	switch compareFindings(finding, reproduced) {
+	case resultFixed:
+		log.Successf("Finding %s does not reproduce anymore", finding.Name)
+		return nil
+	case resultReproduced:
+		err = errors.Errorf("Finding %s still reproduces: %s", finding.Name, reproduced.Details)
+		log.Error(err)
+	case resultDiffers:
+		log.Warnf(`The crashing input of finding %s now produces a different finding:
+    before: %s
+    now:    %s`, finding.Name, finding.Details, reproduced.Details)
+	}
+	if !viper.GetBool("verbose") {
+		log.Print(strings.Join(reproduced.Logs, "\n"))
+	}
+	return cmdutils.ErrSilent
+}
+
+func (c *reproduceCmd) buildFuzzTest() (*build.Result, error) {
+	log.Infof("Building %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(c.opts.fuzzTest))
+
+	_, buildResults, err := fuzztests.Build(&fuzztests.BuildOptions{
+		ProjectDir:   c.opts.ProjectDir,
+		BuildSystem:  c.opts.BuildSystem,
+		BuildCommand: c.opts.BuildCommand,
+		BuildOutput:  c.opts.BuildOutput,
+		Engine:       string(config.LIBFUZZER),
+		Sanitizers:   c.opts.Sanitizers,
+		Stdout:       c.OutOrStdout(),
+		Stderr:       c.ErrOrStderr(),
+		FuzzTests:    []string{c.opts.fuzzTest},
+		// The finding is always reproduced against a fresh build of
+		// the fuzz test, a cached build might be outdated if its
+		// inputs aren't tracked precisely
+		Rebuild: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buildResults[c.opts.fuzzTest], nil
+}
+
+// runFuzzTest executes the fuzz test on the input and returns the
+// finding it produced, if any.
+func (c *reproduceCmd) runFuzzTest(buildResult *build.Result, input string) (*report.Finding, error) {
+	log.Infof("Running %s on %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(c.opts.fuzzTest), input)
+	log.Debugf("Executable: %s", buildResult.Executable)
+
+	// Ensure that symlinks are resolved to be able to add a minijail
+	// binding for the input.
+	input, err := filepath.EvalSymlinks(input)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	input, err = filepath.Abs(input)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	collector := &report.FindingCollector{}
+	runner := libfuzzer.NewRunner(&libfuzzer.RunnerOptions{
+		FuzzTarget:    buildResult.Executable,
+		EngineArgs:    c.opts.EngineArgs,
+		FuzzTestArgs:  c.opts.FuzzTestArgs,
+		ReportHandler: collector,
+		UseMinijail:   c.opts.UseSandbox,
+		Verbose:       viper.GetBool("verbose"),
+		KeepColor:     true,
+	})
+	err = runner.RunOnInputs(context.Background(), []string{input})
+	if err != nil {
+		var execErr *cmdutils.ExecError
+		if errors.As(err, &execErr) {
+			// It's expected that libFuzzer might fail due to user
+			// configuration, so we print the error without the stack trace.
+			log.Error(err)
+			return nil, cmdutils.ErrSilent
+		}
+		return nil, err
+	}
+
+	if len(collector.Findings) == 0 {
+		return nil, nil
+	}
+	// The first finding is the one which is most likely caused by the
+	// same bug as the original finding, further findings are often
+	// just consequences of the first one.
+	return collector.Findings[0], nil
+}
+
+// Matches addresses, pointers and process IDs in the details of a
+// finding, which differ between runs of the same fuzz test.
+var volatileNumberPattern = regexp.MustCompile(`0x[0-9a-fA-F]+|==\d+==`)
+
+func compareFindings(original, reproduced *report.Finding) result {
+	if reproduced == nil {
+		return resultFixed
+	}
+	if original.Signature != "" && reproduced.Signature != "" {
+		if original.Signature == reproduced.Signature {
+			return resultReproduced
+		}
+		return resultDiffers
+	}
+	// The signature is only available for findings with a symbolized
+	// stack trace, so we fall back to comparing the details.
+	if original.Type == reproduced.Type && normalizeDetails(original.Details) == normalizeDetails(reproduced.Details) {
+		return resultReproduced
+	}
+	return resultDiffers
+}
+
+func normalizeDetails(details string) string {
+	return volatileNumberPattern.ReplaceAllString(strings.TrimSpace(details), "")
+}
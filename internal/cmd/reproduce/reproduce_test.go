@@ -0,0 +1,94 @@
+package reproduce
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestReproduceCmd(t *testing.T) {
+	_, err := cmdutils.ExecuteCommand(t, New(), os.Stdin)
+	assert.Error(t, err)
+}
+
+func TestCompareFindings(t *testing.T) {
+	original := &report.Finding{
+		Type:    report.ErrorType_CRASH,
+		Details: "heap-buffer-overflow on address 0x602000000011 at pc 0x0000004e5b4c",
+	}
+	withSignature := &report.Finding{
+		Type:      report.ErrorType_CRASH,
+		Details:   "heap-buffer-overflow on address 0x602000000011 at pc 0x0000004e5b4c",
+		Signature: "abc",
+	}
+
+	tests := []struct {
+		name       string
+		original   *report.Finding
+		reproduced *report.Finding
+		expected   result
+	}{
+		{
+			name:       "fixed",
+			original:   original,
+			reproduced: nil,
+			expected:   resultFixed,
+		},
+		{
+			name:     "reproduced",
+			original: original,
+			reproduced: &report.Finding{
+				Type:    report.ErrorType_CRASH,
+				Details: "heap-buffer-overflow on address 0x603000000042 at pc 0x0000004e6c5d",
+			},
+			expected: resultReproduced,
+		},
+		{
+			name:     "differs",
+			original: original,
+			reproduced: &report.Finding{
+				Type:    report.ErrorType_CRASH,
+				Details: "stack-buffer-overflow on address 0x7ffd4a3b2c10 at pc 0x0000004e5b4c",
+			},
+			expected: resultDiffers,
+		},
+		{
+			name:     "same signature, different details",
+			original: withSignature,
+			reproduced: &report.Finding{
+				Type:      report.ErrorType_CRASH,
+				Details:   "heap-buffer-overflow on address 0x603000000042 at pc 0x0000004e6c5d in parse",
+				Signature: "abc",
+			},
+			expected: resultReproduced,
+		},
+		{
+			name:     "different signature, same details",
+			original: withSignature,
+			reproduced: &report.Finding{
+				Type:      report.ErrorType_CRASH,
+				Details:   "heap-buffer-overflow on address 0x602000000011 at pc 0x0000004e5b4c",
+				Signature: "def",
+			},
+			expected: resultDiffers,
+		},
+		{
+			name:     "signature missing, same details",
+			original: withSignature,
+			reproduced: &report.Finding{
+				Type:    report.ErrorType_CRASH,
+				Details: "heap-buffer-overflow on address 0x603000000042 at pc 0x0000004e6c5d",
+			},
+			expected: resultReproduced,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, compareFindings(tt.original, tt.reproduced))
+		})
+	}
+}
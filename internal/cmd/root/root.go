@@ -13,10 +13,13 @@ import (
 	"github.com/spf13/viper"
 
 	bundleCmd "code-intelligence.com/cifuzz/internal/cmd/bundle"
+	corpusCmd "code-intelligence.com/cifuzz/internal/cmd/corpus"
 	coverageCmd "code-intelligence.com/cifuzz/internal/cmd/coverage"
 	createCmd "code-intelligence.com/cifuzz/internal/cmd/create"
+	findingsCmd "code-intelligence.com/cifuzz/internal/cmd/findings"
 	initCmd "code-intelligence.com/cifuzz/internal/cmd/init"
 	reloadCmd "code-intelligence.com/cifuzz/internal/cmd/reload"
+	reproduceCmd "code-intelligence.com/cifuzz/internal/cmd/reproduce"
 	runCmd "code-intelligence.com/cifuzz/internal/cmd/run"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
@@ -90,6 +93,9 @@ func New() (*cobra.Command, error) {
 	rootCmd.AddCommand(reloadCmd.New())
 	rootCmd.AddCommand(bundleCmd.New(cmdConfig))
 	rootCmd.AddCommand(coverageCmd.New())
+	rootCmd.AddCommand(reproduceCmd.New())
+	rootCmd.AddCommand(findingsCmd.New(cmdConfig))
+	rootCmd.AddCommand(corpusCmd.New())
 
 	return rootCmd, nil
 }
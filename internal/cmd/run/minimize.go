@@ -0,0 +1,151 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// minimizeFindings minimizes the crashing inputs of the findings with
+// libFuzzer's -minimize_crash=1 mode. The minimized input is only kept
+// if it still produces a finding with the same crash signature, in
+// which case it's stored next to the original input in the finding dir
+// and replaces the input in the seed corpus. Each finding is minimized
+// for up to the minimize timeout, but not beyond the deadline, if set.
+func (c *runCmd) minimizeFindings(fuzzTest string, buildResult *build.Result, findings []*report.Finding, deadline time.Time) error {
+	for _, finding := range findings {
+		if finding.InputFile == "" || finding.OriginalInputFile != "" {
+			// There is no input or it was already minimized
+			continue
+		}
+		if finding.Signature == "" {
+			// We can't check if the minimized input still produces the
+			// same finding
+			log.Debugf("Not minimizing the crashing input of finding %s, it has no crash signature", finding.Name)
+			continue
+		}
+
+		timeout := c.opts.MinimizeTimeout
+		if !deadline.IsZero() {
+			left := time.Until(deadline)
+			if left < time.Second {
+				log.Infof("Not minimizing the crashing input of finding %s, the total time is used up", finding.Name)
+				continue
+			}
+			if left < timeout {
+				timeout = left
+			}
+		}
+
+		err := c.minimizeFinding(fuzzTest, buildResult, finding, timeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *runCmd) minimizeFinding(fuzzTest string, buildResult *build.Result, finding *report.Finding, timeout time.Duration) error {
+	log.Infof("Minimizing the crashing input of finding %s", finding.Name)
+
+	// Ensure that symlinks are resolved to be able to add a minijail
+	// binding for the input
+	input, err := filepath.EvalSymlinks(finding.InputFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	input, err = filepath.Abs(input)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	runner := libfuzzer.NewRunner(&libfuzzer.RunnerOptions{
+		FuzzTarget:   buildResult.Executable,
+		EngineArgs:   c.opts.EngineArgs,
+		FuzzTestArgs: c.opts.FuzzTestArgs,
+		// libFuzzer executes the fuzz test on a lot of crashing inputs
+		// while minimizing, we're not interested in those findings
+		ReportHandler: &report.FindingCollector{},
+		Timeout:       timeout,
+		UseMinijail:   c.opts.UseSandbox,
+		Verbose:       viper.GetBool("verbose"),
+		KeepColor:     !c.opts.PrintJSON,
+	})
+	minimized, err := runner.MinimizeCrash(context.Background(), input)
+	if err != nil {
+		var execErr *cmdutils.ExecError
+		if errors.As(err, &execErr) {
+			// Minimizing is best effort, so we keep the original input
+			log.Warnf("Failed to minimize the crashing input of finding %s: %v", finding.Name, err)
+			return nil
+		}
+		return err
+	}
+	if len(minimized) == 0 || len(minimized) >= len(finding.InputData) {
+		log.Infof("Could not minimize the crashing input of finding %s", finding.Name)
+		return nil
+	}
+
+	// Check that the minimized input still produces the same finding.
+	// libFuzzer only checks that it still crashes the fuzz test, which
+	// might be caused by a different bug.
+	tmpDir, err := os.MkdirTemp("", "cifuzz-minimized-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+	tmpDir, err = filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	minimizedInput := filepath.Join(tmpDir, finding.Name)
+	err = os.WriteFile(minimizedInput, minimized, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	reproduced, err := c.replay(fuzzTest, buildResult, []string{minimizedInput})
+	if errors.Is(err, cmdutils.ErrSilent) {
+		// The error was already printed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if reproduced == nil || reproduced.Signature != finding.Signature {
+		log.Warnf("Not using the minimized crashing input of finding %s, it produces a different finding", finding.Name)
+		return nil
+	}
+
+	originalSize := len(finding.InputData)
+	err = finding.SaveMinimizedInput(c.opts.ProjectDir, minimized)
+	if err != nil {
+		return err
+	}
+
+	// Replace the crashing input which was added to the seed corpus
+	seedPath := filepath.Join(buildResult.SeedCorpus, finding.Name)
+	exists, err := fileutil.Exists(seedPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = os.WriteFile(seedPath, minimized, 0644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	log.Successf("Minimized the crashing input of finding %s from %d to %d bytes", finding.Name, originalSize, len(minimized))
+	return nil
+}
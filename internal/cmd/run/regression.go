@@ -0,0 +1,358 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// regressionInput is an input which is replayed in a regression test
+type regressionInput struct {
+	// The name which is used to refer to the input in the output, that's
+	// either the name of the finding or the path of the input
+	name string
+	path string
+	// The corpus dir which contains the input, empty for the inputs of
+	// findings
+	dir string
+}
+
+// regressionResult is the result of replaying a single input
+type regressionResult struct {
+	input    *regressionInput
+	duration time.Duration
+	// Nil if the input passed
+	finding *report.Finding
+}
+
+// runRegressionTests runs the regression test of each fuzz test and
+// writes the report. It returns cmdutils.ErrSilent if any of the inputs
+// produced a finding.
+func (c *runCmd) runRegressionTests(buildResults map[string]*build.Result) error {
+	var findings []*report.Finding
+	var suites []*report.JUnitTestSuite
+	var numInputs, numFailed int
+	for _, fuzzTest := range c.opts.fuzzTests {
+		startedAt := time.Now()
+		results, err := c.runRegressionTest(fuzzTest, buildResults[fuzzTest])
+		if err != nil {
+			return err
+		}
+		suites = append(suites, regressionTestSuite(fuzzTest, results, time.Since(startedAt)))
+		numInputs += len(results)
+		for _, result := range results {
+			if result.finding != nil {
+				findings = append(findings, result.finding)
+				numFailed++
+			}
+		}
+	}
+
+	if c.opts.ReportFormat != "" {
+		err := c.writeReport(findings, suites)
+		if err != nil {
+			return err
+		}
+	}
+
+	if numFailed > 0 {
+		err := errors.Errorf("Regression test failed: %d of %d inputs produced a finding", numFailed, numInputs)
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+
+	log.Successf("Regression test passed: %d inputs", numInputs)
+	return nil
+}
+
+// runRegressionTest executes the fuzz test on all inputs of the seed
+// corpus, the generated corpus and the saved findings of the fuzz test
+// without fuzzing.
+func (c *runCmd) runRegressionTest(fuzzTest string, buildResult *build.Result) ([]*regressionResult, error) {
+	log.Infof("Running regression test for %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(fuzzTest))
+	log.Debugf("Executable: %s", buildResult.Executable)
+
+	inputs, err := c.regressionInputs(fuzzTest, buildResult)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		log.Warnf("No inputs found for the regression test of %s", fuzzTest)
+	}
+
+	results, err := c.replayInputs(fuzzTest, buildResult, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.finding == nil {
+			log.Debugf("PASS %s", result.input.name)
+			continue
+		}
+		log.Printf("FAIL %s: %s", result.input.name, result.finding.Details)
+		if !viper.GetBool("verbose") {
+			log.Print(strings.Join(result.finding.Logs, "\n"))
+		}
+	}
+
+	return results, nil
+}
+
+// regressionInputs returns the inputs which are replayed in the
+// regression test. Inputs with the same content are only replayed once,
+// preferring the inputs of findings, so that the name of the finding is
+// used in the output.
+func (c *runCmd) regressionInputs(fuzzTest string, buildResult *build.Result) ([]*regressionInput, error) {
+	var inputs []*regressionInput
+	seen := map[string]bool{}
+	add := func(name, path, dir string) error {
+		// Ensure that symlinks are resolved to be able to add minijail
+		// bindings for the inputs.
+		path, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		hash, err := fileutil.SHA1(path)
+		if err != nil {
+			return err
+		}
+		if seen[hash] {
+			return nil
+		}
+		seen[hash] = true
+		inputs = append(inputs, &regressionInput{name: name, path: path, dir: dir})
+		return nil
+	}
+
+	findings, err := report.ListFindings(c.opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, finding := range findings {
+		if finding.FuzzTest != fuzzTest || finding.InputFile == "" {
+			continue
+		}
+		err = add(finding.Name, finding.InputFile, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	corpusDirs := append([]string{}, c.opts.SeedCorpusDirs...)
+	corpusDirs = append(corpusDirs, buildResult.SeedCorpus, cmdutils.GeneratedCorpusDir(c.opts.ProjectDir, fuzzTest))
+	for _, dir := range corpusDirs {
+		exists, err := fileutil.Exists(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		// Ensure that symlinks are resolved to be able to add a minijail
+		// binding for the corpus dir
+		resolvedDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		resolvedDir, err = filepath.Abs(resolvedDir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var paths []string
+		err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			err = add(fileutil.PrettifyPath(path), path, resolvedDir)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return inputs, nil
+}
+
+// replayInputs executes the fuzz test on the inputs. In the common case
+// that none of the inputs produce a finding, that only takes a single
+// execution of the fuzz test on the corpus dirs. Else, each input is
+// replayed on its own to find out which inputs produce a finding,
+// because libFuzzer stops at the first input which produces a finding.
+func (c *runCmd) replayInputs(fuzzTest string, buildResult *build.Result, inputs []*regressionInput) ([]*regressionResult, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	startedAt := time.Now()
+	corpusFinding, err := c.replayCorpus(fuzzTest, buildResult, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if corpusFinding == nil {
+		// The duration of the individual inputs is unknown, so we
+		// distribute the total duration evenly
+		duration := time.Since(startedAt) / time.Duration(len(inputs))
+		var results []*regressionResult
+		for _, input := range inputs {
+			results = append(results, &regressionResult{input: input, duration: duration})
+		}
+		return results, nil
+	}
+
+	var results []*regressionResult
+	var numFailed int
+	for _, input := range inputs {
+		startedAt := time.Now()
+		finding, err := c.replay(fuzzTest, buildResult, []string{input.path})
+		if err != nil {
+			return nil, err
+		}
+		if finding != nil {
+			numFailed++
+		}
+		results = append(results, &regressionResult{
+			input:    input,
+			duration: time.Since(startedAt),
+			finding:  finding,
+		})
+	}
+
+	if numFailed == 0 {
+		// The finding depends on the state left behind by previous
+		// inputs, so we report it for the fuzz test as a whole
+		log.Warnf("The finding of %s could not be reproduced with a single input", fuzzTest)
+		results = append(results, &regressionResult{
+			input:   &regressionInput{name: fuzzTest},
+			finding: corpusFinding,
+		})
+	}
+	return results, nil
+}
+
+// replayCorpus executes the fuzz test on all inputs in a single run.
+// The corpus dirs are passed to libFuzzer as a whole instead of the
+// individual inputs, the inputs of findings are copied to a temporary
+// dir for that.
+func (c *runCmd) replayCorpus(fuzzTest string, buildResult *build.Result, inputs []*regressionInput) (*report.Finding, error) {
+	findingsDir, err := os.MkdirTemp("", "cifuzz-regression-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(findingsDir)
+	findingsDir, err = filepath.EvalSymlinks(findingsDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// libFuzzer might write to the first corpus dir, so that's the
+	// temporary dir
+	dirs := []string{findingsDir}
+	for _, input := range inputs {
+		if input.dir == "" {
+			data, err := os.ReadFile(input.path)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			err = os.WriteFile(filepath.Join(findingsDir, input.name), data, 0644)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			continue
+		}
+		if !stringutil.Contains(dirs, input.dir) {
+			dirs = append(dirs, input.dir)
+		}
+	}
+
+	return c.replayWith(fuzzTest, buildResult, func(runner *libfuzzer.Runner) error {
+		return runner.RunOnCorpusDirs(context.Background(), dirs)
+	})
+}
+
+// replay executes the fuzz test on the inputs and returns the first
+// finding it produced, if any.
+func (c *runCmd) replay(fuzzTest string, buildResult *build.Result, inputs []string) (*report.Finding, error) {
+	return c.replayWith(fuzzTest, buildResult, func(runner *libfuzzer.Runner) error {
+		return runner.RunOnInputs(context.Background(), inputs)
+	})
+}
+
+// replayWith runs the fuzz test via the given function and returns the
+// first finding it produced, if any.
+func (c *runCmd) replayWith(fuzzTest string, buildResult *build.Result, run func(*libfuzzer.Runner) error) (*report.Finding, error) {
+	collector := &report.FindingCollector{}
+	runner := libfuzzer.NewRunner(&libfuzzer.RunnerOptions{
+		FuzzTarget:    buildResult.Executable,
+		EngineArgs:    c.opts.EngineArgs,
+		FuzzTestArgs:  c.opts.FuzzTestArgs,
+		ReportHandler: collector,
+		UseMinijail:   c.opts.UseSandbox,
+		Verbose:       viper.GetBool("verbose"),
+		KeepColor:     !c.opts.PrintJSON,
+	})
+	err := run(runner)
+	if err != nil {
+		var execErr *cmdutils.ExecError
+		if errors.As(err, &execErr) {
+			// It's expected that libFuzzer might fail due to user
+			// configuration, so we print the error without the stack trace.
+			log.Error(err)
+			return nil, cmdutils.ErrSilent
+		}
+		return nil, err
+	}
+
+	if len(collector.Findings) == 0 {
+		return nil, nil
+	}
+	finding := collector.Findings[0]
+	finding.FuzzTest = fuzzTest
+	return finding, nil
+}
+
+// regressionTestSuite describes the regression test of a fuzz test as a
+// JUnit test suite with a test case per replayed input.
+func regressionTestSuite(fuzzTest string, results []*regressionResult, duration time.Duration) *report.JUnitTestSuite {
+	suite := &report.JUnitTestSuite{
+		Name:     fuzzTest,
+		Duration: duration,
+	}
+	for _, result := range results {
+		testCase := &report.JUnitTestCase{Name: result.input.name, Duration: result.duration}
+		if result.finding != nil {
+			testCase.Failure = report.NewJUnitTestCaseFromFinding(result.finding).Failure
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
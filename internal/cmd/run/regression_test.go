@@ -0,0 +1,75 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestRegressionInputs(t *testing.T) {
+	projectDir := t.TempDir()
+	seedCorpusDir := filepath.Join(projectDir, "my_fuzz_test_inputs")
+	generatedCorpusDir := cmdutils.GeneratedCorpusDir(projectDir, "my_fuzz_test")
+	require.NoError(t, os.MkdirAll(seedCorpusDir, 0755))
+	require.NoError(t, os.MkdirAll(generatedCorpusDir, 0755))
+
+	// The crashing input of the finding was also copied to the seed
+	// corpus, so it should only be replayed once, under the name of the
+	// finding.
+	input := filepath.Join(projectDir, "crash")
+	require.NoError(t, os.WriteFile(input, []byte("crash"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpusDir, "lucid_turing"), []byte("crash"), 0644))
+	finding := &report.Finding{Name: "lucid_turing", FuzzTest: "my_fuzz_test", InputFile: input}
+	require.NoError(t, finding.Save(projectDir))
+	// Findings of other fuzz tests are not replayed
+	otherInput := filepath.Join(projectDir, "other")
+	require.NoError(t, os.WriteFile(otherInput, []byte("other"), 0644))
+	otherFinding := &report.Finding{Name: "brave_hopper", FuzzTest: "other_fuzz_test", InputFile: otherInput}
+	require.NoError(t, otherFinding.Save(projectDir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpusDir, "seed"), []byte("seed"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(generatedCorpusDir, "generated"), []byte("generated"), 0644))
+
+	c := &runCmd{opts: &runOptions{ProjectDir: projectDir}}
+	inputs, err := c.regressionInputs("my_fuzz_test", &build.Result{SeedCorpus: seedCorpusDir})
+	require.NoError(t, err)
+
+	var names []string
+	for _, input := range inputs {
+		names = append(names, filepath.Base(input.name))
+	}
+	assert.Equal(t, []string{"lucid_turing", "seed", "generated"}, names)
+
+	// The inputs of the corpus dirs are replayed via their dirs
+	resolvedSeedCorpusDir, err := filepath.EvalSymlinks(seedCorpusDir)
+	require.NoError(t, err)
+	assert.Empty(t, inputs[0].dir)
+	assert.Equal(t, resolvedSeedCorpusDir, inputs[1].dir)
+}
+
+func TestRegressionTestSuite(t *testing.T) {
+	results := []*regressionResult{
+		{input: &regressionInput{name: "seed"}, duration: time.Second},
+		{
+			input:   &regressionInput{name: "lucid_turing"},
+			finding: &report.Finding{Type: report.ErrorType_CRASH, Details: "heap-buffer-overflow"},
+		},
+	}
+
+	suite := regressionTestSuite("my_fuzz_test", results, 2*time.Second)
+	assert.Equal(t, "my_fuzz_test", suite.Name)
+	require.Len(t, suite.TestCases, 2)
+	assert.Equal(t, "seed", suite.TestCases[0].Name)
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.Equal(t, "lucid_turing", suite.TestCases[1].Name)
+	require.NotNil(t, suite.TestCases[1].Failure)
+	assert.Equal(t, "heap-buffer-overflow", suite.TestCases[1].Failure.Message)
+}
@@ -2,6 +2,7 @@ package report_handler
 
 import (
 	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -25,40 +26,56 @@ import (
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
+type ReportHandlerOptions struct {
+	ProjectDir    string
+	SeedCorpusDir string
+	// The name of the fuzz test which is stored in the findings
+	FuzzTest  string
+	PrintJSON bool
+	Verbose   bool
+}
+
 type ReportHandler struct {
-	printJSON            bool
-	verbose              bool
+	*ReportHandlerOptions
 	usingUpdatingPrinter bool
 
 	printer      metrics.Printer
 	startedAt    time.Time
+	runID        string
 	initStarted  bool
 	initFinished bool
 
 	lastMetrics  *report.FuzzingMetric
 	firstMetrics *report.FuzzingMetric
 
-	numFindings    uint
+	// The number of findings reported during this run, including
+	// duplicates of findings which were found before
+	numFindings uint
+	// The number of findings which weren't found before
+	numNewFindings uint
 	numSeedsAtInit uint
-	seedCorpusDir  string
+
+	// The findings of this run, duplicates are merged
+	findings []*report.Finding
 
 	jsonOutput io.Writer
 }
 
-func NewReportHandler(seedCorpusDir string, printJSON, verbose bool) (*ReportHandler, error) {
+func NewReportHandler(options *ReportHandlerOptions) (*ReportHandler, error) {
 	var err error
 	h := &ReportHandler{
-		seedCorpusDir: seedCorpusDir,
-		printJSON:     printJSON,
-		verbose:       verbose,
-		startedAt:     time.Now(),
-		jsonOutput:    os.Stdout,
+		ReportHandlerOptions: options,
+		startedAt:            time.Now(),
+		jsonOutput:           os.Stdout,
 	}
+	// Identify the run by the time it was started, which allows to
+	// tell which findings were produced by the same run.
+	h.runID = h.startedAt.UTC().Format("20060102T150405Z")
 
 	// When --json was used, we don't want anything but JSON output on
 	// stdout, so we make the printer use stderr.
 	var printerOutput *os.File
-	if printJSON {
+	if h.PrintJSON {
 		printerOutput = os.Stderr
 	} else {
 		printerOutput = os.Stdout
@@ -81,28 +98,64 @@ func NewReportHandler(seedCorpusDir string, printJSON, verbose bool) (*ReportHan
 func (h *ReportHandler) Handle(r *report.Report) error {
 	var err error
 
-	if r.Finding != nil {
-		// Count the number of findings for the final metrics
-		h.numFindings += 1
+	// Whether the finding is a duplicate of a finding which was found
+	// before and was merged into that one
+	var duplicate bool
 
+	if r.Finding != nil {
 		if r.Finding.Name == "" {
-			// create a name based on a hash of the crashing input
-			h := sha1.New()
-			h.Write(r.Finding.InputData)
-			r.Finding.Name = names.GetDeterministicName(h.Sum(nil))
+			r.Finding.Name = generateName(r.Finding)
 		}
 
-		if err := r.Finding.Save(); err != nil {
+		if r.Finding.FuzzTest == "" {
+			r.Finding.FuzzTest = h.FuzzTest
+		}
+		if r.Finding.RunID == "" {
+			r.Finding.RunID = h.runID
+		}
+		if r.Finding.CreatedAt.IsZero() {
+			r.Finding.CreatedAt = time.Now()
+		}
+		if r.Finding.HumanReadableInput == "" {
+			r.Finding.HumanReadableInput = report.HumanReadableInput(r.Finding.InputData)
+		}
+		if r.Finding.MoreDetails != nil && r.Finding.MoreDetails.Knowledge == nil {
+			r.Finding.MoreDetails.Knowledge = report.LookupKnowledge(r.Finding.MoreDetails.Id)
+		}
+
+		var inputReplaced bool
+		duplicate, inputReplaced, err = h.mergeIntoExistingFinding(r.Finding)
+		if err != nil {
 			return err
 		}
 
-		// Copy the input file to the seed corpus dir
-		if r.Finding.InputFile != "" {
-			err = os.MkdirAll(h.seedCorpusDir, 0755)
+		h.addFinding(r.Finding)
+
+		// Count the number of findings for the final metrics
+		h.numFindings += 1
+
+		if !duplicate {
+			h.numNewFindings += 1
+
+			if r.Finding.Count == 0 {
+				r.Finding.Count = 1
+			}
+
+			if err := r.Finding.Save(h.ProjectDir); err != nil {
+				return err
+			}
+		}
+
+		// Copy the input file to the seed corpus dir. For duplicates,
+		// this is only done if the input is smaller than the one which
+		// was stored before, to avoid adding a new seed for each
+		// occurrence of the same bug.
+		if r.Finding.InputFile != "" && (!duplicate || inputReplaced) {
+			err = os.MkdirAll(h.SeedCorpusDir, 0755)
 			if err != nil {
 				return errors.WithStack(err)
 			}
-			err = copy.Copy(r.Finding.InputFile, filepath.Join(h.seedCorpusDir, r.Finding.Name))
+			err = copy.Copy(r.Finding.InputFile, filepath.Join(h.SeedCorpusDir, r.Finding.Name))
 			if err != nil {
 				return errors.WithStack(err)
 			}
@@ -110,7 +163,7 @@ func (h *ReportHandler) Handle(r *report.Report) error {
 	}
 
 	// Print report as JSON if the --json flag was specified
-	if h.printJSON {
+	if h.PrintJSON {
 		var jsonString string
 		// Print with color if the output stream is a TTY
 		if file, ok := h.jsonOutput.(*os.File); !ok || !term.IsTerminal(int(file.Fd())) {
@@ -149,13 +202,22 @@ func (h *ReportHandler) Handle(r *report.Report) error {
 		h.initFinished = true
 	}
 
-	if r.Finding != nil && !h.verbose {
+	if duplicate && !h.Verbose {
+		log.Infof("Found finding %s again (%d times in total)", r.Finding.Name, r.Finding.Count)
+	} else if r.Finding != nil && !h.Verbose {
 		log.Print("\n")
 		log.Printf("=========================== Finding %d ===========================", h.numFindings)
 		log.Print(strings.Join(r.Finding.Logs, "\n"))
 
+		if r.Finding.ShortDescription != "" {
+			log.Printf("\nFinding %s: %s", r.Finding.Name, r.Finding.ShortDescription)
+		} else if summary := r.Finding.CrashSummary(); summary != "" {
+			log.Printf("\nFinding %s %s", r.Finding.Name, summary)
+		}
+		printErrorDetails(r.Finding.MoreDetails)
+
 		if r.Finding.InputFile != "" {
-			seedPath := fileutil.PrettifyPath(filepath.Join(h.seedCorpusDir, r.Finding.Name))
+			seedPath := fileutil.PrettifyPath(filepath.Join(h.SeedCorpusDir, r.Finding.Name))
 			log.Notef(`
 Note: The crashing input has been copied to the seed corpus at:
 
@@ -184,36 +246,153 @@ regression tests. For more information on regression tests, see:
 	return nil
 }
 
-func (h *ReportHandler) PrintFinalMetrics(numSeeds uint) error {
-	// We don't want to print colors to stderr unless it's a TTY
-	if !term.IsTerminal(int(os.Stderr.Fd())) {
-		color.Disable()
+// printErrorDetails prints the severity of a finding and what is known
+// about its kind of bug
+func printErrorDetails(details *report.ErrorDetails) {
+	if details == nil {
+		return
+	}
+	if details.Severity != nil && details.Severity.Score != 0 {
+		log.Printf("Severity: %s (%.1f)", details.Severity.Description, details.Severity.Score)
+	}
+	if knowledge := details.Knowledge; knowledge != nil {
+		log.Printf("\n%s", knowledge.Description)
+		log.Printf("\nTypical root cause: %s", knowledge.RootCause)
+		log.Printf("How to fix: %s", knowledge.Mitigation)
 	}
+}
 
-	if h.usingUpdatingPrinter {
-		// Stop the updating printer
-		updatingPrinter := h.printer.(*metrics.UpdatingPrinter)
-		err := updatingPrinter.Stop()
-		if err != nil {
-			return errors.WithStack(err)
+// addFinding adds the finding to the findings of this run or replaces
+// the finding with the same name if it was found before in this run.
+func (h *ReportHandler) addFinding(finding *report.Finding) {
+	for i, f := range h.findings {
+		if f.Name == finding.Name {
+			h.findings[i] = finding
+			return
 		}
+	}
+	h.findings = append(h.findings, finding)
+}
+
+// Findings returns the findings which were reported during this run.
+func (h *ReportHandler) Findings() []*report.Finding {
+	return h.findings
+}
+
+// generateName returns a name for the finding which is derived from
+// the crash signature, so that all occurrences of the same bug get the
+// same name. If the finding doesn't have a signature, the name is
+// derived from the crashing input instead.
+func generateName(finding *report.Finding) string {
+	h := sha1.New()
+	if finding.Signature != "" {
+		h.Write([]byte(finding.Signature))
 	} else {
-		// Stopping the updating printer leaves an empty line, which
-		// we actually want before the final metrics (because it looks
-		// better), so in case we did not use an updating printer,
-		// print an empty line anyway.
-		log.Print("\n")
+		h.Write(finding.InputData)
 	}
+	return names.GetDeterministicName(h.Sum(nil))
+}
 
-	duration := time.Since(h.startedAt)
-	totalSeeds := numSeeds
-	newSeeds := totalSeeds - h.numSeedsAtInit
+// signatureName returns the name for a finding whose generated name is
+// already used by a finding with a different signature. It appends a
+// hash of the full signature to the name, which makes it unique unless
+// the hashes of both signatures start with the same 32 bits.
+func signatureName(finding *report.Finding) string {
+	hash := sha1.Sum([]byte(finding.Signature))
+	return finding.Name + "_" + hex.EncodeToString(hash[:4])
+}
 
-	var averageExecsStr string
+// mergeIntoExistingFinding checks if a finding with the same signature
+// was stored before. If that's the case, the count of the existing
+// finding is increased and its crashing input is replaced if the new
+// one is smaller. The finding is updated to the merged finding.
+func (h *ReportHandler) mergeIntoExistingFinding(finding *report.Finding) (duplicate bool, inputReplaced bool, err error) {
+	if finding.Signature == "" {
+		return false, false, nil
+	}
 
-	if h.firstMetrics == nil {
-		averageExecsStr = metrics.NumberString("n/a")
-	} else {
+	existing, err := report.LoadFinding(h.ProjectDir, finding.Name)
+	if err == nil && existing.Signature != finding.Signature {
+		// Different bugs which happen to have the same name, so the
+		// finding gets a name which is derived from its full signature
+		log.Debugf("Finding %s has a different signature, not merging", finding.Name)
+		finding.Name = signatureName(finding)
+		existing, err = report.LoadFinding(h.ProjectDir, finding.Name)
+		if err == nil && existing.Signature != finding.Signature {
+			return false, false, errors.Errorf("Finding %s already exists with a different signature", finding.Name)
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	if existing.Count == 0 {
+		// Findings which were stored before counting was introduced
+		existing.Count = 1
+	}
+	existing.Count += 1
+
+	if finding.InputFile != "" && len(finding.InputData) < len(existing.InputData) {
+		// Keep the smaller reproducer
+		existing.InputData = finding.InputData
+		existing.InputFile = finding.InputFile
+		existing.HumanReadableInput = finding.HumanReadableInput
+		existing.Logs = finding.Logs
+		existing.Details = finding.Details
+		existing.ShortDescription = finding.ShortDescription
+		existing.StackTrace = finding.StackTrace
+		inputReplaced = true
+	} else if finding.InputFile != "" {
+		// The crashing input is not needed anymore
+		err = os.Remove(finding.InputFile)
+		if err != nil {
+			return false, false, errors.WithStack(err)
+		}
+	}
+
+	err = existing.Save(h.ProjectDir)
+	if err != nil {
+		return false, false, err
+	}
+
+	*finding = *existing
+	return true, inputReplaced, nil
+}
+
+// FinalMetrics describes the results of a fuzzing run
+type FinalMetrics struct {
+	FuzzTest string
+	Duration time.Duration
+	// Nil if no metrics were reported during the run
+	AverageExecsPerSecond *uint64
+	NumFindings           uint
+	// The number of findings which weren't found before
+	NumNewFindings uint
+	NewSeeds       uint
+	TotalSeeds     uint
+}
+
+// FinalMetrics calculates the final metrics of the run. numSeeds is the
+// total number of seeds in the corpus directories after the run.
+func (h *ReportHandler) FinalMetrics(numSeeds uint) *FinalMetrics {
+	m := &FinalMetrics{
+		FuzzTest:       h.FuzzTest,
+		Duration:       time.Since(h.startedAt),
+		NumFindings:    h.numFindings,
+		NumNewFindings: h.numNewFindings,
+		TotalSeeds:     numSeeds,
+	}
+	// The fuzzer might have counted more seeds than there are now, e.g.
+	// because seeds were removed during the run, so we make sure that
+	// the number of new seeds doesn't wrap around
+	if numSeeds > h.numSeedsAtInit {
+		m.NewSeeds = numSeeds - h.numSeedsAtInit
+	}
+
+	if h.firstMetrics != nil {
 		var averageExecs uint64
 		metricsDuration := h.lastMetrics.Timestamp.Sub(h.firstMetrics.Timestamp)
 		if metricsDuration.Milliseconds() == 0 {
@@ -227,19 +406,75 @@ func (h *ReportHandler) PrintFinalMetrics(numSeeds uint) error {
 			execs := h.lastMetrics.TotalExecutions - h.firstMetrics.TotalExecutions
 			averageExecs = uint64(float64(execs) / (float64(metricsDuration.Milliseconds()) / 1000))
 		}
-		averageExecsStr = metrics.NumberString("%d", averageExecs)
+		m.AverageExecsPerSecond = &averageExecs
 	}
 
+	return m
+}
+
+// Merge adds the metrics of another run of the same fuzz test
+func (m *FinalMetrics) Merge(other *FinalMetrics) {
+	if other.AverageExecsPerSecond != nil {
+		if m.AverageExecsPerSecond == nil {
+			m.AverageExecsPerSecond = other.AverageExecsPerSecond
+		} else {
+			// Weight the averages by the duration of the runs
+			totalSeconds := (m.Duration + other.Duration).Seconds()
+			if totalSeconds > 0 {
+				averageExecs := uint64((float64(*m.AverageExecsPerSecond)*m.Duration.Seconds() +
+					float64(*other.AverageExecsPerSecond)*other.Duration.Seconds()) / totalSeconds)
+				m.AverageExecsPerSecond = &averageExecs
+			}
+		}
+	}
+	m.Duration += other.Duration
+	m.NumFindings += other.NumFindings
+	m.NumNewFindings += other.NumNewFindings
+	m.NewSeeds += other.NewSeeds
+	m.TotalSeeds = other.TotalSeeds
+}
+
+func (m *FinalMetrics) durationString() string {
 	// Round towards the next larger second to avoid that very short
 	// runs show "Ran for 0s".
-	durationStr := (duration.Truncate(time.Second) + time.Second).String()
+	return (m.Duration.Truncate(time.Second) + time.Second).String()
+}
+
+func (m *FinalMetrics) averageExecsString() string {
+	if m.AverageExecsPerSecond == nil {
+		return metrics.NumberString("n/a")
+	}
+	return metrics.NumberString("%d", *m.AverageExecsPerSecond)
+}
+
+func (h *ReportHandler) PrintFinalMetrics(finalMetrics *FinalMetrics) error {
+	// We don't want to print colors to stderr unless it's a TTY
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		color.Disable()
+	}
+
+	if h.usingUpdatingPrinter {
+		// Stop the updating printer
+		updatingPrinter := h.printer.(*metrics.UpdatingPrinter)
+		err := updatingPrinter.Stop()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		// Stopping the updating printer leaves an empty line, which
+		// we actually want before the final metrics (because it looks
+		// better), so in case we did not use an updating printer,
+		// print an empty line anyway.
+		log.Print("\n")
+	}
 
 	lines := []string{
-		metrics.DescString("Execution time:\t") + metrics.NumberString(durationStr),
-		metrics.DescString("Average exec/s:\t") + averageExecsStr,
-		metrics.DescString("Findings:\t") + metrics.NumberString("%d", h.numFindings),
-		metrics.DescString("New seeds:\t") + metrics.NumberString("%d", newSeeds) +
-			metrics.DescString(" (total: %s)", metrics.NumberString("%d", totalSeeds)),
+		metrics.DescString("Execution time:\t") + metrics.NumberString(finalMetrics.durationString()),
+		metrics.DescString("Average exec/s:\t") + finalMetrics.averageExecsString(),
+		metrics.DescString("Findings:\t") + metrics.NumberString("%d", finalMetrics.NumFindings) +
+			metrics.DescString(" (new: %s)", metrics.NumberString("%d", finalMetrics.NumNewFindings)),
+		metrics.DescString("New seeds:\t") + metrics.NumberString("%d", finalMetrics.NewSeeds) +
+			metrics.DescString(" (total: %s)", metrics.NumberString("%d", finalMetrics.TotalSeeds)),
 	}
 
 	w := tabwriter.NewWriter(log.NewPTermWriter(os.Stderr), 0, 0, 1, ' ', 0)
@@ -256,3 +491,49 @@ func (h *ReportHandler) PrintFinalMetrics(numSeeds uint) error {
 
 	return nil
 }
+
+// PrintCombinedFinalMetrics prints a table with the final metrics of
+// each fuzz test of a run with multiple fuzz tests and the totals.
+func PrintCombinedFinalMetrics(allMetrics []*FinalMetrics) error {
+	// We don't want to print colors to stderr unless it's a TTY
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		color.Disable()
+	}
+
+	total := &FinalMetrics{FuzzTest: "Total"}
+	var totalSeeds uint
+	for _, m := range allMetrics {
+		total.Merge(m)
+		totalSeeds += m.TotalSeeds
+	}
+	total.TotalSeeds = totalSeeds
+
+	lines := []string{
+		metrics.DescString("Fuzz test\tExecution time\tAverage exec/s\tFindings\tNew seeds\t"),
+	}
+	for _, m := range append(allMetrics, total) {
+		lines = append(lines, strings.Join([]string{
+			m.FuzzTest,
+			metrics.NumberString(m.durationString()),
+			m.averageExecsString(),
+			metrics.NumberString("%d", m.NumFindings),
+			metrics.NumberString("%d", m.NewSeeds) +
+				metrics.DescString(" (total: %s)", metrics.NumberString("%d", m.TotalSeeds)),
+		}, "\t")+"\t")
+	}
+
+	log.Print("\n")
+	w := tabwriter.NewWriter(log.NewPTermWriter(os.Stderr), 0, 0, 2, ' ', 0)
+	for _, line := range lines {
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err := w.Flush()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,7 +37,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestReportHandler_EmptyCorpus(t *testing.T) {
-	h, err := NewReportHandler("", false, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{})
 	require.NoError(t, err)
 
 	initStartedReport := &report.Report{
@@ -50,7 +51,7 @@ func TestReportHandler_EmptyCorpus(t *testing.T) {
 }
 
 func TestReportHandler_NonEmptyCorpus(t *testing.T) {
-	h, err := NewReportHandler("", false, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{})
 	require.NoError(t, err)
 
 	initStartedReport := &report.Report{
@@ -67,8 +68,19 @@ func TestReportHandler_NonEmptyCorpus(t *testing.T) {
 	checkOutput(t, logOutput, "Successfully initialized fuzzer")
 }
 
+func TestReportHandler_FinalMetricsNewSeeds(t *testing.T) {
+	h, err := NewReportHandler(&ReportHandlerOptions{})
+	require.NoError(t, err)
+	err = h.Handle(&report.Report{Status: report.RunStatus_INITIALIZING, NumSeeds: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(5), h.FinalMetrics(15).NewSeeds)
+	// Fewer seeds than during initialization must not wrap around
+	assert.Equal(t, uint(0), h.FinalMetrics(5).NewSeeds)
+}
+
 func TestReportHandler_Metrics(t *testing.T) {
-	h, err := NewReportHandler("", false, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{})
 	require.NoError(t, err)
 
 	printerOut := bytes.NewBuffer([]byte{})
@@ -88,7 +100,7 @@ func TestReportHandler_Metrics(t *testing.T) {
 }
 
 func TestReportHandler_Finding(t *testing.T) {
-	h, err := NewReportHandler("seed_corpus", false, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{SeedCorpusDir: "seed_corpus"})
 	require.NoError(t, err)
 
 	// create an input file
@@ -108,12 +120,12 @@ func TestReportHandler_Finding(t *testing.T) {
 	require.NoError(t, err)
 
 	expectedOutputs := append([]string{"Finding 1"}, findingLogs...)
-	expectedOutputs = append(expectedOutputs, filepath.Join(h.seedCorpusDir, findingReport.Finding.Name))
+	expectedOutputs = append(expectedOutputs, filepath.Join(h.SeedCorpusDir, findingReport.Finding.Name))
 	checkOutput(t, logOutput, expectedOutputs...)
 }
 
 func TestReportHandler_PrintJSON(t *testing.T) {
-	h, err := NewReportHandler("", true, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{PrintJSON: true})
 	require.NoError(t, err)
 
 	jsonOut := bytes.NewBuffer([]byte{})
@@ -132,7 +144,7 @@ func TestReportHandler_PrintJSON(t *testing.T) {
 }
 
 func TestReportHandler_GenerateName(t *testing.T) {
-	h, err := NewReportHandler("", true, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{PrintJSON: true})
 	require.NoError(t, err)
 
 	findingLogs := []string{"Oops", "The program crashed"}
@@ -149,7 +161,7 @@ func TestReportHandler_GenerateName(t *testing.T) {
 }
 
 func TestReportHandler_NotOverrideName(t *testing.T) {
-	h, err := NewReportHandler("", true, false)
+	h, err := NewReportHandler(&ReportHandlerOptions{PrintJSON: true})
 	require.NoError(t, err)
 
 	findingLogs := []string{"Oops", "The program crashed"}
@@ -164,6 +176,43 @@ func TestReportHandler_NotOverrideName(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "MyName", findingReport.Finding.Name)
 }
+
+func TestReportHandler_ErrorDetails(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "error-details-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	h, err := NewReportHandler(&ReportHandlerOptions{ProjectDir: projectDir})
+	require.NoError(t, err)
+
+	finding := &report.Finding{
+		Logs:             []string{"==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011"},
+		InputData:        []byte("GET / HTTP/1.1\r\n"),
+		ShortDescription: "heap-buffer-overflow (WRITE of size 4) in parse_header (src/http.c:120)",
+		MoreDetails: &report.ErrorDetails{
+			Id:       "heap-buffer-overflow",
+			Name:     "Heap buffer overflow: write of size 4",
+			Severity: &report.Severity{Description: "High", Score: 8.0},
+		},
+	}
+	err = h.Handle(&report.Report{Status: report.RunStatus_RUNNING, Finding: finding})
+	require.NoError(t, err)
+
+	knowledge := report.LookupKnowledge("heap-buffer-overflow")
+	checkOutput(t, logOutput,
+		"Finding "+finding.Name+": "+finding.ShortDescription,
+		"Severity: High (8.0)",
+		knowledge.Description,
+		"Typical root cause: "+knowledge.RootCause,
+		"How to fix: "+knowledge.Mitigation,
+	)
+
+	stored, err := report.LoadFinding(projectDir, finding.Name)
+	require.NoError(t, err)
+	assert.Equal(t, knowledge, stored.MoreDetails.Knowledge)
+	assert.Equal(t, `"GET / HTTP/1.1\r\n"`, stored.HumanReadableInput)
+}
+
 func checkOutput(t *testing.T, r io.Reader, s ...string) {
 	output, err := io.ReadAll(r)
 	require.NoError(t, err)
@@ -171,3 +220,84 @@ func checkOutput(t *testing.T, r io.Reader, s ...string) {
 		require.Contains(t, string(output), str)
 	}
 }
+
+func TestReportHandler_MergeDuplicates(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "merge-duplicates-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+	seedCorpusDir := filepath.Join(projectDir, "seed_corpus")
+
+	h, err := NewReportHandler(&ReportHandlerOptions{ProjectDir: projectDir, SeedCorpusDir: seedCorpusDir})
+	require.NoError(t, err)
+
+	handleFinding := func(input string) *report.Finding {
+		inputFile := filepath.Join(projectDir, "crash-"+input)
+		err := os.WriteFile(inputFile, []byte(input), 0644)
+		require.NoError(t, err)
+		finding := &report.Finding{
+			Logs:      []string{"Oops"},
+			InputData: []byte(input),
+			InputFile: inputFile,
+			Signature: "0123456789abcdef",
+		}
+		err = h.Handle(&report.Report{Status: report.RunStatus_RUNNING, Finding: finding})
+		require.NoError(t, err)
+		return finding
+	}
+
+	first := handleFinding("AAAA")
+	second := handleFinding("AAAAAAAA")
+	third := handleFinding("AA")
+	assert.Equal(t, first.Name, second.Name)
+	assert.Equal(t, first.Name, third.Name)
+	assert.EqualValues(t, 3, h.numFindings)
+	assert.EqualValues(t, 1, h.numNewFindings)
+
+	stored, err := report.LoadFinding(projectDir, first.Name)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, stored.Count)
+	// The smallest reproducer is kept
+	assert.Equal(t, []byte("AA"), stored.InputData)
+	assert.Equal(t, `"AA"`, stored.HumanReadableInput)
+	input, err := os.ReadFile(stored.InputFile)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("AA"), input)
+	seed, err := os.ReadFile(filepath.Join(seedCorpusDir, first.Name))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("AA"), seed)
+
+	// Inputs of duplicates are not stored
+	assert.NoFileExists(t, filepath.Join(projectDir, "crash-AAAAAAAA"))
+}
+
+func TestReportHandler_NameCollision(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "name-collision-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	h, err := NewReportHandler(&ReportHandlerOptions{ProjectDir: projectDir})
+	require.NoError(t, err)
+
+	handleFinding := func(signature string) *report.Finding {
+		finding := &report.Finding{Name: "lucid_turing", Signature: signature}
+		err := h.Handle(&report.Report{Status: report.RunStatus_RUNNING, Finding: finding})
+		require.NoError(t, err)
+		return finding
+	}
+
+	first := handleFinding("abc")
+	assert.Equal(t, "lucid_turing", first.Name)
+
+	// A finding with the same name and a different (short) signature
+	// gets a name derived from its signature
+	second := handleFinding("xy")
+	assert.NotEqual(t, first.Name, second.Name)
+	assert.True(t, strings.HasPrefix(second.Name, "lucid_turing_"))
+
+	// Another occurrence of the second bug is merged into it instead
+	// of getting yet another name
+	third := handleFinding("xy")
+	assert.Equal(t, second.Name, third.Name)
+	assert.EqualValues(t, 2, third.Count)
+	assert.EqualValues(t, 2, h.numNewFindings)
+}
@@ -2,11 +2,13 @@ package run
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,30 +19,49 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"code-intelligence.com/cifuzz/internal/build"
-	"code-intelligence.com/cifuzz/internal/build/cmake"
-	"code-intelligence.com/cifuzz/internal/build/other"
+	"code-intelligence.com/cifuzz/internal/build/fuzztests"
+	"code-intelligence.com/cifuzz/internal/build/golang"
 	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/aflpp"
+	"code-intelligence.com/cifuzz/pkg/runner/gofuzz"
+	"code-intelligence.com/cifuzz/pkg/runner/honggfuzz"
+	"code-intelligence.com/cifuzz/pkg/runner/jazzer"
 	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
 	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
 type runOptions struct {
 	BuildSystem    string        `mapstructure:"build-system"`
 	BuildCommand   string        `mapstructure:"build-command"`
+	BuildOutput    string        `mapstructure:"build-output"`
 	SeedCorpusDirs []string      `mapstructure:"seed-corpus-dirs"`
 	Dictionary     string        `mapstructure:"dict"`
 	EngineArgs     []string      `mapstructure:"engine-args"`
 	FuzzTestArgs   []string      `mapstructure:"fuzz-test-args"`
+	Engine         string        `mapstructure:"engine"`
+	Sanitizers     []string      `mapstructure:"sanitizers"`
 	Timeout        time.Duration `mapstructure:"timeout"`
+	Jobs           int           `mapstructure:"jobs"`
 	UseSandbox     bool          `mapstructure:"use-sandbox"`
 	PrintJSON      bool          `mapstructure:"print-json"`
+	ReportFormat   string        `mapstructure:"report-format"`
+	ReportFile     string        `mapstructure:"report-file"`
+
+	MinimizeFindings bool          `mapstructure:"minimize-findings"`
+	MinimizeTimeout  time.Duration `mapstructure:"minimize-timeout"`
 
 	ProjectDir string
-	fuzzTest   string
+	fuzzTests  []string
+	all        bool
+	rebuild    bool
+	totalTime  time.Duration
+	regression bool
 }
 
 func (opts *runOptions) validate() error {
@@ -80,9 +101,152 @@ func (opts *runOptions) validate() error {
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
+	if opts.all && len(opts.fuzzTests) > 0 {
+		msg := "Flag \"all\" can't be used together with fuzz test arguments"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if !opts.all && len(opts.fuzzTests) == 0 {
+		msg := "Specify the fuzz tests to run or use flag \"all\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.all && opts.BuildSystem != config.BuildSystemCMake && opts.BuildSystem != config.BuildSystemBazel &&
+		opts.BuildSystem != config.BuildSystemMeson {
+		msg := "Flag \"all\" is only supported for CMake, Bazel and Meson projects"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.BuildOutput != "" && (opts.all || len(opts.fuzzTests) > 1) {
+		msg := "Flag \"build-output\" can only be used with a single fuzz test"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.totalTime != 0 && opts.Timeout != 0 {
+		msg := "Flags \"timeout\" and \"total-time\" can't be used together"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	// Fuzz tests are run one after the other, so unless we're running
+	// regression tests, all but the first would never be executed
+	// without a time limit.
+	if (opts.all || len(opts.fuzzTests) > 1) && !opts.regression && opts.Timeout == 0 && opts.totalTime == 0 {
+		msg := "Flag \"timeout\" or \"total-time\" must be set when running multiple fuzz tests"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.Jobs < 1 {
+		msg := fmt.Sprintf("Invalid number of jobs %d, must be at least 1", opts.Jobs)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.Engine == "" {
+		// Go fuzz tests are run with Go's native fuzzing engine and
+		// Java fuzz tests with Jazzer by default
+		if opts.BuildSystem == config.BuildSystemGo {
+			opts.Engine = string(config.GO_NATIVE)
+		} else if config.IsJavaBuildSystem(opts.BuildSystem) {
+			opts.Engine = string(config.JAZZER)
+		} else {
+			opts.Engine = string(config.LIBFUZZER)
+		}
+	}
+	if !stringutil.Contains(supportedEngines, opts.Engine) {
+		msg := fmt.Sprintf("Invalid engine \"%s\", valid engines are: %s",
+			opts.Engine, strings.Join(supportedEngines, ", "))
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.BuildSystem == config.BuildSystemGo &&
+		opts.Engine != string(config.GO_NATIVE) && opts.Engine != string(config.LIBFUZZER) {
+		msg := fmt.Sprintf("Engine \"%s\" is not supported for Go projects, valid engines are: %s, %s",
+			opts.Engine, config.GO_NATIVE, config.LIBFUZZER)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.Engine == string(config.GO_NATIVE) {
+		if opts.BuildSystem != config.BuildSystemGo {
+			msg := "The go engine is only supported for Go projects"
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if opts.Dictionary != "" {
+			msg := "Flag \"dict\" is not supported with the go engine"
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+	if opts.BuildSystem == config.BuildSystemCargo && opts.Engine != string(config.LIBFUZZER) {
+		msg := fmt.Sprintf("Engine \"%s\" is not supported for Rust projects, the only valid engine is: %s",
+			opts.Engine, config.LIBFUZZER)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.BuildSystem == config.BuildSystemBazel && opts.Engine != string(config.LIBFUZZER) {
+		msg := fmt.Sprintf("Engine \"%s\" is not supported for Bazel projects, the only valid engine is: %s",
+			opts.Engine, config.LIBFUZZER)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if config.IsJavaBuildSystem(opts.BuildSystem) && opts.Engine != string(config.JAZZER) {
+		msg := fmt.Sprintf("Engine \"%s\" is not supported for Maven and Gradle projects, the only valid engine is: %s",
+			opts.Engine, config.JAZZER)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.Engine == string(config.JAZZER) && !config.IsJavaBuildSystem(opts.BuildSystem) {
+		msg := "The jazzer engine is only supported for Maven and Gradle projects"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.Engine == string(config.JAZZER) && opts.regression {
+		msg := "Flag \"regression\" is not supported for Maven and Gradle projects"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if (opts.Engine == string(config.AFL) || opts.Engine == string(config.JAZZER)) && opts.Jobs > 1 {
+		msg := fmt.Sprintf("Flag \"jobs\" is not supported with the %s engine", opts.Engine)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.MinimizeFindings && opts.Engine != string(config.LIBFUZZER) {
+		msg := fmt.Sprintf("Flag \"minimize-findings\" is not supported with the %s engine", opts.Engine)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if opts.MinimizeFindings && opts.regression {
+		msg := "Flag \"minimize-findings\" can't be used together with flag \"regression\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	// libFuzzer only supports timeouts in seconds
+	if opts.MinimizeFindings && opts.MinimizeTimeout < time.Second {
+		msg := fmt.Sprintf("Invalid minimize timeout %s, must be at least 1s", opts.MinimizeTimeout)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if len(opts.Sanitizers) == 0 {
+		opts.Sanitizers = config.DefaultSanitizers()
+	} else {
+		err = config.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	if opts.ReportFormat != "" {
+		if !stringutil.Contains(supportedReportFormats, opts.ReportFormat) {
+			msg := fmt.Sprintf("Invalid report format \"%s\", valid formats are: %s",
+				opts.ReportFormat, strings.Join(supportedReportFormats, ", "))
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if opts.ReportFile == "" {
+			msg := "Flag \"report-file\" must be set when using flag \"report-format\""
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
 	return nil
 }
 
+var supportedEngines = []string{
+	string(config.LIBFUZZER),
+	string(config.AFL),
+	string(config.HONGGFUZZ),
+	string(config.GO_NATIVE),
+	string(config.JAZZER),
+}
+
+const (
+	reportFormatSARIF = "sarif"
+	reportFormatJUnit = "junit"
+)
+
+var supportedReportFormats = []string{reportFormatSARIF, reportFormatJUnit}
+
 type runCmd struct {
 	*cobra.Command
 	opts *runOptions
@@ -95,26 +259,34 @@ func New() *cobra.Command {
 	opts := &runOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "run [flags] <fuzz test>",
-		Short: "Build and run a fuzz test",
+		Use:   "run [flags] <fuzz test>...",
+		Short: "Build and run fuzz tests",
 		// TODO: Write long description (easier once we support more
 		//       than just the fallback mode). In particular, explain how a
 		//       "fuzz test" is identified on the CLI.
 		Long:              "",
 		ValidArgsFunction: completion.ValidFuzzTests,
-		Args:              cobra.ExactArgs(1),
+		Args:              cobra.ArbitraryArgs,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Bind viper keys to flags. We can't do this in the New
 			// function, because that would re-bind viper keys which
 			// were bound to the flags of other commands before.
 			cmdutils.ViperMustBindPFlag("build-command", cmd.Flags().Lookup("build-command"))
+			cmdutils.ViperMustBindPFlag("build-output", cmd.Flags().Lookup("build-output"))
 			cmdutils.ViperMustBindPFlag("seed-corpus-dirs", cmd.Flags().Lookup("seed-corpus"))
 			cmdutils.ViperMustBindPFlag("dict", cmd.Flags().Lookup("dict"))
 			cmdutils.ViperMustBindPFlag("engine-args", cmd.Flags().Lookup("engine-arg"))
 			cmdutils.ViperMustBindPFlag("fuzz-test-args", cmd.Flags().Lookup("fuzz-test-arg"))
+			cmdutils.ViperMustBindPFlag("engine", cmd.Flags().Lookup("engine"))
+			cmdutils.ViperMustBindPFlag("sanitizers", cmd.Flags().Lookup("sanitizers"))
 			cmdutils.ViperMustBindPFlag("timeout", cmd.Flags().Lookup("timeout"))
+			cmdutils.ViperMustBindPFlag("jobs", cmd.Flags().Lookup("jobs"))
 			cmdutils.ViperMustBindPFlag("use-sandbox", cmd.Flags().Lookup("use-sandbox"))
 			cmdutils.ViperMustBindPFlag("print-json", cmd.Flags().Lookup("json"))
+			cmdutils.ViperMustBindPFlag("report-format", cmd.Flags().Lookup("report-format"))
+			cmdutils.ViperMustBindPFlag("report-file", cmd.Flags().Lookup("report-file"))
+			cmdutils.ViperMustBindPFlag("minimize-findings", cmd.Flags().Lookup("minimize-findings"))
+			cmdutils.ViperMustBindPFlag("minimize-timeout", cmd.Flags().Lookup("minimize-timeout"))
 
 			projectDir, err := config.ParseProjectConfig(opts)
 			if err != nil {
@@ -122,7 +294,7 @@ func New() *cobra.Command {
 			}
 			opts.ProjectDir = projectDir
 
-			opts.fuzzTest = args[0]
+			opts.fuzzTests = args
 			return opts.validate()
 		},
 		RunE: func(c *cobra.Command, args []string) error {
@@ -134,14 +306,26 @@ func New() *cobra.Command {
 	// Note: If a flag should be configurable via cifuzz.yaml as well,
 	// bind it to viper in the PreRunE function.
 	cmd.Flags().String("build-command", "", "The command to build the fuzz test. Example: \"make clean && make my-fuzz-test\"")
+	cmd.Flags().String("build-output", "", "The path of the fuzz test executable built by the build command,\nif the build system type is \"other\". Example: \"build/my_fuzz_test\"")
 	cmd.Flags().StringArrayP("seed-corpus", "s", nil, "Directory containing sample inputs for the code under test.\nSee https://llvm.org/docs/LibFuzzer.html#corpus and\nhttps://aflplus.plus/docs/fuzzing_in_depth/#a-collecting-inputs.")
 	cmd.Flags().String("dict", "", "A file containing input language keywords or other interesting byte sequences.\nSee https://llvm.org/docs/LibFuzzer.html#dictionaries and\nhttps://github.com/AFLplusplus/AFLplusplus/blob/stable/dictionaries/README.md.")
-	cmd.Flags().StringArray("engine-arg", nil, "Command-line argument to pass to the fuzzing engine.\nSee https://llvm.org/docs/LibFuzzer.html#options and\nhttps://www.mankier.com/8/afl-fuzz.")
+	cmd.Flags().StringArray("engine-arg", nil, "Command-line argument to pass to the fuzzing engine.\nSee https://llvm.org/docs/LibFuzzer.html#options,\nhttps://www.mankier.com/8/afl-fuzz and\nhttps://github.com/google/honggfuzz/blob/master/docs/USAGE.md.")
 	cmd.Flags().StringArray("fuzz-test-arg", nil, "Command-line argument to pass to the fuzz test.")
+	cmd.Flags().String("engine", "", fmt.Sprintf("The fuzzing engine to run the fuzz tests with.\nValid engines: %s. Default: %s (%s for Go projects,\n%s for Maven and Gradle projects).", strings.Join(supportedEngines, ", "), config.LIBFUZZER, config.GO_NATIVE, config.JAZZER))
+	cmd.Flags().StringSlice("sanitizers", nil, fmt.Sprintf("Comma-separated list of sanitizers to build the fuzz tests with.\nValid sanitizers: %s. Default: %s.", strings.Join(config.SupportedSanitizers, ", "), strings.Join(config.DefaultSanitizers(), ",")))
 	cmd.Flags().Duration("timeout", 0, "Maximum time in seconds to run the fuzz test. The default is to run indefinitely.")
+	cmd.Flags().Int("jobs", 1, "Number of libFuzzer processes to run in parallel. The processes share\nthe generated corpus.")
+	cmd.Flags().DurationVar(&opts.totalTime, "total-time", 0, "Maximum time to run all fuzz tests together. The time is split\nbetween the fuzz tests, giving more time to those which found new\ncoverage recently.")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Run all fuzz tests of the project.")
+	cmd.Flags().BoolVar(&opts.rebuild, "rebuild", false, "Build the fuzz tests even if their sources didn't change since the last build.")
 	cmd.Flags().Bool("use-sandbox", false, "By default, fuzz tests are executed in a sandbox to prevent accidental damage to the system.\nUse --use-sandbox=false to run the fuzz test unsandboxed.\nOnly supported on Linux.")
 	viper.SetDefault("use-sandbox", runtime.GOOS == "linux")
 	cmd.Flags().BoolVar(&opts.PrintJSON, "json", false, "Print output as JSON")
+	cmd.Flags().String("report-format", "", fmt.Sprintf("Write a report of the findings in the given format to the report file.\nValid formats: %s.", strings.Join(supportedReportFormats, ", ")))
+	cmd.Flags().String("report-file", "", "The file to which the report is written, see --report-format.")
+	cmd.Flags().Bool("minimize-findings", false, "Minimize the crashing inputs of new findings with libFuzzer's -minimize_crash=1\nmode. The original crashing input is kept in the finding directory.")
+	cmd.Flags().Duration("minimize-timeout", time.Minute, "Maximum time to minimize the crashing input of a single finding.\nWith --total-time, the time spent minimizing is part of the total time.")
+	cmd.Flags().BoolVar(&opts.regression, "regression", false, "Only run the fuzz tests on the inputs of the seed corpus, the generated corpus\nand the findings of the fuzz test instead of fuzzing. Exits with a non-zero\nexit code if any of the inputs produces a finding.")
 
 	return cmd
 }
@@ -149,115 +333,245 @@ func New() *cobra.Command {
 func (c *runCmd) run() error {
 	var err error
 
-	buildResult, err := c.buildFuzzTest()
-	if err != nil {
-		return err
-	}
-
-	// Initialize the report handler. Only do this right before we start
-	// the fuzz test, because this is storing a timestamp which is used
-	// to figure out how long the fuzzing run is running.
-	verbose := viper.GetBool("verbose")
-	c.reportHandler, err = report_handler.NewReportHandler(buildResult.SeedCorpus, c.opts.PrintJSON, verbose)
-	if err != nil {
-		return err
-	}
-
-	err = c.runFuzzTest(buildResult)
+	buildResults, err := c.buildFuzzTests()
 	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && c.opts.UseSandbox {
-			return cmdutils.WrapCouldBeSandboxError(err)
-		}
 		return err
 	}
 
-	err = c.printFinalMetrics()
-	if err != nil {
-		return err
+	if c.opts.regression {
+		return c.runRegressionTests(buildResults)
 	}
 
-	return nil
-}
+	// The metrics and findings of each fuzz test, accumulated over
+	// all the times it was run
+	allMetrics := map[string]*report_handler.FinalMetrics{}
+	findings := map[string][]*report.Finding{}
 
-func (c *runCmd) buildFuzzTest() (*build.Result, error) {
-	// TODO: Do not hardcode these values.
-	sanitizers := []string{"address"}
-	// UBSan is not supported by MSVC
-	// TODO: Not needed anymore when sanitizers are configurable,
-	//       then we do want to fail if the user explicitly asked for
-	//       UBSan.
-	if runtime.GOOS != "windows" {
-		sanitizers = append(sanitizers, "undefined")
-	}
-
-	if c.opts.BuildSystem == config.BuildSystemCMake {
-		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
-			ProjectDir: c.opts.ProjectDir,
-			// TODO: Do not hardcode this value.
-			Engine:     "libfuzzer",
-			Sanitizers: sanitizers,
-			Stdout:     c.OutOrStdout(),
-			Stderr:     c.ErrOrStderr(),
+	sched := newScheduler(c.opts.fuzzTests, c.opts.totalTime, c.opts.Timeout)
+	for {
+		fuzzTest, timeout, ok := sched.next()
+		if !ok {
+			break
+		}
+		buildResult := buildResults[fuzzTest]
+
+		// Initialize the report handler. Only do this right before we start
+		// the fuzz test, because this is storing a timestamp which is used
+		// to figure out how long the fuzzing run is running.
+		c.reportHandler, err = report_handler.NewReportHandler(&report_handler.ReportHandlerOptions{
+			ProjectDir:    c.opts.ProjectDir,
+			SeedCorpusDir: buildResult.SeedCorpus,
+			FuzzTest:      fuzzTest,
+			PrintJSON:     c.opts.PrintJSON,
+			Verbose:       viper.GetBool("verbose"),
 		})
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		start := time.Now()
+		err = c.runFuzzTest(fuzzTest, buildResult, timeout)
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && c.opts.UseSandbox {
+				return cmdutils.WrapCouldBeSandboxError(err)
+			}
+			return err
 		}
-		err = builder.Configure()
+
+		finalMetrics, err := c.finalMetrics(fuzzTest, buildResult)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+
+		err = c.reportHandler.PrintFinalMetrics(finalMetrics)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return buildResults[c.opts.fuzzTest], nil
-	} else if c.opts.BuildSystem == config.BuildSystemOther {
-		if runtime.GOOS == "windows" {
-			return nil, errors.New("CMake is the only supported build system on Windows")
+
+		if c.opts.MinimizeFindings {
+			// Minimizing is charged to the total time, so it must not
+			// take longer than what's left of it
+			var deadline time.Time
+			remaining, ok := sched.remaining()
+			if ok {
+				deadline = start.Add(remaining)
+			}
+			err = c.minimizeFindings(fuzzTest, buildResult, c.reportHandler.Findings(), deadline)
+			if err != nil {
+				return err
+			}
 		}
-		builder, err := other.NewBuilder(&other.BuilderOptions{
-			BuildCommand: c.opts.BuildCommand,
-			// TODO: Do not hardcode this value.
-			Engine:     "libfuzzer",
-			Sanitizers: sanitizers,
-			Stdout:     c.OutOrStdout(),
-			Stderr:     c.ErrOrStderr(),
-		})
+
+		// Findings which were merged into a finding of a previous run
+		// also mark the fuzz test as done, it would only run into the
+		// same bug again
+		sched.record(fuzzTest, time.Since(start), finalMetrics.NewSeeds, len(c.reportHandler.Findings()) > 0)
+		if allMetrics[fuzzTest] == nil {
+			allMetrics[fuzzTest] = finalMetrics
+		} else {
+			allMetrics[fuzzTest].Merge(finalMetrics)
+		}
+		findings[fuzzTest] = addFindings(findings[fuzzTest], c.reportHandler.Findings()...)
+	}
+
+	var metricsList []*report_handler.FinalMetrics
+	var allFindings []*report.Finding
+	var suites []*report.JUnitTestSuite
+	for _, fuzzTest := range c.opts.fuzzTests {
+		if allMetrics[fuzzTest] == nil {
+			// The fuzz test was never run because the total time was
+			// used up by the other fuzz tests
+			continue
+		}
+		metricsList = append(metricsList, allMetrics[fuzzTest])
+		// Multiple fuzz tests can run into the same bug, which is
+		// stored as a single finding
+		allFindings = addFindings(allFindings, findings[fuzzTest]...)
+		suites = append(suites, junitTestSuite(fuzzTest, allMetrics[fuzzTest], findings[fuzzTest]))
+	}
+
+	if len(c.opts.fuzzTests) > 1 {
+		err = report_handler.PrintCombinedFinalMetrics(metricsList)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		buildResult, err := builder.Build(c.opts.fuzzTest)
+	}
+
+	if c.opts.ReportFormat != "" {
+		err = c.writeReport(allFindings, suites)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return buildResult, nil
-	} else {
-		return nil, errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
 	}
+
+	return nil
+}
+
+// addFindings adds the findings to the list of findings. Findings with
+// the same name as a finding in the list are the same bug found again,
+// they replace that finding because they include the updated count.
+func addFindings(findings []*report.Finding, newFindings ...*report.Finding) []*report.Finding {
+findingsLoop:
+	for _, newFinding := range newFindings {
+		for i, f := range findings {
+			if f.Name == newFinding.Name {
+				findings[i] = newFinding
+				continue findingsLoop
+			}
+		}
+		findings = append(findings, newFinding)
+	}
+	return findings
+}
+
+// writeReport writes the report in the format specified via
+// --report-format. Depending on the format, either the findings or the
+// JUnit test suites are used.
+func (c *runCmd) writeReport(findings []*report.Finding, suites []*report.JUnitTestSuite) error {
+	file, err := os.Create(c.opts.ReportFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	switch c.opts.ReportFormat {
+	case reportFormatSARIF:
+		err = report.WriteSARIF(file, findings, c.opts.ProjectDir)
+	case reportFormatJUnit:
+		err = report.WriteJUnit(file, suites)
+	default:
+		err = errors.Errorf("Unsupported report format \"%s\"", c.opts.ReportFormat)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Successf("Wrote %s report to %s", c.opts.ReportFormat, fileutil.PrettifyPath(c.opts.ReportFile))
+	return nil
 }
 
-func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
-	log.Infof("Running %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(c.opts.fuzzTest))
-	log.Debugf("Executable: %s", buildResult.Executable)
+// junitTestSuite describes the fuzzing run of a fuzz test as a JUnit
+// test suite with a failed test case per finding. If there are no
+// findings, the suite contains a single passed test case for the fuzz
+// test.
+func junitTestSuite(fuzzTest string, finalMetrics *report_handler.FinalMetrics, findings []*report.Finding) *report.JUnitTestSuite {
+	averageExecs := "n/a"
+	if finalMetrics.AverageExecsPerSecond != nil {
+		averageExecs = fmt.Sprint(*finalMetrics.AverageExecsPerSecond)
+	}
+	suite := &report.JUnitTestSuite{
+		Name:     fuzzTest,
+		Duration: finalMetrics.Duration,
+		Properties: []*report.JUnitProperty{
+			{Name: "duration", Value: finalMetrics.Duration.Round(time.Second).String()},
+			{Name: "average_exec_per_second", Value: averageExecs},
+			{Name: "new_seeds", Value: fmt.Sprint(finalMetrics.NewSeeds)},
+			{Name: "total_seeds", Value: fmt.Sprint(finalMetrics.TotalSeeds)},
+		},
+	}
 
-	generatedCorpusDir := cmdutils.GeneratedCorpusDir(c.opts.ProjectDir, c.opts.fuzzTest)
+	for _, finding := range findings {
+		suite.TestCases = append(suite.TestCases, report.NewJUnitTestCaseFromFinding(finding))
+	}
+	if len(suite.TestCases) == 0 {
+		suite.TestCases = []*report.JUnitTestCase{{Name: fuzzTest, Duration: finalMetrics.Duration}}
+	}
+
+	return suite
+}
+
+// buildFuzzTests builds the fuzz tests and returns the build results by
+// fuzz test. If --all was used, the fuzz tests are set to all fuzz
+// tests of the project.
+func (c *runCmd) buildFuzzTests() (map[string]*build.Result, error) {
+	// Regression tests replay the inputs via libFuzzer, independent of
+	// the engine which is used for fuzzing
+	engine := c.opts.Engine
+	if c.opts.regression {
+		engine = string(config.LIBFUZZER)
+	}
+
+	fuzzTests, buildResults, err := fuzztests.Build(&fuzztests.BuildOptions{
+		ProjectDir:   c.opts.ProjectDir,
+		BuildSystem:  c.opts.BuildSystem,
+		BuildCommand: c.opts.BuildCommand,
+		BuildOutput:  c.opts.BuildOutput,
+		Engine:       engine,
+		Sanitizers:   c.opts.Sanitizers,
+		Stdout:       c.OutOrStdout(),
+		Stderr:       c.ErrOrStderr(),
+		FuzzTests:    c.opts.fuzzTests,
+		All:          c.opts.all,
+		Rebuild:      c.opts.rebuild,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.opts.fuzzTests = fuzzTests
+	return buildResults, nil
+}
+
+func (c *runCmd) runFuzzTest(fuzzTest string, buildResult *build.Result, timeout time.Duration) error {
+	log.Infof("Running %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(fuzzTest))
+	if timeout != 0 {
+		log.Debugf("Timeout: %s", timeout)
+	}
+	if buildResult.Executable != "" {
+		log.Debugf("Executable: %s", buildResult.Executable)
+	}
+
+	generatedCorpusDir := cmdutils.GeneratedCorpusDir(c.opts.ProjectDir, fuzzTest)
 	err := os.MkdirAll(generatedCorpusDir, 0755)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	log.Infof("Storing generated corpus in %s", fileutil.PrettifyPath(generatedCorpusDir))
 
-	// Use user-specified seed corpus dirs (if any) and the default seed
-	// corpus (if it exists)
-	seedCorpusDirs := c.opts.SeedCorpusDirs
-	exists, err := fileutil.Exists(buildResult.SeedCorpus)
+	seedCorpusDirs, err := c.seedCorpusDirs(buildResult)
 	if err != nil {
 		return err
 	}
-	if exists {
-		seedCorpusDirs = append(seedCorpusDirs, buildResult.SeedCorpus)
-	}
 
 	// Ensure that symlinks are resolved to be able to add minijail
 	// bindings for the corpus dirs.
@@ -272,20 +586,89 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 		}
 	}
 
-	runnerOpts := &libfuzzer.RunnerOptions{
-		FuzzTarget:         buildResult.Executable,
-		GeneratedCorpusDir: generatedCorpusDir,
-		SeedCorpusDirs:     seedCorpusDirs,
-		Dictionary:         c.opts.Dictionary,
-		EngineArgs:         c.opts.EngineArgs,
-		FuzzTestArgs:       c.opts.FuzzTestArgs,
-		ReportHandler:      c.reportHandler,
-		Timeout:            c.opts.Timeout,
-		UseMinijail:        c.opts.UseSandbox,
-		Verbose:            viper.GetBool("verbose"),
-		KeepColor:          !c.opts.PrintJSON,
+	var runner fuzzerRunner
+	switch c.opts.Engine {
+	case string(config.GO_NATIVE):
+		pkgDir, name, err := golang.ParseFuzzTest(fuzzTest)
+		if err != nil {
+			return err
+		}
+		runner = gofuzz.NewRunner(&gofuzz.RunnerOptions{
+			FuzzTarget:         buildResult.Executable,
+			FuzzTestName:       name,
+			PackageDir:         filepath.Join(c.opts.ProjectDir, pkgDir),
+			GeneratedCorpusDir: generatedCorpusDir,
+			SeedCorpusDirs:     seedCorpusDirs,
+			EngineArgs:         c.opts.EngineArgs,
+			FuzzTestArgs:       c.opts.FuzzTestArgs,
+			ReportHandler:      c.reportHandler,
+			Timeout:            timeout,
+			Jobs:               c.opts.Jobs,
+			UseMinijail:        c.opts.UseSandbox,
+			Verbose:            viper.GetBool("verbose"),
+			KeepColor:          !c.opts.PrintJSON,
+		})
+	case string(config.JAZZER):
+		runner = jazzer.NewRunner(&jazzer.RunnerOptions{
+			TargetClass: fuzzTest,
+			ClassPaths:  buildResult.RuntimeDeps,
+			LibfuzzerOptions: &libfuzzer.RunnerOptions{
+				GeneratedCorpusDir: generatedCorpusDir,
+				SeedCorpusDirs:     seedCorpusDirs,
+				Dictionary:         c.opts.Dictionary,
+				EngineArgs:         c.opts.EngineArgs,
+				FuzzTestArgs:       c.opts.FuzzTestArgs,
+				ReportHandler:      c.reportHandler,
+				Timeout:            timeout,
+				UseMinijail:        c.opts.UseSandbox,
+				Verbose:            viper.GetBool("verbose"),
+				KeepColor:          !c.opts.PrintJSON,
+			},
+		})
+	case string(config.AFL):
+		runner = aflpp.NewRunner(&aflpp.RunnerOptions{
+			FuzzTarget:         buildResult.Executable,
+			GeneratedCorpusDir: generatedCorpusDir,
+			SeedCorpusDirs:     seedCorpusDirs,
+			Dictionary:         c.opts.Dictionary,
+			EngineArgs:         c.opts.EngineArgs,
+			FuzzTestArgs:       c.opts.FuzzTestArgs,
+			ReportHandler:      c.reportHandler,
+			Timeout:            timeout,
+			UseMinijail:        c.opts.UseSandbox,
+			Verbose:            viper.GetBool("verbose"),
+		})
+	case string(config.HONGGFUZZ):
+		runner = honggfuzz.NewRunner(&honggfuzz.RunnerOptions{
+			FuzzTarget:         buildResult.Executable,
+			GeneratedCorpusDir: generatedCorpusDir,
+			SeedCorpusDirs:     seedCorpusDirs,
+			Dictionary:         c.opts.Dictionary,
+			EngineArgs:         c.opts.EngineArgs,
+			FuzzTestArgs:       c.opts.FuzzTestArgs,
+			ReportHandler:      c.reportHandler,
+			Timeout:            timeout,
+			Jobs:               c.opts.Jobs,
+			UseMinijail:        c.opts.UseSandbox,
+			Verbose:            viper.GetBool("verbose"),
+			KeepColor:          !c.opts.PrintJSON,
+		})
+	default:
+		runner = libfuzzer.NewRunner(&libfuzzer.RunnerOptions{
+			FuzzTarget:         buildResult.Executable,
+			GeneratedCorpusDir: generatedCorpusDir,
+			SeedCorpusDirs:     seedCorpusDirs,
+			Dictionary:         c.opts.Dictionary,
+			EngineArgs:         c.opts.EngineArgs,
+			FuzzTestArgs:       c.opts.FuzzTestArgs,
+			ReportHandler:      c.reportHandler,
+			Timeout:            timeout,
+			Jobs:               c.opts.Jobs,
+			UseMinijail:        c.opts.UseSandbox,
+			Verbose:            viper.GetBool("verbose"),
+			KeepColor:          !c.opts.PrintJSON,
+		})
 	}
-	runner := libfuzzer.NewRunner(runnerOpts)
 
 	// Handle cleanup (terminating the fuzzer process) when receiving
 	// termination signals
@@ -325,7 +708,7 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 
 	var execErr *cmdutils.ExecError
 	if errors.As(err, &execErr) {
-		// It's expected that libFuzzer might fail due to user
+		// It's expected that the fuzzer might fail due to user
 		// configuration, so we print the error without the stack trace.
 		log.Error(err)
 		return cmdutils.ErrSilent
@@ -334,19 +717,41 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 	return err
 }
 
-func (c *runCmd) printFinalMetrics() error {
-	numSeeds, err := countSeeds(append(c.opts.SeedCorpusDirs, c.generatedCorpusPath()))
+// fuzzerRunner is implemented by the runners of all supported engines
+type fuzzerRunner interface {
+	Run(ctx context.Context) error
+	Cleanup()
+}
+
+// seedCorpusDirs returns the user-specified seed corpus dirs (if any)
+// and the default seed corpus of the fuzz test (if it exists)
+func (c *runCmd) seedCorpusDirs(buildResult *build.Result) ([]string, error) {
+	seedCorpusDirs := append([]string{}, c.opts.SeedCorpusDirs...)
+	exists, err := fileutil.Exists(buildResult.SeedCorpus)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return c.reportHandler.PrintFinalMetrics(numSeeds)
+	if exists {
+		seedCorpusDirs = append(seedCorpusDirs, buildResult.SeedCorpus)
+	}
+	return seedCorpusDirs, nil
 }
 
-func (c *runCmd) generatedCorpusPath() string {
-	// Store the generated corpus in a single persistent directory per
-	// fuzz test in a hidden subdirectory.
-	return filepath.Join(c.opts.ProjectDir, ".cifuzz-corpus", c.opts.fuzzTest)
+func (c *runCmd) finalMetrics(fuzzTest string, buildResult *build.Result) (*report_handler.FinalMetrics, error) {
+	// Count the seeds of the same corpus dirs which were passed to the
+	// fuzzer, so that the number of new seeds is the difference to
+	// the number of seeds the fuzzer reported during initialization
+	seedCorpusDirs, err := c.seedCorpusDirs(buildResult)
+	if err != nil {
+		return nil, err
+	}
+	seedCorpusDirs = append(seedCorpusDirs, cmdutils.GeneratedCorpusDir(c.opts.ProjectDir, fuzzTest))
+	numSeeds, err := countSeeds(seedCorpusDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.reportHandler.FinalMetrics(numSeeds), nil
 }
 
 func countSeeds(seedCorpusDirs []string) (numSeeds uint, err error) {
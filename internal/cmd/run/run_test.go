@@ -3,13 +3,207 @@ package run
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
+	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
 func TestRunCmd(t *testing.T) {
 	_, err := cmdutils.ExecuteCommand(t, New(), os.Stdin)
 	assert.Error(t, err)
 }
+
+func TestRunOptions_ValidateReportFormat(t *testing.T) {
+	opts := &runOptions{
+		BuildSystem:  "other",
+		BuildCommand: "make",
+		Jobs:         1,
+		ReportFormat: "sarif",
+		fuzzTests:    []string{"my_fuzz_test"},
+	}
+	// The report file must be set when a report format is used
+	assert.Error(t, opts.validate())
+
+	opts.ReportFile = "findings.sarif"
+	assert.NoError(t, opts.validate())
+
+	opts.ReportFormat = "unknown"
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateMultipleFuzzTests(t *testing.T) {
+	opts := &runOptions{BuildSystem: "cmake", Jobs: 1}
+	// Either fuzz tests or --all must be specified
+	assert.Error(t, opts.validate())
+
+	opts.all = true
+	opts.fuzzTests = []string{"my_fuzz_test"}
+	assert.Error(t, opts.validate())
+
+	// Multiple fuzz tests require a time limit
+	opts.fuzzTests = nil
+	assert.Error(t, opts.validate())
+
+	opts.totalTime = time.Hour
+	assert.NoError(t, opts.validate())
+
+	opts.Timeout = time.Minute
+	assert.Error(t, opts.validate())
+
+	// --all is only supported for CMake
+	opts = &runOptions{BuildSystem: "other", BuildCommand: "make", Jobs: 1, all: true, totalTime: time.Hour}
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateBuildOutput(t *testing.T) {
+	opts := &runOptions{BuildSystem: "other", BuildCommand: "make", BuildOutput: "build/my_fuzz_test", Jobs: 1, fuzzTests: []string{"my_fuzz_test"}}
+	assert.NoError(t, opts.validate())
+
+	// The build output is the executable of a single fuzz test
+	opts.fuzzTests = []string{"my_fuzz_test", "other_fuzz_test"}
+	opts.totalTime = time.Hour
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateJobs(t *testing.T) {
+	opts := &runOptions{BuildSystem: "other", BuildCommand: "make", fuzzTests: []string{"my_fuzz_test"}}
+	assert.Error(t, opts.validate())
+
+	opts.Jobs = 8
+	assert.NoError(t, opts.validate())
+}
+
+func TestRunOptions_ValidateMinimizeFindings(t *testing.T) {
+	opts := &runOptions{BuildSystem: "other", BuildCommand: "make", Jobs: 1, MinimizeFindings: true, MinimizeTimeout: time.Minute, fuzzTests: []string{"my_fuzz_test"}}
+	require.NoError(t, opts.validate())
+
+	// Minimizing crashing inputs is only supported with libFuzzer
+	opts.Engine = "honggfuzz"
+	assert.Error(t, opts.validate())
+
+	opts = &runOptions{BuildSystem: "other", BuildCommand: "make", Jobs: 1, MinimizeFindings: true, MinimizeTimeout: time.Minute, regression: true, fuzzTests: []string{"my_fuzz_test"}}
+	assert.Error(t, opts.validate())
+
+	// libFuzzer only supports timeouts in seconds
+	opts = &runOptions{BuildSystem: "other", BuildCommand: "make", Jobs: 1, MinimizeFindings: true, MinimizeTimeout: 500 * time.Millisecond, fuzzTests: []string{"my_fuzz_test"}}
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateJava(t *testing.T) {
+	opts := &runOptions{BuildSystem: "maven", Jobs: 1, fuzzTests: []string{"com.example.MyFuzzTest"}}
+	require.NoError(t, opts.validate())
+	// Java fuzz tests are run with Jazzer by default
+	assert.Equal(t, "jazzer", opts.Engine)
+
+	opts = &runOptions{BuildSystem: "gradle", Engine: "libfuzzer", Jobs: 1, fuzzTests: []string{"com.example.MyFuzzTest"}}
+	assert.Error(t, opts.validate())
+
+	opts = &runOptions{BuildSystem: "other", BuildCommand: "make", Engine: "jazzer", Jobs: 1, fuzzTests: []string{"my_fuzz_test"}}
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateCargo(t *testing.T) {
+	opts := &runOptions{BuildSystem: "cargo", Jobs: 1, fuzzTests: []string{"parse"}}
+	require.NoError(t, opts.validate())
+	assert.Equal(t, "libfuzzer", opts.Engine)
+
+	opts = &runOptions{BuildSystem: "cargo", Engine: "afl", Jobs: 1, fuzzTests: []string{"parse"}}
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateBazel(t *testing.T) {
+	opts := &runOptions{BuildSystem: "bazel", Jobs: 1, all: true, totalTime: time.Hour}
+	require.NoError(t, opts.validate())
+
+	opts = &runOptions{BuildSystem: "bazel", Engine: "honggfuzz", Jobs: 1, fuzzTests: []string{"//src:parser_fuzz_test"}}
+	assert.Error(t, opts.validate())
+}
+
+func TestRunOptions_ValidateSanitizers(t *testing.T) {
+	opts := &runOptions{BuildSystem: "other", BuildCommand: "make", Jobs: 1, fuzzTests: []string{"my_fuzz_test"}}
+	require.NoError(t, opts.validate())
+	// The fuzz tests are built with the default sanitizers if none are
+	// configured
+	assert.Equal(t, config.DefaultSanitizers(), opts.Sanitizers)
+
+	opts.Sanitizers = []string{"address", "memory"}
+	assert.Error(t, opts.validate())
+
+	opts.Sanitizers = []string{"coverage"}
+	assert.Error(t, opts.validate())
+}
+
+func TestRun_SameSignatureInTwoRounds(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "same-signature-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	// Runs the fuzz test and reports a finding with the signature if
+	// it's not empty, like a run of the fuzz test would
+	runFuzzTest := func(fuzzTest string, signature string) []*report.Finding {
+		h, err := report_handler.NewReportHandler(&report_handler.ReportHandlerOptions{
+			ProjectDir: projectDir,
+			FuzzTest:   fuzzTest,
+		})
+		require.NoError(t, err)
+		if signature != "" {
+			err = h.Handle(&report.Report{
+				Status:  report.RunStatus_RUNNING,
+				Finding: &report.Finding{Signature: signature},
+			})
+			require.NoError(t, err)
+		}
+		return h.Findings()
+	}
+
+	// The bug was found by a previous run
+	runFuzzTest("a", "0123456789abcdef")
+
+	signatures := map[int]map[string]string{
+		// a runs into the known bug in the first round
+		1: {"a": "0123456789abcdef"},
+		// b runs into the same bug in the second round
+		2: {"b": "0123456789abcdef"},
+	}
+	findings := map[string][]*report.Finding{}
+	var run []string
+	s := newScheduler([]string{"a", "b"}, 3*time.Minute, 0)
+	for {
+		fuzzTest, timeout, ok := s.next()
+		if !ok {
+			break
+		}
+		run = append(run, fuzzTest)
+		newFindings := runFuzzTest(fuzzTest, signatures[s.round][fuzzTest])
+		s.record(fuzzTest, timeout, 0, len(newFindings) > 0)
+		findings[fuzzTest] = addFindings(findings[fuzzTest], newFindings...)
+	}
+	// a is not run again after running into the known bug and b is
+	// not run again after it ran into it too
+	assert.Equal(t, []string{"a", "b", "b"}, run)
+
+	var allFindings []*report.Finding
+	for _, fuzzTest := range []string{"a", "b"} {
+		require.Len(t, findings[fuzzTest], 1)
+		allFindings = addFindings(allFindings, findings[fuzzTest]...)
+	}
+	require.Len(t, allFindings, 1)
+	assert.EqualValues(t, 3, allFindings[0].Count)
+}
+
+func TestAddFindings(t *testing.T) {
+	first := &report.Finding{Name: "a", Count: 1}
+	other := &report.Finding{Name: "b", Count: 1}
+	again := &report.Finding{Name: "a", Count: 2}
+
+	findings := addFindings(nil, first, other)
+	findings = addFindings(findings, again)
+	assert.Equal(t, []*report.Finding{again, other}, findings)
+}
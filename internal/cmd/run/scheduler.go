@@ -0,0 +1,170 @@
+package run
+
+import (
+	"time"
+)
+
+const (
+	// The maximum number of rounds in which the total time is split
+	maxRounds = 3
+	// The minimum time a fuzz test is run in a round, shorter runs
+	// are dominated by the startup time of the fuzz test
+	minSliceDuration = 30 * time.Second
+	// The maximum weight of a fuzz test, which avoids that the sum of
+	// the weights overflows
+	maxWeight = 1 << 20
+)
+
+// scheduler decides which fuzz test is run next and for how long.
+//
+// Without a total time, each fuzz test is run once with the timeout.
+// With a total time, the time is split into rounds. In the first round,
+// each fuzz test gets the same share of the round's time. In the
+// following rounds, the time is split proportionally to the new seeds
+// each fuzz test found in the previous round, so that fuzz tests which
+// still make progress get more time. Fuzz tests which produced a
+// finding are not run again, because they would run into the same
+// finding right away.
+type scheduler struct {
+	fuzzTests []string
+	totalTime time.Duration
+	timeout   time.Duration
+
+	numRounds int
+	round     int
+	elapsed   time.Duration
+	// The fuzz tests and timeouts which are still to be run in the
+	// current round
+	queue []*slice
+	// The number of new seeds each fuzz test found when it was last run
+	newSeeds map[string]uint
+	// Fuzz tests which produced a finding
+	done map[string]bool
+}
+
+type slice struct {
+	fuzzTest string
+	timeout  time.Duration
+}
+
+func newScheduler(fuzzTests []string, totalTime time.Duration, timeout time.Duration) *scheduler {
+	s := &scheduler{
+		fuzzTests: fuzzTests,
+		totalTime: totalTime,
+		timeout:   timeout,
+		numRounds: 1,
+		newSeeds:  map[string]uint{},
+		done:      map[string]bool{},
+	}
+	// A single fuzz test is run for the whole time in one go, restarting
+	// it would only waste time
+	if totalTime != 0 && len(fuzzTests) > 1 {
+		s.numRounds = int(totalTime / (time.Duration(len(fuzzTests)) * minSliceDuration))
+		if s.numRounds > maxRounds {
+			s.numRounds = maxRounds
+		}
+		if s.numRounds < 1 {
+			s.numRounds = 1
+		}
+	}
+	return s
+}
+
+// next returns the fuzz test which should be run next and the timeout
+// with which it should be run. It returns false if all fuzz tests are
+// done or the total time is used up.
+func (s *scheduler) next() (string, time.Duration, bool) {
+	if len(s.queue) == 0 && !s.startRound() {
+		return "", 0, false
+	}
+	next := s.queue[0]
+	s.queue = s.queue[1:]
+	return next.fuzzTest, next.timeout, true
+}
+
+// record must be called after a fuzz test was run with the duration of
+// the run, the number of new seeds and whether it produced a finding.
+func (s *scheduler) record(fuzzTest string, duration time.Duration, newSeeds uint, foundFinding bool) {
+	s.elapsed += duration
+	s.newSeeds[fuzzTest] = newSeeds
+	if foundFinding {
+		s.done[fuzzTest] = true
+	}
+}
+
+// remaining returns the part of the total time which is not used up
+// yet. It returns false if there is no total time.
+func (s *scheduler) remaining() (time.Duration, bool) {
+	if s.totalTime == 0 {
+		return 0, false
+	}
+	if s.elapsed > s.totalTime {
+		return 0, true
+	}
+	return s.totalTime - s.elapsed, true
+}
+
+func (s *scheduler) startRound() bool {
+	if s.round == s.numRounds {
+		return false
+	}
+	s.round++
+
+	var fuzzTests []string
+	for _, fuzzTest := range s.fuzzTests {
+		if !s.done[fuzzTest] {
+			fuzzTests = append(fuzzTests, fuzzTest)
+		}
+	}
+	if len(fuzzTests) == 0 {
+		return false
+	}
+
+	if s.totalTime == 0 {
+		for _, fuzzTest := range fuzzTests {
+			s.queue = append(s.queue, &slice{fuzzTest: fuzzTest, timeout: s.timeout})
+		}
+		return true
+	}
+
+	// Split the remaining time evenly between the remaining rounds, so
+	// that time which wasn't used in previous rounds (for example
+	// because a fuzz test produced a finding) isn't lost.
+	roundsLeft := s.numRounds - s.round + 1
+	budget := (s.totalTime - s.elapsed) / time.Duration(roundsLeft)
+	if budget < time.Second {
+		return false
+	}
+
+	weights := map[string]uint{}
+	var totalWeight uint
+	for _, fuzzTest := range fuzzTests {
+		// Every fuzz test gets at least a small share of the time, so
+		// that fuzz tests which didn't find new seeds in a round get
+		// another chance.
+		weight := uint(1)
+		if s.round > 1 {
+			weight += s.newSeeds[fuzzTest]
+		}
+		if weight > maxWeight || weight == 0 {
+			// The weight is too large or wrapped around
+			weight = maxWeight
+		}
+		weights[fuzzTest] = weight
+		totalWeight += weight
+	}
+	for _, fuzzTest := range fuzzTests {
+		// Multiplying the budget with the weight could overflow, so we
+		// calculate the share of the fuzz test first
+		share := float64(weights[fuzzTest]) / float64(totalWeight)
+		timeout := time.Duration(float64(budget) * share)
+		// libFuzzer only supports timeouts in seconds and treats a
+		// timeout of zero as no timeout
+		timeout = timeout.Truncate(time.Second)
+		if timeout < time.Second {
+			timeout = time.Second
+		}
+		s.queue = append(s.queue, &slice{fuzzTest: fuzzTest, timeout: timeout})
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package run
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Timeout(t *testing.T) {
+	s := newScheduler([]string{"a", "b"}, 0, time.Minute)
+
+	fuzzTest, timeout, ok := s.next()
+	assert.True(t, ok)
+	assert.Equal(t, "a", fuzzTest)
+	assert.Equal(t, time.Minute, timeout)
+	s.record(fuzzTest, timeout, 10, false)
+
+	fuzzTest, timeout, ok = s.next()
+	assert.True(t, ok)
+	assert.Equal(t, "b", fuzzTest)
+	assert.Equal(t, time.Minute, timeout)
+	s.record(fuzzTest, timeout, 10, false)
+
+	_, _, ok = s.next()
+	assert.False(t, ok)
+}
+
+func TestScheduler_TotalTime(t *testing.T) {
+	s := newScheduler([]string{"a", "b", "c"}, 9*time.Minute, 0)
+	assert.Equal(t, 3, s.numRounds)
+
+	// In the first round, the time is split evenly
+	for _, expected := range []string{"a", "b", "c"} {
+		fuzzTest, timeout, ok := s.next()
+		assert.True(t, ok)
+		assert.Equal(t, expected, fuzzTest)
+		assert.Equal(t, time.Minute, timeout)
+		switch fuzzTest {
+		case "a":
+			s.record(fuzzTest, timeout, 2, false)
+		case "b":
+			s.record(fuzzTest, timeout, 0, false)
+		case "c":
+			s.record(fuzzTest, timeout, 0, true)
+		}
+	}
+
+	// In the second round, the fuzz test with a finding isn't run again
+	// and the time is weighted by the new seeds of the first round
+	fuzzTest, timeout, ok := s.next()
+	assert.True(t, ok)
+	assert.Equal(t, "a", fuzzTest)
+	assert.Equal(t, 2*time.Minute+15*time.Second, timeout)
+	s.record(fuzzTest, timeout, 0, false)
+
+	fuzzTest, timeout, ok = s.next()
+	assert.True(t, ok)
+	assert.Equal(t, "b", fuzzTest)
+	assert.Equal(t, 45*time.Second, timeout)
+	s.record(fuzzTest, timeout, 0, false)
+
+	// The third round gets the remaining time
+	for _, expected := range []string{"a", "b"} {
+		fuzzTest, timeout, ok = s.next()
+		assert.True(t, ok)
+		assert.Equal(t, expected, fuzzTest)
+		assert.Equal(t, 90*time.Second, timeout)
+		s.record(fuzzTest, timeout, 0, false)
+	}
+
+	_, _, ok = s.next()
+	assert.False(t, ok)
+}
+
+func TestScheduler_SingleFuzzTest(t *testing.T) {
+	s := newScheduler([]string{"a"}, time.Hour, 0)
+
+	fuzzTest, timeout, ok := s.next()
+	assert.True(t, ok)
+	assert.Equal(t, "a", fuzzTest)
+	assert.Equal(t, time.Hour, timeout)
+	s.record(fuzzTest, timeout, 100, false)
+
+	_, _, ok = s.next()
+	assert.False(t, ok)
+}
+
+func TestScheduler_HugeNewSeeds(t *testing.T) {
+	s := newScheduler([]string{"a", "b"}, 4*time.Minute, 0)
+	assert.Equal(t, 3, s.numRounds)
+
+	fuzzTest, timeout, ok := s.next()
+	require.True(t, ok)
+	// A number of new seeds which wrapped around must neither overflow
+	// the weights nor the timeouts
+	s.record(fuzzTest, timeout, ^uint(0)-5, false)
+	fuzzTest, timeout, ok = s.next()
+	require.True(t, ok)
+	s.record(fuzzTest, timeout, ^uint(0), false)
+
+	// Both weights are capped, so the time is split evenly
+	for _, expected := range []string{"a", "b"} {
+		fuzzTest, timeout, ok = s.next()
+		require.True(t, ok)
+		assert.Equal(t, expected, fuzzTest)
+		assert.Equal(t, 40*time.Second, timeout)
+		s.record(fuzzTest, timeout, 0, false)
+	}
+}
+
+func TestScheduler_Remaining(t *testing.T) {
+	s := newScheduler([]string{"a"}, 0, time.Minute)
+	_, ok := s.remaining()
+	assert.False(t, ok)
+
+	s = newScheduler([]string{"a", "b"}, 4*time.Minute, 0)
+	remaining, ok := s.remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 4*time.Minute, remaining)
+
+	// Time spent after the fuzzing run, e.g. minimizing findings, is
+	// recorded as part of the run and reduces the time of later runs
+	fuzzTest, timeout, ok := s.next()
+	assert.True(t, ok)
+	assert.Equal(t, "a", fuzzTest)
+	s.record(fuzzTest, timeout+30*time.Second, 0, false)
+	remaining, ok = s.remaining()
+	assert.True(t, ok)
+	assert.Equal(t, 4*time.Minute-timeout-30*time.Second, remaining)
+
+	// The remaining time never becomes negative
+	s.record("b", time.Hour, 0, false)
+	remaining, ok = s.remaining()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), remaining)
+}
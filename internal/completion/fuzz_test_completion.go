@@ -1,15 +1,22 @@
 package completion
 
 import (
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/mattn/go-zglob"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"code-intelligence.com/cifuzz/internal/build/bazel"
+	"code-intelligence.com/cifuzz/internal/build/meson"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/pkg/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
 // ValidFuzzTests can be used as a cobra ValidArgsFunction that completes fuzz test names.
@@ -35,6 +42,12 @@ func ValidFuzzTests(cmd *cobra.Command, args []string, toComplete string) ([]str
 
 	if conf.BuildSystem == config.BuildSystemCMake {
 		return validCMakeFuzzTests(cmd, args, toComplete)
+	} else if conf.BuildSystem == config.BuildSystemBazel {
+		return validBazelFuzzTests(projectDir)
+	} else if conf.BuildSystem == config.BuildSystemMeson {
+		return validMesonFuzzTests(cmd, args, toComplete)
+	} else if conf.BuildSystem == config.BuildSystemCargo {
+		return validCargoFuzzTests(cmd, args, toComplete)
 	} else if conf.BuildSystem == config.BuildSystemOther {
 		// For other build systems, the <fuzz test> argument must be
 		// the path to the fuzz test executable, so we use file
@@ -42,6 +55,10 @@ func ValidFuzzTests(cmd *cobra.Command, args []string, toComplete string) ([]str
 		// been built before, but that's still better than no completion
 		// support)
 		return nil, cobra.ShellCompDirectiveDefault
+	} else if config.IsJavaBuildSystem(conf.BuildSystem) || conf.BuildSystem == config.BuildSystemGo {
+		// Java and Go fuzz tests are specified by names which we can't
+		// complete without building the project
+		return nil, cobra.ShellCompDirectiveNoFileComp
 	} else {
 		err := errors.Errorf("Unsupported build system \"%s\"", conf.BuildSystem)
 		log.Error(err, err.Error())
@@ -61,3 +78,96 @@ func validCMakeFuzzTests(cmd *cobra.Command, args []string, toComplete string) (
 	}
 	return res, cobra.ShellCompDirectiveNoFileComp
 }
+
+// ValidFindings can be used as a cobra ValidArgsFunction that completes finding names.
+func ValidFindings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Change the directory if the `--directory` flag was set
+	err := cmdutils.Chdir()
+	if err != nil {
+		log.Error(err, err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	projectDir, err := config.FindProjectDir()
+	if err != nil {
+		log.Error(err, err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	entries, err := os.ReadDir(report.FindingsDir(projectDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		log.Error(err, err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var res []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			res = append(res, entry.Name())
+		}
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+func validBazelFuzzTests(projectDir string) ([]string, cobra.ShellCompDirective) {
+	builder, err := bazel.NewBuilder(&bazel.BuilderOptions{
+		ProjectDir: projectDir,
+		Engine:     "libfuzzer",
+		Stdout:     io.Discard,
+		Stderr:     io.Discard,
+	})
+	if err != nil {
+		log.Error(err, err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	fuzzTests, err := builder.ListFuzzTests()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return fuzzTests, cobra.ShellCompDirectiveNoFileComp
+}
+
+func validMesonFuzzTests(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Meson stores the introspection data in each configured build
+	// directory, so we don't have to run meson here
+	matches, err := filepath.Glob(filepath.Join(".cifuzz-build", "meson", "*", "*", "meson-info", "intro-targets.json"))
+	if err != nil {
+		log.Error(err, err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var res []string
+	for _, match := range matches {
+		introspection, err := os.ReadFile(match)
+		if err != nil {
+			log.Error(err, err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		fuzzTests, err := meson.ParseFuzzTests(introspection)
+		if err != nil {
+			log.Error(err, err.Error())
+			return nil, cobra.ShellCompDirectiveError
+		}
+		for _, fuzzTest := range fuzzTests {
+			if !stringutil.Contains(res, fuzzTest) {
+				res = append(res, fuzzTest)
+			}
+		}
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+func validCargoFuzzTests(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// cargo-fuzz stores each fuzz target in a file named after it
+	matches, err := filepath.Glob(filepath.Join("fuzz", "fuzz_targets", "*.rs"))
+	if err != nil {
+		log.Error(err, err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var res []string
+	for _, match := range matches {
+		res = append(res, strings.TrimSuffix(filepath.Base(match), ".rs"))
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
@@ -17,15 +17,22 @@ import (
 )
 
 const (
-	BuildSystemCMake string = "cmake"
-	BuildSystemOther string = "other"
+	BuildSystemCMake  string = "cmake"
+	BuildSystemBazel  string = "bazel"
+	BuildSystemMeson  string = "meson"
+	BuildSystemGo     string = "go"
+	BuildSystemCargo  string = "cargo"
+	BuildSystemMaven  string = "maven"
+	BuildSystemGradle string = "gradle"
+	BuildSystemOther  string = "other"
 )
 
-var buildSystemTypes = []string{BuildSystemCMake, BuildSystemOther}
+var buildSystemTypes = []string{BuildSystemCMake, BuildSystemBazel, BuildSystemMeson, BuildSystemGo, BuildSystemCargo, BuildSystemMaven, BuildSystemGradle, BuildSystemOther}
 
 type ProjectConfig struct {
 	LastUpdated string
 	BuildSystem string `yaml:"build_system"`
+	Sanitizers  []string
 }
 
 const projectConfigFile = "cifuzz.yaml"
@@ -104,6 +111,7 @@ func ReadProjectConfig(projectDir string) (*ProjectConfig, error) {
 
 	config := &ProjectConfig{
 		BuildSystem: viper.GetString("build-system"),
+		Sanitizers:  viper.GetStringSlice("sanitizers"),
 	}
 
 	if config.BuildSystem == "" {
@@ -118,6 +126,15 @@ func ReadProjectConfig(projectDir string) (*ProjectConfig, error) {
 		}
 	}
 
+	if len(config.Sanitizers) == 0 {
+		config.Sanitizers = DefaultSanitizers()
+	} else {
+		err = ValidateSanitizers(config.Sanitizers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return config, nil
 }
 
@@ -135,9 +152,69 @@ func DetermineBuildSystem(projectDir string) (string, error) {
 	}
 	if isCMakeProject {
 		return BuildSystemCMake, nil
-	} else {
-		return BuildSystemOther, nil
 	}
+
+	for _, workspaceFile := range []string{"WORKSPACE", "WORKSPACE.bazel", "MODULE.bazel"} {
+		isBazelProject, err := fileutil.Exists(filepath.Join(projectDir, workspaceFile))
+		if err != nil {
+			return "", err
+		}
+		if isBazelProject {
+			return BuildSystemBazel, nil
+		}
+	}
+
+	isMesonProject, err := fileutil.Exists(filepath.Join(projectDir, "meson.build"))
+	if err != nil {
+		return "", err
+	}
+	if isMesonProject {
+		return BuildSystemMeson, nil
+	}
+
+	isGoProject, err := fileutil.Exists(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	if isGoProject {
+		return BuildSystemGo, nil
+	}
+
+	// Rust projects are fuzzed with the fuzz targets of cargo-fuzz,
+	// which are stored in a separate crate in the fuzz directory
+	isCargoProject, err := fileutil.Exists(filepath.Join(projectDir, "fuzz", "Cargo.toml"))
+	if err != nil {
+		return "", err
+	}
+	if isCargoProject {
+		return BuildSystemCargo, nil
+	}
+
+	isMavenProject, err := fileutil.Exists(filepath.Join(projectDir, "pom.xml"))
+	if err != nil {
+		return "", err
+	}
+	if isMavenProject {
+		return BuildSystemMaven, nil
+	}
+
+	for _, buildFile := range []string{"build.gradle", "build.gradle.kts"} {
+		isGradleProject, err := fileutil.Exists(filepath.Join(projectDir, buildFile))
+		if err != nil {
+			return "", err
+		}
+		if isGradleProject {
+			return BuildSystemGradle, nil
+		}
+	}
+
+	return BuildSystemOther, nil
+}
+
+// IsJavaBuildSystem returns true if the build system is used to build
+// Java projects, which are fuzzed with Jazzer
+func IsJavaBuildSystem(buildSystem string) bool {
+	return buildSystem == BuildSystemMaven || buildSystem == BuildSystemGradle
 }
 
 func FindProjectDir() (string, error) {
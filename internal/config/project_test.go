@@ -120,3 +120,102 @@ func TestReadProjectConfigCMake(t *testing.T) {
 
 	require.Equal(t, BuildSystemCMake, config.BuildSystem)
 }
+
+func TestReadProjectConfigGo(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+
+	configFile := filepath.Join(projectDir, "cifuzz.yaml")
+	err = os.WriteFile(configFile, []byte("build_system: "), 0644)
+	require.NoError(t, err)
+
+	// Create a go.mod in the project dir, which should cause the build
+	// system to be detected as Go
+	err = os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module example.com/project\n"), 0644)
+	require.NoError(t, err)
+
+	config, err := ReadProjectConfig(projectDir)
+	require.NoError(t, err)
+
+	require.Equal(t, BuildSystemGo, config.BuildSystem)
+}
+
+func TestReadProjectConfigJava(t *testing.T) {
+	for buildFile, buildSystem := range map[string]string{
+		"pom.xml":          BuildSystemMaven,
+		"build.gradle":     BuildSystemGradle,
+		"build.gradle.kts": BuildSystemGradle,
+	} {
+		projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+		require.NoError(t, err)
+
+		configFile := filepath.Join(projectDir, "cifuzz.yaml")
+		err = os.WriteFile(configFile, []byte("build_system: "), 0644)
+		require.NoError(t, err)
+
+		err = os.WriteFile(filepath.Join(projectDir, buildFile), []byte{}, 0644)
+		require.NoError(t, err)
+
+		config, err := ReadProjectConfig(projectDir)
+		require.NoError(t, err)
+
+		require.Equal(t, buildSystem, config.BuildSystem, buildFile)
+	}
+}
+
+func TestReadProjectConfigCargo(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+
+	configFile := filepath.Join(projectDir, "cifuzz.yaml")
+	err = os.WriteFile(configFile, []byte("build_system: "), 0644)
+	require.NoError(t, err)
+
+	// Create the crate of the cargo-fuzz fuzz targets, which should
+	// cause the build system to be detected as Cargo
+	err = os.MkdirAll(filepath.Join(projectDir, "fuzz"), 0755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "fuzz", "Cargo.toml"), []byte{}, 0644)
+	require.NoError(t, err)
+
+	config, err := ReadProjectConfig(projectDir)
+	require.NoError(t, err)
+
+	require.Equal(t, BuildSystemCargo, config.BuildSystem)
+}
+
+func TestReadProjectConfigBazel(t *testing.T) {
+	for _, workspaceFile := range []string{"WORKSPACE", "WORKSPACE.bazel", "MODULE.bazel"} {
+		projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+		require.NoError(t, err)
+
+		configFile := filepath.Join(projectDir, "cifuzz.yaml")
+		err = os.WriteFile(configFile, []byte("build_system: "), 0644)
+		require.NoError(t, err)
+
+		err = os.WriteFile(filepath.Join(projectDir, workspaceFile), []byte{}, 0644)
+		require.NoError(t, err)
+
+		config, err := ReadProjectConfig(projectDir)
+		require.NoError(t, err)
+
+		require.Equal(t, BuildSystemBazel, config.BuildSystem, workspaceFile)
+	}
+}
+
+func TestReadProjectConfigMeson(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+
+	configFile := filepath.Join(projectDir, "cifuzz.yaml")
+	err = os.WriteFile(configFile, []byte("build_system: "), 0644)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(projectDir, "meson.build"), []byte{}, 0644)
+	require.NoError(t, err)
+
+	config, err := ReadProjectConfig(projectDir)
+	require.NoError(t, err)
+
+	require.Equal(t, BuildSystemMeson, config.BuildSystem)
+}
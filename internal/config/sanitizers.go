@@ -0,0 +1,67 @@
+package config
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	SanitizerAddress   string = "address"
+	SanitizerUndefined string = "undefined"
+	SanitizerMemory    string = "memory"
+	SanitizerThread    string = "thread"
+	SanitizerLeak      string = "leak"
+)
+
+var SupportedSanitizers = []string{SanitizerAddress, SanitizerUndefined, SanitizerMemory, SanitizerThread, SanitizerLeak}
+
+// Pairs of sanitizers which can't be used in the same build, because
+// their runtimes conflict. UBSan can be combined with all of them.
+var incompatibleSanitizers = [][2]string{
+	{SanitizerAddress, SanitizerMemory},
+	{SanitizerAddress, SanitizerThread},
+	{SanitizerMemory, SanitizerThread},
+	{SanitizerMemory, SanitizerLeak},
+	{SanitizerThread, SanitizerLeak},
+}
+
+// DefaultSanitizers returns the sanitizers which fuzz tests are built
+// with if none are configured
+func DefaultSanitizers() []string {
+	// UBSan is not supported by MSVC
+	if runtime.GOOS == "windows" {
+		return []string{SanitizerAddress}
+	}
+	return []string{SanitizerAddress, SanitizerUndefined}
+}
+
+// ValidateSanitizers checks that all sanitizers are supported on the
+// current platform and can be combined with each other
+func ValidateSanitizers(sanitizers []string) error {
+	if len(sanitizers) == 0 {
+		return errors.New("No sanitizers specified")
+	}
+	for i, sanitizer := range sanitizers {
+		if !stringutil.Contains(SupportedSanitizers, sanitizer) {
+			return errors.Errorf("Invalid sanitizer \"%s\", valid sanitizers are: %s",
+				sanitizer, strings.Join(SupportedSanitizers, ", "))
+		}
+		if stringutil.Contains(sanitizers[:i], sanitizer) {
+			return errors.Errorf("Sanitizer \"%s\" is specified multiple times", sanitizer)
+		}
+		// MSVC only supports AddressSanitizer
+		if runtime.GOOS == "windows" && sanitizer != SanitizerAddress {
+			return errors.Errorf("Sanitizer \"%s\" is not supported on Windows", sanitizer)
+		}
+	}
+	for _, pair := range incompatibleSanitizers {
+		if stringutil.Contains(sanitizers, pair[0]) && stringutil.Contains(sanitizers, pair[1]) {
+			return errors.Errorf("Sanitizers \"%s\" and \"%s\" can't be used together", pair[0], pair[1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSanitizers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Only AddressSanitizer is supported on Windows")
+	}
+
+	for _, sanitizers := range [][]string{
+		{"address"},
+		{"address", "undefined"},
+		{"address", "leak"},
+		{"undefined"},
+		{"memory"},
+		{"memory", "undefined"},
+		{"thread"},
+		{"thread", "undefined"},
+		{"leak", "undefined"},
+	} {
+		assert.NoError(t, ValidateSanitizers(sanitizers), sanitizers)
+	}
+
+	for _, sanitizers := range [][]string{
+		{},
+		{"coverage"},
+		{"address", "address"},
+		{"address", "memory"},
+		{"address", "thread"},
+		{"memory", "thread"},
+		{"memory", "leak"},
+		{"thread", "leak", "undefined"},
+	} {
+		assert.Error(t, ValidateSanitizers(sanitizers), sanitizers)
+	}
+}
+
+func TestReadProjectConfigSanitizers(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+
+	configFile := filepath.Join(projectDir, "cifuzz.yaml")
+	err = os.WriteFile(configFile, []byte("build-system: other\n"), 0644)
+	require.NoError(t, err)
+	config, err := ReadProjectConfig(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSanitizers(), config.Sanitizers)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	err = os.WriteFile(configFile, []byte("build-system: other\nsanitizers:\n  - memory\n  - undefined\n"), 0644)
+	require.NoError(t, err)
+	config, err = ReadProjectConfig(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"memory", "undefined"}, config.Sanitizers)
+
+	err = os.WriteFile(configFile, []byte("build-system: other\nsanitizers: [address, thread]\n"), 0644)
+	require.NoError(t, err)
+	_, err = ReadProjectConfig(projectDir)
+	assert.Error(t, err)
+}
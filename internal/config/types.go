@@ -3,11 +3,19 @@ package config
 type FuzzTestType string
 
 const (
-	CPP FuzzTestType = "cpp"
+	CPP  FuzzTestType = "cpp"
+	GO   FuzzTestType = "go"
+	JAVA FuzzTestType = "java"
 )
 
 type Engine string
 
 const (
 	LIBFUZZER Engine = "libfuzzer"
+	AFL       Engine = "afl"
+	HONGGFUZZ Engine = "honggfuzz"
+	// Go's native fuzzing engine (go test -fuzz)
+	GO_NATIVE Engine = "go"
+	// Jazzer, the libFuzzer-based fuzzing engine for the JVM
+	JAZZER Engine = "jazzer"
 )
@@ -2,15 +2,19 @@ package artifact
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"compress/gzip"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
+
+	"code-intelligence.com/cifuzz/pkg/log"
 )
 
 // WriteArchive writes a GZip-compressed TAR to out containing the files and directories given in manifest.
@@ -57,14 +61,58 @@ func AddDirToManifest(manifest map[string]string, archiveBasePath string, dir st
 	})
 }
 
-// ExtractArchiveForTestsOnly extracts the GZip-compressed TAR read by in into dir.
-func ExtractArchiveForTestsOnly(in io.Reader, dir string) error {
+// The limits which ExtractArchive and ExtractZipArchive enforce to
+// protect against archive bombs
+var (
+	// The maximum total size of the extracted files in bytes
+	MaxExtractedSize int64 = 10 << 30
+	// The maximum number of entries in an archive
+	MaxExtractedEntries = 1 << 20
+)
+
+// extractLimits tracks the size and number of the entries extracted
+// from an archive.
+type extractLimits struct {
+	size    int64
+	entries int
+}
+
+func (l *extractLimits) addEntry() error {
+	l.entries++
+	if l.entries > MaxExtractedEntries {
+		return errors.Errorf("archive has more than the maximum of %d entries", MaxExtractedEntries)
+	}
+	return nil
+}
+
+// copy copies content to w, failing as soon as the total size of the
+// extracted files exceeds MaxExtractedSize.
+func (l *extractLimits) copy(w io.Writer, content io.Reader) error {
+	remaining := MaxExtractedSize - l.size
+	n, err := io.CopyN(w, content, remaining+1)
+	l.size += n
+	if err != nil && err != io.EOF {
+		return errors.WithStack(err)
+	}
+	if l.size > MaxExtractedSize {
+		return errors.Errorf("extracted size of archive exceeds the maximum of %d bytes", MaxExtractedSize)
+	}
+	return nil
+}
+
+// ExtractArchive extracts the GZip-compressed TAR read by in into dir.
+// The archive may come from an untrusted source, so entries which would
+// be extracted outside of dir are rejected, links and special files
+// are skipped, the permissions of files are restricted and the size and number of
+// entries are limited by MaxExtractedSize and MaxExtractedEntries.
+func ExtractArchive(in io.Reader, dir string) error {
 	gr, err := gzip.NewReader(in)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	defer gr.Close()
 	tr := tar.NewReader(gr)
+	limits := &extractLimits{}
 
 	for {
 		var header *tar.Header
@@ -75,42 +123,128 @@ func ExtractArchiveForTestsOnly(in io.Reader, dir string) error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		err = limits.addEntry()
+		if err != nil {
+			return err
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
-			err = os.Mkdir(filepath.Join(dir, header.Name), 0755)
-			if err != nil {
-				return errors.WithStack(err)
-			}
+			err = extractDir(dir, header.Name)
 		case tar.TypeReg:
+			err = extractFile(dir, header.Name, os.FileMode(header.Mode), tr, limits)
+		case tar.TypeXGlobalHeader:
+			// PAX global headers, e.g. the one written by git archive,
+			// only contain metadata
+		default:
+			// Links could point to arbitrary files outside of dir, so
+			// they are skipped like special files, which is also what
+			// happens when files are read from a directory
+			log.Debugf("Skipping %q in archive, it's not a regular file or directory", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractZipArchive extracts the ZIP archive at path into dir. Like
+// ExtractArchive, it's safe to use with archives from untrusted sources.
+func ExtractZipArchive(path string, dir string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer zr.Close()
+
+	// The number of entries is known upfront, the sizes stored in the
+	// archive can't be trusted though
+	if len(zr.File) > MaxExtractedEntries {
+		return errors.Errorf("archive has more than the maximum of %d entries", MaxExtractedEntries)
+	}
+	limits := &extractLimits{}
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = extractDir(dir, f.Name)
+		case mode.IsRegular():
 			err = func() error {
-				filePath := filepath.Join(dir, header.Name)
-				err = os.MkdirAll(filepath.Dir(filePath), 0755)
+				rc, err := f.Open()
 				if err != nil {
 					return errors.WithStack(err)
 				}
-				var file *os.File
-				file, err = os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, os.FileMode(header.Mode))
-				if err != nil {
-					return errors.WithStack(err)
-				}
-				defer file.Close()
-				_, err = io.Copy(file, tr)
-				if err != nil {
-					return errors.WithStack(err)
-				}
-				return nil
+				defer rc.Close()
+				return extractFile(dir, f.Name, mode, rc, limits)
 			}()
-			if err != nil {
-				return err
-			}
 		default:
-			return errors.Errorf("unsupported file type: %d", header.Typeflag)
+			log.Debugf("Skipping %q in archive, it's not a regular file or directory", f.Name)
+		}
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// extractPath returns the path in dir at which the archive entry with
+// the given name should be extracted. It returns an error if that path
+// is not below dir.
+func extractPath(dir string, name string) (string, error) {
+	// Archives always use forward slashes as separators
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", errors.Errorf("archive entry has an absolute path: %q", name)
+	}
+	path := filepath.Join(dir, name)
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("archive entry is outside of the target directory: %q", name)
+	}
+	return path, nil
+}
+
+func extractDir(dir string, name string) error {
+	path, err := extractPath(dir, name)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(os.MkdirAll(path, 0755))
+}
+
+func extractFile(dir string, name string, mode os.FileMode, content io.Reader, limits *extractLimits) error {
+	path, err := extractPath(dir, name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Only keep the executable bit, never setuid/setgid or write
+	// permissions for other users.
+	perm := os.FileMode(0644)
+	if mode&0100 != 0 {
+		perm = 0755
+	}
+	// Don't follow a symlink which might already exist at the path
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+	return limits.copy(file, content)
+}
+
 // addToArchive adds the file absPath to the archive under the path archivePath.
 func addToArchive(tw *tar.Writer, archivePath, absPath string) error {
 	fileOrDir, err := os.Open(absPath)
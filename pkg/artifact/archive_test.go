@@ -1,12 +1,17 @@
 package artifact_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 
@@ -130,3 +135,202 @@ func TestWriteArchive(t *testing.T) {
 	}
 	require.Empty(t, remainingExpectedEntries, "Archive did not contain the following expected entries: %s", msg.String())
 }
+
+func TestExtractArchive(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	writeTarFile(t, tw, "dir/file", "foo", 0755)
+	writeTarFile(t, tw, "other", "bar", 04777)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	dir := t.TempDir()
+	err := artifact.ExtractArchive(&buf, dir)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "dir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+	if runtime.GOOS != "windows" {
+		// Setuid and write permissions for others must be dropped
+		info, err := os.Stat(filepath.Join(dir, "other"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode()&(os.ModePerm|os.ModeSetuid))
+	}
+}
+
+func TestExtractArchive_Untrusted(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(tw *tar.Writer)
+	}{
+		{
+			name: "path traversal",
+			write: func(tw *tar.Writer) {
+				writeTarFile(t, tw, "../escaped", "foo", 0644)
+			},
+		},
+		{
+			name: "absolute path",
+			write: func(tw *tar.Writer) {
+				writeTarFile(t, tw, "/tmp/escaped", "foo", 0644)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			tt.write(tw)
+			require.NoError(t, tw.Close())
+			require.NoError(t, gw.Close())
+
+			parentDir := t.TempDir()
+			dir := filepath.Join(parentDir, "out")
+			require.NoError(t, os.Mkdir(dir, 0755))
+			err := artifact.ExtractArchive(&buf, dir)
+			assert.Error(t, err)
+			assert.NoFileExists(t, filepath.Join(parentDir, "escaped"))
+		})
+	}
+}
+
+func TestExtractArchive_SkippedEntries(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	// The PAX global header which git archive writes
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag:   tar.TypeXGlobalHeader,
+		PAXRecords: map[string]string{"comment": "0123456789abcdef"},
+	})
+	require.NoError(t, err)
+	err = tw.WriteHeader(&tar.Header{Name: "symlink", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink})
+	require.NoError(t, err)
+	err = tw.WriteHeader(&tar.Header{Name: "hardlink", Linkname: "/etc/passwd", Typeflag: tar.TypeLink})
+	require.NoError(t, err)
+	err = tw.WriteHeader(&tar.Header{Name: "fifo", Typeflag: tar.TypeFifo, Mode: 0644})
+	require.NoError(t, err)
+	writeTarFile(t, tw, "file", "foo", 0644)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	// Links and special files are skipped, the other files are
+	// extracted
+	dir := t.TempDir()
+	err = artifact.ExtractArchive(&buf, dir)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "file", entries[0].Name())
+}
+
+func TestExtractArchive_Limits(t *testing.T) {
+	maxSize, maxEntries := artifact.MaxExtractedSize, artifact.MaxExtractedEntries
+	t.Cleanup(func() {
+		artifact.MaxExtractedSize, artifact.MaxExtractedEntries = maxSize, maxEntries
+	})
+	artifact.MaxExtractedSize = 5
+	artifact.MaxExtractedEntries = 2
+
+	tests := []struct {
+		name          string
+		files         map[string]string
+		expectedError string
+	}{
+		{
+			name:  "within limits",
+			files: map[string]string{"a": "foo", "b": "ba"},
+		},
+		{
+			name:          "too large",
+			files:         map[string]string{"a": "foo", "b": "bar"},
+			expectedError: "exceeds the maximum of 5 bytes",
+		},
+		{
+			name:          "too many entries",
+			files:         map[string]string{"a": "f", "b": "b", "c": "z"},
+			expectedError: "more than the maximum of 2 entries",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for name := range tt.files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			archivePath := filepath.Join(t.TempDir(), "corpus.zip")
+			zipFile, err := os.Create(archivePath)
+			require.NoError(t, err)
+			zw := zip.NewWriter(zipFile)
+			for _, name := range names {
+				writeTarFile(t, tw, name, tt.files[name], 0644)
+				w, err := zw.Create(name)
+				require.NoError(t, err)
+				_, err = w.Write([]byte(tt.files[name]))
+				require.NoError(t, err)
+			}
+			require.NoError(t, tw.Close())
+			require.NoError(t, gw.Close())
+			require.NoError(t, zw.Close())
+			require.NoError(t, zipFile.Close())
+
+			tarErr := artifact.ExtractArchive(&buf, t.TempDir())
+			zipErr := artifact.ExtractZipArchive(archivePath, t.TempDir())
+			if tt.expectedError == "" {
+				assert.NoError(t, tarErr)
+				assert.NoError(t, zipErr)
+				return
+			}
+			require.Error(t, tarErr)
+			assert.Contains(t, tarErr.Error(), tt.expectedError)
+			require.Error(t, zipErr)
+			assert.Contains(t, zipErr.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestExtractZipArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "corpus.zip")
+	file, err := os.Create(archivePath)
+	require.NoError(t, err)
+	zw := zip.NewWriter(file)
+	w, err := zw.Create("dir/file")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("foo"))
+	require.NoError(t, err)
+	_, err = zw.Create("../escaped")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, file.Close())
+
+	dir := t.TempDir()
+	err = artifact.ExtractZipArchive(archivePath, dir)
+	// The second entry is outside of the target directory
+	require.Error(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "dir", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escaped"))
+}
+
+func writeTarFile(t *testing.T, tw *tar.Writer, name string, content string, mode int64) {
+	err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     mode,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+	})
+	require.NoError(t, err)
+	_, err = tw.Write([]byte(content))
+	require.NoError(t, err)
+}
@@ -199,6 +199,9 @@ type Options struct {
 	Args     []string
 	Env      []string
 	Bindings []*Binding
+	// The working directory inside the sandbox. Defaults to the current
+	// working directory.
+	WorkDir string
 }
 
 type minijail struct {
@@ -287,9 +290,12 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	// Some fuzz targets (e.g. the one for nginx) write to the working
 	// directory, which is why we mount it read-write. We decided that
 	// this is fine on CIFUZZ-1192.
-	workdir, err := os.Getwd()
-	if err != nil {
-		return nil, errors.WithStack(err)
+	workdir := opts.WorkDir
+	if workdir == "" {
+		workdir, err = os.Getwd()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
 	}
 	bindings = append(bindings, &Binding{Source: workdir, Writable: ReadWrite})
 
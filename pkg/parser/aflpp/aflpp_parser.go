@@ -0,0 +1,220 @@
+package aflpp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/parser/sanitizer"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// Parser reads the files which afl-fuzz writes to the directory of a
+// fuzzer instance (e.g. <output dir>/default) and turns them into
+// reports.
+type Parser struct {
+	instanceDir string
+	// The names of the crashing inputs for which a finding was
+	// already returned
+	seenCrashes map[string]bool
+	// The timestamp of the last metric which was returned
+	lastUpdate time.Time
+}
+
+func NewParser(instanceDir string) *Parser {
+	return &Parser{
+		instanceDir: instanceDir,
+		seenCrashes: map[string]bool{},
+	}
+}
+
+// NewMetric returns the metric from the fuzzer_stats file if it was
+// updated since the last call. It returns nil if the file doesn't exist
+// yet or wasn't updated.
+func (p *Parser) NewMetric() (*report.FuzzingMetric, error) {
+	file, err := os.Open(filepath.Join(p.instanceDir, "fuzzer_stats"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	metric, err := ParseFuzzerStats(file)
+	if err != nil {
+		return nil, err
+	}
+	if !metric.Timestamp.After(p.lastUpdate) {
+		return nil, nil
+	}
+	p.lastUpdate = metric.Timestamp
+	return metric, nil
+}
+
+// NewFindings returns a finding for each crashing input in the crashes
+// directory for which no finding was returned before.
+func (p *Parser) NewFindings() ([]*report.Finding, error) {
+	crashesDir := filepath.Join(p.instanceDir, "crashes")
+	entries, err := os.ReadDir(crashesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Sort the crashes by name, which starts with the ID of the crash,
+	// to report them in the order in which they were found
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var findings []*report.Finding
+	for _, entry := range entries {
+		// The crashes directory also contains a README.txt
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), "id:") {
+			continue
+		}
+		if p.seenCrashes[entry.Name()] {
+			continue
+		}
+		p.seenCrashes[entry.Name()] = true
+
+		path := filepath.Join(crashesDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		findings = append(findings, &report.Finding{
+			Type:      report.ErrorType_CRASH,
+			InputData: data,
+			InputFile: path,
+			Details:   crashDetails(entry.Name()),
+		})
+	}
+	return findings, nil
+}
+
+// ParseFuzzerStats parses the fuzzer_stats file written by afl-fuzz,
+// which consists of lines of the form "key : value".
+func ParseFuzzerStats(r io.Reader) (*report.FuzzingMetric, error) {
+	stats := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		stats[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lastUpdate, err := parseUint(stats, "last_update")
+	if err != nil {
+		return nil, err
+	}
+	execsPerSec, err := strconv.ParseFloat(stats["execs_per_sec"], 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid value for execs_per_sec in fuzzer_stats: %q", stats["execs_per_sec"])
+	}
+	execsDone, err := parseUint(stats, "execs_done")
+	if err != nil {
+		return nil, err
+	}
+	corpusCount, err := parseUint(stats, "corpus_count")
+	if err != nil {
+		return nil, err
+	}
+	edgesFound, err := parseUint(stats, "edges_found")
+	if err != nil {
+		return nil, err
+	}
+
+	metric := &report.FuzzingMetric{
+		Timestamp:           time.Unix(int64(lastUpdate), 0),
+		ExecutionsPerSecond: int32(execsPerSec),
+		TotalExecutions:     execsDone,
+		CorpusSize:          int32(corpusCount),
+		Edges:               int32(edgesFound),
+		// AFL++ doesn't have a notion of features like libFuzzer, the
+		// closest equivalent are the edges
+		Features: int32(edgesFound),
+	}
+
+	if _, ok := stats["last_find"]; ok {
+		lastFind, err := parseUint(stats, "last_find")
+		if err != nil {
+			return nil, err
+		}
+		// Older versions of AFL++ write the time of the last find as a
+		// unix timestamp, newer versions the seconds since the last find
+		// (and 0 if nothing was found yet).
+		var secondsSinceLastFind uint64
+		if lastFind > lastUpdate/2 {
+			if lastFind <= lastUpdate {
+				secondsSinceLastFind = lastUpdate - lastFind
+			}
+		} else {
+			secondsSinceLastFind = lastFind
+		}
+		metric.SecondsSinceLastEdge = secondsSinceLastFind
+		metric.SecondsSinceLastFeature = secondsSinceLastFind
+	}
+
+	return metric, nil
+}
+
+// AddReplayOutput adds the output of the fuzz test when it was executed
+// on the crashing input of the finding to the finding. If the output
+// contains a sanitizer report, the details of the finding are taken
+// from that report.
+func AddReplayOutput(finding *report.Finding, logs []string) {
+	finding.Logs = logs
+	for _, line := range logs {
+		sanitizerFinding := sanitizer.ParseAsFinding(line)
+		if sanitizerFinding != nil {
+			finding.Type = sanitizerFinding.Type
+			finding.Details = sanitizerFinding.Details
+			break
+		}
+	}
+	finding.StackTrace = sanitizer.ParseStackTrace(logs)
+	finding.Signature = sanitizer.CrashSignature(finding.Details, finding.StackTrace)
+	finding.MoreDetails = sanitizer.ParseErrorDetails(logs)
+	finding.ShortDescription = sanitizer.ShortDescription(finding)
+}
+
+// crashDetails derives the details of a finding from the name of the
+// crashing input, which afl-fuzz sets to something like
+// "id:000000,sig:06,src:000000,time:1234,execs:5678,op:havoc,rep:4".
+func crashDetails(name string) string {
+	for _, field := range strings.Split(name, ",") {
+		if !strings.HasPrefix(field, "sig:") {
+			continue
+		}
+		sig, err := strconv.Atoi(strings.TrimPrefix(field, "sig:"))
+		if err != nil {
+			break
+		}
+		return fmt.Sprintf("deadly signal %d (%s)", sig, syscall.Signal(sig))
+	}
+	return "deadly signal"
+}
+
+func parseUint(stats map[string]string, key string) (uint64, error) {
+	value, err := strconv.ParseUint(stats[key], 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Invalid value for %s in fuzzer_stats: %q", key, stats[key])
+	}
+	return value, nil
+}
@@ -0,0 +1,102 @@
+package aflpp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+const fuzzerStats = `start_time        : 1660000000
+last_update       : 1660000100
+run_time          : 100
+fuzzer_pid        : 12345
+cycles_done       : 2
+execs_done        : 123456
+execs_per_sec     : 1234.56
+corpus_count      : 42
+edges_found       : 314
+last_find         : 1660000090
+bitmap_cvg        : 0.48%
+`
+
+func TestParseFuzzerStats(t *testing.T) {
+	metric, err := ParseFuzzerStats(strings.NewReader(fuzzerStats))
+	require.NoError(t, err)
+	assert.Equal(t, &report.FuzzingMetric{
+		Timestamp:               time.Unix(1660000100, 0),
+		ExecutionsPerSecond:     1234,
+		TotalExecutions:         123456,
+		CorpusSize:              42,
+		Edges:                   314,
+		Features:                314,
+		SecondsSinceLastEdge:    10,
+		SecondsSinceLastFeature: 10,
+	}, metric)
+
+	// Newer versions of AFL++ write the seconds since the last find
+	metric, err = ParseFuzzerStats(strings.NewReader(strings.Replace(fuzzerStats, "1660000090", "25", 1)))
+	require.NoError(t, err)
+	assert.EqualValues(t, 25, metric.SecondsSinceLastEdge)
+
+	_, err = ParseFuzzerStats(strings.NewReader("execs_done : 1\n"))
+	assert.Error(t, err)
+}
+
+func TestParser(t *testing.T) {
+	instanceDir := t.TempDir()
+	crashesDir := filepath.Join(instanceDir, "crashes")
+	require.NoError(t, os.Mkdir(crashesDir, 0755))
+	parser := NewParser(instanceDir)
+
+	metric, err := parser.NewMetric()
+	require.NoError(t, err)
+	assert.Nil(t, metric)
+
+	require.NoError(t, os.WriteFile(filepath.Join(instanceDir, "fuzzer_stats"), []byte(fuzzerStats), 0644))
+	metric, err = parser.NewMetric()
+	require.NoError(t, err)
+	assert.NotNil(t, metric)
+	// The metric is only returned again once the stats were updated
+	metric, err = parser.NewMetric()
+	require.NoError(t, err)
+	assert.Nil(t, metric)
+
+	require.NoError(t, os.WriteFile(filepath.Join(crashesDir, "README.txt"), []byte("readme"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(crashesDir, "id:000000,sig:06,src:000000,time:1234,execs:5678,op:havoc,rep:4"), []byte("crash"), 0644))
+	findings, err := parser.NewFindings()
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, []byte("crash"), findings[0].InputData)
+	assert.Equal(t, report.ErrorType_CRASH, findings[0].Type)
+	assert.Contains(t, findings[0].Details, "deadly signal 6")
+
+	// Crashes are only reported once
+	findings, err = parser.NewFindings()
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestAddReplayOutput(t *testing.T) {
+	finding := &report.Finding{Type: report.ErrorType_CRASH, Details: "deadly signal"}
+	logs := []string{
+		"==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011",
+		"READ of size 1 at 0x602000000011 thread T0",
+		"    #0 0x55f7e8 in parse /src/parser.c:12:3",
+		"    #1 0x55f8a1 in LLVMFuzzerTestOneInput /src/fuzz_test.c:8:3",
+	}
+	AddReplayOutput(finding, logs)
+	assert.True(t, strings.HasPrefix(finding.Details, "heap-buffer-overflow"))
+	assert.Equal(t, logs, finding.Logs)
+	assert.NotEmpty(t, finding.StackTrace)
+	assert.NotEmpty(t, finding.Signature)
+	require.NotNil(t, finding.MoreDetails)
+	assert.Equal(t, "heap-buffer-overflow", finding.MoreDetails.Id)
+	assert.Equal(t, float32(5.5), finding.MoreDetails.Severity.Score)
+}
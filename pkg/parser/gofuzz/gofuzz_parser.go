@@ -0,0 +1,237 @@
+package gofuzz
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+
+	libfuzzer_output_parser "code-intelligence.com/cifuzz/pkg/parser/libfuzzer"
+	"code-intelligence.com/cifuzz/pkg/parser/sanitizer"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/regexutil"
+)
+
+var (
+	// Printed by the fuzzing engine every few seconds, e.g.
+	//   fuzz: elapsed: 3s, execs: 325017 (108336/sec), new interesting: 11 (total: 12)
+	statsPattern = regexp.MustCompile(
+		`fuzz: elapsed: \d+s, execs: (?P<execs>\d+) \((?P<execs_per_second>\d+)/sec\), ` +
+			`new interesting: (?P<new_interesting>\d+) \(total: (?P<total>\d+)\)`,
+	)
+	// Printed once the seed corpus was executed:
+	//   fuzz: elapsed: 0s, gathering baseline coverage: 12/12 completed, now fuzzing with 8 workers
+	fuzzingStartedPattern = regexp.MustCompile(`fuzz: elapsed: \d+s, .*now fuzzing with \d+ workers`)
+	// Printed by the testing package when the fuzz test failed:
+	//   --- FAIL: FuzzParse (0.02s)
+	failPattern = regexp.MustCompile(`^--- FAIL: (?P<name>\S+)`)
+	// The message with which the fuzz test failed, e.g.
+	//       parse_test.go:20: unexpected result
+	//       testing.go:1349: panic: runtime error: index out of range [3] with length 3
+	failMessagePattern = regexp.MustCompile(`^\s+\S+\.go:\d+: (?P<message>.+)$`)
+	inputFilePattern   = regexp.MustCompile(`Failing input written to (?P<input_file>\S+)`)
+)
+
+type Options struct {
+	KeepColor bool
+	// The working directory of the fuzz test, relative to which the
+	// paths of the failing inputs are printed
+	WorkDir string
+}
+
+type parser struct {
+	*Options
+
+	reportsCh       chan *report.Report
+	fuzzingStarted  bool
+	pendingFinding  *report.Finding
+	lastInteresting uint64
+	lastNewTime     time.Time
+}
+
+func NewOutputParser(options *Options) *parser {
+	if options == nil {
+		options = &Options{}
+	}
+	return &parser{Options: options}
+}
+
+// Parse parses the output of a test binary which runs a native Go fuzz
+// test (i.e. with -test.fuzz) and sends reports for the status, the
+// metrics and the findings.
+func (p *parser) Parse(ctx context.Context, input io.Reader, reportsCh chan *report.Report) error {
+	p.reportsCh = reportsCh
+	defer close(p.reportsCh)
+	p.lastNewTime = time.Now()
+	scanner := bufio.NewScanner(input)
+
+	for scanner.Scan() {
+		err := p.parseLine(ctx, scanner.Text())
+		if err != nil {
+			return err
+		}
+	}
+	return p.sendPendingFindingIfAny(ctx)
+}
+
+func (p *parser) parseLine(ctx context.Context, line string) error {
+	if !p.KeepColor {
+		line = pterm.RemoveColorFromString(line)
+	}
+
+	if !p.fuzzingStarted && fuzzingStartedPattern.MatchString(line) {
+		p.fuzzingStarted = true
+		return p.sendReport(ctx, &report.Report{Status: report.RunStatus_RUNNING})
+	}
+
+	if metric := p.parseAsFuzzingMetric(line); metric != nil {
+		return p.sendReport(ctx, &report.Report{Status: report.RunStatus_RUNNING, Metric: metric})
+	}
+
+	if _, found := regexutil.FindNamedGroupsMatch(failPattern, line); found {
+		if p.pendingFinding != nil {
+			// The testing package prints a "--- FAIL" line for the
+			// fuzz test itself and one for the failing input, which
+			// both belong to the same finding
+			p.pendingFinding.Logs = append(p.pendingFinding.Logs, line)
+			return nil
+		}
+		p.pendingFinding = &report.Finding{Logs: []string{line}}
+		return nil
+	}
+
+	if p.pendingFinding == nil {
+		return nil
+	}
+
+	// The test binary prints "FAIL" after the output of the failed test
+	if line == "FAIL" {
+		return p.sendPendingFindingIfAny(ctx)
+	}
+
+	p.pendingFinding.Logs = append(p.pendingFinding.Logs, line)
+
+	if result, found := regexutil.FindNamedGroupsMatch(inputFilePattern, line); found {
+		inputFile := result["input_file"]
+		if !filepath.IsAbs(inputFile) && p.WorkDir != "" {
+			inputFile = filepath.Join(p.WorkDir, inputFile)
+		}
+		inputData, err := os.ReadFile(inputFile)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		p.pendingFinding.InputFile = inputFile
+		p.pendingFinding.InputData = inputData
+	}
+
+	return nil
+}
+
+func (p *parser) parseAsFuzzingMetric(line string) *report.FuzzingMetric {
+	result, found := regexutil.FindNamedGroupsMatch(statsPattern, line)
+	if !found {
+		return nil
+	}
+	now := time.Now()
+	execs, _ := strconv.ParseUint(result["execs"], 10, 64)
+	execsPerSecond, _ := strconv.ParseInt(result["execs_per_second"], 10, 32)
+	newInteresting, _ := strconv.ParseUint(result["new_interesting"], 10, 64)
+	total, _ := strconv.ParseInt(result["total"], 10, 32)
+
+	if newInteresting > p.lastInteresting {
+		p.lastInteresting = newInteresting
+		p.lastNewTime = now
+	}
+	secondsSinceLastNew := uint64(now.Sub(p.lastNewTime).Seconds())
+
+	// Go's fuzzing engine doesn't print the covered edges or features,
+	// the closest equivalent is the number of interesting inputs
+	return &report.FuzzingMetric{
+		Timestamp:               now,
+		ExecutionsPerSecond:     int32(execsPerSecond),
+		TotalExecutions:         execs,
+		CorpusSize:              int32(total),
+		Features:                int32(total),
+		SecondsSinceLastFeature: secondsSinceLastNew,
+		SecondsSinceLastEdge:    secondsSinceLastNew,
+	}
+}
+
+func (p *parser) sendPendingFindingIfAny(ctx context.Context) error {
+	if p.pendingFinding == nil {
+		return nil
+	}
+	finding := p.pendingFinding
+	p.pendingFinding = nil
+
+	finding.Type = report.ErrorType_RUNTIME_ERROR
+	finding.Details = failureDetails(finding.Logs)
+	if strings.HasPrefix(finding.Details, "panic: ") {
+		finding.Type = report.ErrorType_CRASH
+	}
+	finding.StackTrace = ParseStackTrace(finding.Logs)
+	finding.Signature = sanitizer.CrashSignature(finding.Details, finding.StackTrace)
+	finding.ShortDescription = sanitizer.ShortDescription(finding)
+
+	return p.sendReport(ctx, &report.Report{Status: report.RunStatus_RUNNING, Finding: finding})
+}
+
+func (p *parser) sendReport(ctx context.Context, report *report.Report) error {
+	select {
+	case p.reportsCh <- report:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// failureDetails returns the message with which the fuzz test failed.
+// If the logs don't contain a message of the testing package, the first
+// line which isn't a "--- FAIL" line is used, for example the error
+// which the fuzzing engine prints if the fuzz test crashed a worker.
+func failureDetails(logs []string) string {
+	var firstLine string
+	for _, line := range logs {
+		if result, found := regexutil.FindNamedGroupsMatch(failMessagePattern, line); found {
+			return strings.TrimSpace(result["message"])
+		}
+		trimmed := strings.TrimSpace(line)
+		if firstLine == "" && trimmed != "" && !strings.HasPrefix(trimmed, "--- FAIL") {
+			firstLine = trimmed
+		}
+	}
+	if firstLine != "" {
+		return firstLine
+	}
+	return "fuzz test failed"
+}
+
+// ParseStackTrace returns the frames of the stack trace of the
+// goroutine which panicked. The testing package indents the stack trace
+// in the output of the failed test, so the indentation is removed
+// before it's parsed.
+func ParseStackTrace(logs []string) []*report.StackFrame {
+	var indent string
+	var inStackTrace bool
+	var lines []string
+	for _, line := range logs {
+		if !inStackTrace {
+			trimmed := strings.TrimLeft(line, " ")
+			if !strings.HasPrefix(trimmed, "goroutine ") {
+				continue
+			}
+			indent = line[:len(line)-len(trimmed)]
+			inStackTrace = true
+		}
+		lines = append(lines, strings.TrimPrefix(line, indent))
+	}
+	return libfuzzer_output_parser.ParseGoStackTrace(lines)
+}
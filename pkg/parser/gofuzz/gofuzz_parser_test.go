@@ -0,0 +1,102 @@
+package gofuzz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func parse(t *testing.T, options *Options, output string) []*report.Report {
+	reportsCh := make(chan *report.Report)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- NewOutputParser(options).Parse(context.Background(), strings.NewReader(output), reportsCh)
+	}()
+	var reports []*report.Report
+	for r := range reportsCh {
+		reports = append(reports, r)
+	}
+	require.NoError(t, <-errCh)
+	return reports
+}
+
+func TestParse_Metrics(t *testing.T) {
+	output := `fuzz: elapsed: 0s, gathering baseline coverage: 0/3 completed
+fuzz: elapsed: 0s, gathering baseline coverage: 3/3 completed, now fuzzing with 8 workers
+fuzz: elapsed: 3s, execs: 325017 (108336/sec), new interesting: 11 (total: 14)
+PASS
+`
+	reports := parse(t, nil, output)
+	require.Len(t, reports, 2)
+	assert.Equal(t, report.RunStatus_RUNNING, reports[0].Status)
+	assert.Nil(t, reports[0].Metric)
+	assert.EqualValues(t, 325017, reports[1].Metric.TotalExecutions)
+	assert.EqualValues(t, 108336, reports[1].Metric.ExecutionsPerSecond)
+	assert.EqualValues(t, 14, reports[1].Metric.CorpusSize)
+}
+
+func TestParse_Failure(t *testing.T) {
+	workDir := t.TempDir()
+	inputFile := filepath.Join("testdata", "fuzz", "FuzzReverse", "af69258a12129d6c")
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, filepath.Dir(inputFile)), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, inputFile), []byte("go test fuzz v1\n[]byte(\"\\x9c\")\n"), 0644))
+
+	output := `fuzz: elapsed: 0s, gathering baseline coverage: 0/1 completed
+--- FAIL: FuzzReverse (0.02s)
+    --- FAIL: FuzzReverse (0.00s)
+        reverse_test.go:20: Reverse produced invalid UTF-8 string "\x9c"
+
+    Failing input written to ` + filepath.ToSlash(inputFile) + `
+    To re-run:
+    go test -run=FuzzReverse/af69258a12129d6c
+FAIL
+`
+	reports := parse(t, &Options{WorkDir: workDir}, output)
+	require.Len(t, reports, 1)
+	finding := reports[0].Finding
+	require.NotNil(t, finding)
+	assert.Equal(t, report.ErrorType_RUNTIME_ERROR, finding.Type)
+	assert.Equal(t, `Reverse produced invalid UTF-8 string "\x9c"`, finding.Details)
+	assert.Equal(t, filepath.Join(workDir, inputFile), finding.InputFile)
+	assert.Contains(t, string(finding.InputData), "go test fuzz v1")
+}
+
+func TestParse_Panic(t *testing.T) {
+	output := `--- FAIL: FuzzParse (0.05s)
+    --- FAIL: FuzzParse (0.00s)
+        testing.go:1349: panic: runtime error: index out of range [3] with length 3
+            goroutine 20 [running]:
+            runtime/debug.Stack()
+            	/usr/lib/go/src/runtime/debug/stack.go:24 +0x90
+            testing.tRunner.func1()
+            	/usr/lib/go/src/testing/testing.go:1349 +0x1f2
+            panic({0x5b2a40, 0xc0000b8018})
+            	/usr/lib/go/src/runtime/panic.go:838 +0x207
+            example.com/parser.Parse({0xc0000a6000, 0x3, 0x40})
+            	/src/parser/parser.go:12 +0x1d
+            example.com/parser.FuzzParse.func1(0x0?, {0xc0000a6000, 0x3, 0x40})
+            	/src/parser/parser_test.go:9 +0x2a
+FAIL
+`
+	reports := parse(t, nil, output)
+	require.Len(t, reports, 1)
+	finding := reports[0].Finding
+	require.NotNil(t, finding)
+	assert.Equal(t, report.ErrorType_CRASH, finding.Type)
+	assert.Equal(t, "panic: runtime error: index out of range [3] with length 3", finding.Details)
+	require.NotEmpty(t, finding.StackTrace)
+	assert.Equal(t, "runtime/debug.Stack", finding.StackTrace[0].Function)
+	assert.Equal(t, &report.StackFrame{
+		Function: "example.com/parser.Parse",
+		File:     "/src/parser/parser.go",
+		Line:     12,
+	}, finding.StackTrace[3])
+	assert.NotEmpty(t, finding.Signature)
+}
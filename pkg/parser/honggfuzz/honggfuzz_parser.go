@@ -0,0 +1,270 @@
+package honggfuzz
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+
+	"code-intelligence.com/cifuzz/pkg/parser/sanitizer"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/regexutil"
+)
+
+var (
+	// Honggfuzz prints a line like this for each input which adds
+	// coverage, the counters are (i)nstructions, (b)ranches,
+	// (h)ardware, (e)dges, (p)cs and (c)mp feedback:
+	//   Sz:40 Tm:1,138us (i/b/h/e/p/c) New:0/0/0/3/0/6, Cur:0/0/0/58/0/2106
+	newInputPattern = regexp.MustCompile(
+		`Sz:\d+ Tm:[\d,]+us \(i/b/h/e/p/c\) New:[\d/]+, ` +
+			`Cur:(?P<instr>\d+)/(?P<branch>\d+)/(?P<hw>\d+)/(?P<edge>\d+)/(?P<pc>\d+)/(?P<cmp>\d+)`,
+	)
+	// Printed when honggfuzz exits:
+	//   Summary iterations:123456 time:10 speed:12345 crashes_count:1 timeout_count:0 new_units_added:42 ...
+	summaryPattern = regexp.MustCompile(
+		`Summary iterations:(?P<iterations>\d+) time:(?P<time>\d+) speed:(?P<speed>\d+)`,
+	)
+	// Honggfuzz switches to the feedback driven mode once it has
+	// executed all inputs of the input corpus
+	//   Entering phase 2/3: Switching to the Feedback Driven Mode
+	feedbackModePattern = regexp.MustCompile(`Entering phase [23]/3`)
+
+	// Stack frames in HONGGFUZZ.REPORT.TXT look like this:
+	//    <0x000055555556a1b2> [func:parse file:/src/parser.c line:12 module:/src/my_fuzz_test]
+	reportStackFramePattern = regexp.MustCompile(
+		`^\s*<0x(?P<address>[0-9a-fA-F]+)>\s+\[func:(?P<function>\S*)\s+file:(?P<file>\S*)\s+line:(?P<line>\d+)\s+module:(?P<module>[^\]]*)\]`,
+	)
+)
+
+type Options struct {
+	KeepColor bool
+}
+
+type parser struct {
+	*Options
+
+	reportsCh        chan *report.Report
+	feedbackMode     bool
+	numNewInputs     int32
+	lastNewInputTime time.Time
+	lastMetric       *report.FuzzingMetric
+}
+
+func NewOutputParser(options *Options) *parser {
+	if options == nil {
+		options = &Options{}
+	}
+	return &parser{Options: options}
+}
+
+// Parse parses the output of honggfuzz, which must be run with
+// --verbose to print log lines instead of the interactive UI, and
+// sends reports for the status and metrics. Crashes are not parsed from
+// the output but from the report file, see ParseReport.
+func (p *parser) Parse(ctx context.Context, input io.Reader, reportsCh chan *report.Report) error {
+	p.reportsCh = reportsCh
+	defer close(p.reportsCh)
+	scanner := bufio.NewScanner(input)
+
+	for scanner.Scan() {
+		err := p.parseLine(ctx, scanner.Text())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseLine(ctx context.Context, line string) error {
+	if !p.KeepColor {
+		line = pterm.RemoveColorFromString(line)
+	}
+
+	if !p.feedbackMode && feedbackModePattern.MatchString(line) {
+		p.feedbackMode = true
+		return p.sendReport(ctx, &report.Report{Status: report.RunStatus_RUNNING})
+	}
+
+	if metric := p.parseAsNewInput(line); metric != nil {
+		return p.sendReport(ctx, &report.Report{Status: report.RunStatus_RUNNING, Metric: metric})
+	}
+
+	if metric := p.parseAsSummary(line); metric != nil {
+		return p.sendReport(ctx, &report.Report{Status: report.RunStatus_RUNNING, Metric: metric})
+	}
+
+	return nil
+}
+
+func (p *parser) parseAsNewInput(line string) *report.FuzzingMetric {
+	result, found := regexutil.FindNamedGroupsMatch(newInputPattern, line)
+	if !found {
+		return nil
+	}
+	now := time.Now()
+	p.numNewInputs++
+	p.lastNewInputTime = now
+
+	edges := parseInt32(result["edge"])
+	var features int32
+	for _, key := range []string{"instr", "branch", "hw", "edge", "pc", "cmp"} {
+		features += parseInt32(result[key])
+	}
+	metric := &report.FuzzingMetric{
+		Timestamp:  now,
+		Features:   features,
+		Edges:      edges,
+		CorpusSize: p.numNewInputs,
+	}
+	if p.lastMetric != nil {
+		// The new input lines don't contain the number of executions,
+		// those are only printed in the summary
+		metric.TotalExecutions = p.lastMetric.TotalExecutions
+		metric.ExecutionsPerSecond = p.lastMetric.ExecutionsPerSecond
+	}
+	p.lastMetric = metric
+	return metric
+}
+
+func (p *parser) parseAsSummary(line string) *report.FuzzingMetric {
+	result, found := regexutil.FindNamedGroupsMatch(summaryPattern, line)
+	if !found {
+		return nil
+	}
+	now := time.Now()
+	iterations, _ := strconv.ParseUint(result["iterations"], 10, 64)
+	metric := &report.FuzzingMetric{
+		Timestamp:           now,
+		TotalExecutions:     iterations,
+		ExecutionsPerSecond: parseInt32(result["speed"]),
+		CorpusSize:          p.numNewInputs,
+	}
+	if p.lastMetric != nil {
+		metric.Features = p.lastMetric.Features
+		metric.Edges = p.lastMetric.Edges
+	}
+	if !p.lastNewInputTime.IsZero() {
+		secondsSinceLastNewInput := uint64(now.Sub(p.lastNewInputTime).Seconds())
+		metric.SecondsSinceLastEdge = secondsSinceLastNewInput
+		metric.SecondsSinceLastFeature = secondsSinceLastNewInput
+	}
+	p.lastMetric = metric
+	return metric
+}
+
+func (p *parser) sendReport(ctx context.Context, report *report.Report) error {
+	select {
+	case p.reportsCh <- report:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ParseReport parses the HONGGFUZZ.REPORT.TXT file, which honggfuzz
+// writes to its workspace, and returns a finding for each crash in it.
+// The input file of the findings is set to the path of the crashing
+// input in the workspace.
+func ParseReport(r io.Reader) ([]*report.Finding, error) {
+	var findings []*report.Finding
+	var finding *report.Finding
+	var inStack bool
+	var signal string
+
+	finish := func() {
+		if finding == nil {
+			return
+		}
+		finding.Details = "deadly signal"
+		if signal != "" {
+			finding.Details += " " + signal
+		}
+		finding.Signature = sanitizer.CrashSignature(finding.Details, finding.StackTrace)
+		finding.ShortDescription = sanitizer.ShortDescription(finding)
+		findings = append(findings, finding)
+		finding = nil
+		inStack = false
+		signal = ""
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		// Each report entry is surrounded by lines of "=" characters
+		if strings.HasPrefix(trimmed, "=====") {
+			finish()
+			continue
+		}
+		if trimmed == "CRASH:" {
+			finding = &report.Finding{Type: report.ErrorType_CRASH}
+			continue
+		}
+		if finding == nil {
+			continue
+		}
+		finding.Logs = append(finding.Logs, line)
+
+		if inStack {
+			frame := parseReportStackFrame(line)
+			if frame != nil {
+				finding.StackTrace = append(finding.StackTrace, frame)
+				continue
+			}
+			inStack = false
+		}
+
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "FUZZ_FNAME":
+			finding.InputFile = value
+		case "SIGNAL":
+			signal = value
+		case "STACK":
+			inStack = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	finish()
+
+	return findings, nil
+}
+
+func parseReportStackFrame(line string) *report.StackFrame {
+	result, found := regexutil.FindNamedGroupsMatch(reportStackFramePattern, line)
+	if !found {
+		return nil
+	}
+	frame := &report.StackFrame{}
+	frame.Address, _ = strconv.ParseUint(result["address"], 16, 64)
+	if result["function"] != "UNKNOWN" {
+		frame.Function = result["function"]
+	}
+	if result["file"] != "" && result["line"] != "0" {
+		frame.File = result["file"]
+		line, _ := strconv.ParseUint(result["line"], 10, 32)
+		frame.Line = uint32(line)
+	} else {
+		frame.Module = result["module"]
+	}
+	return frame
+}
+
+func parseInt32(s string) int32 {
+	i, _ := strconv.ParseInt(s, 10, 32)
+	return int32(i)
+}
@@ -0,0 +1,88 @@
+package honggfuzz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestParse(t *testing.T) {
+	output := `Start time:'2022-08-01.12.00.00' bin:'/src/my_fuzz_test', input:'/tmp/input', output:'/tmp/output', persistent:true, stdin:false, mutation_rate:5, timeout:1, max_runs:0, threads:1, minimize:false, git_commit:1fb4e8c
+Entering phase 1/3: Dry Run
+Sz:4 Tm:1,002us (i/b/h/e/p/c) New:0/0/0/12/0/34, Cur:0/0/0/12/0/34
+Entering phase 2/3: Switching to the Feedback Driven Mode
+Sz:40 Tm:1,138us (i/b/h/e/p/c) New:0/0/0/3/0/6, Cur:0/0/0/15/0/40
+Summary iterations:123456 time:10 speed:12345 crashes_count:0 timeout_count:0 new_units_added:2 slowest_unit_ms:3 guard_nb:58 branch_coverage_percent:25 peak_rss_mb:12
+`
+	reportsCh := make(chan *report.Report)
+	parser := NewOutputParser(nil)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parser.Parse(context.Background(), strings.NewReader(output), reportsCh)
+	}()
+	var reports []*report.Report
+	for r := range reportsCh {
+		reports = append(reports, r)
+	}
+	require.NoError(t, <-errCh)
+
+	require.Len(t, reports, 4)
+	assert.EqualValues(t, 12, reports[0].Metric.Edges)
+	assert.EqualValues(t, 46, reports[0].Metric.Features)
+	assert.EqualValues(t, 1, reports[0].Metric.CorpusSize)
+	// Entering the feedback driven mode finishes the initialization
+	assert.Equal(t, report.RunStatus_RUNNING, reports[1].Status)
+	assert.Nil(t, reports[1].Metric)
+	assert.EqualValues(t, 15, reports[2].Metric.Edges)
+	assert.EqualValues(t, 2, reports[2].Metric.CorpusSize)
+	assert.EqualValues(t, 123456, reports[3].Metric.TotalExecutions)
+	assert.EqualValues(t, 12345, reports[3].Metric.ExecutionsPerSecond)
+	assert.EqualValues(t, 15, reports[3].Metric.Edges)
+}
+
+func TestParseReport(t *testing.T) {
+	reportText := `=====================================================================
+TIME: 2022-08-01.12:00:10
+=====================================================================
+FUZZER ARGS:
+ mutationsPerRun : 5
+ externalCmd     : NULL
+=====================================================================
+CRASH:
+DESCRIPTION:
+ORIG_FNAME: 1234abcd.00000004.honggfuzz.cov
+FUZZ_FNAME: /tmp/workspace/SIGSEGV.PC.55555556a1b2.STACK.badbad.CODE.1.ADDR.0.INSTR.mov.fuzz
+PID: 12345
+SIGNAL: SIGSEGV (11)
+PC: 0x55555556a1b2
+STACK HASH: 00000000badbad
+STACK:
+ <0x000055555556a1b2> [func:parse file:/src/parser.c line:12 module:/src/my_fuzz_test]
+ <0x000055555556a2c3> [func:LLVMFuzzerTestOneInput file:/src/my_fuzz_test.c line:8 module:/src/my_fuzz_test]
+ <0x00007ffff7a2d083> [func:UNKNOWN file: line:0 module:/lib/x86_64-linux-gnu/libc.so.6]
+=====================================================================
+`
+	findings, err := ParseReport(strings.NewReader(reportText))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+
+	finding := findings[0]
+	assert.Equal(t, report.ErrorType_CRASH, finding.Type)
+	assert.Equal(t, "deadly signal SIGSEGV (11)", finding.Details)
+	assert.Equal(t, "/tmp/workspace/SIGSEGV.PC.55555556a1b2.STACK.badbad.CODE.1.ADDR.0.INSTR.mov.fuzz", finding.InputFile)
+	require.Len(t, finding.StackTrace, 3)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x55555556a1b2,
+		Function: "parse",
+		File:     "/src/parser.c",
+		Line:     12,
+	}, finding.StackTrace[0])
+	assert.Equal(t, "/lib/x86_64-linux-gnu/libc.so.6", finding.StackTrace[2].Module)
+	assert.NotEmpty(t, finding.Signature)
+	assert.NotEmpty(t, finding.Logs)
+}
@@ -50,6 +50,17 @@ var (
 	slowInputPattern = regexp.MustCompile(
 		`\s*Slowest unit: (?P<duration>\d+) s.*`)
 	goPanicPattern = regexp.MustCompile(`^panic:\s+\S+`)
+	// Printed by the Rust standard library when a thread panicked, e.g.
+	//   thread '<unnamed>' panicked at 'index out of bounds: the len is 3 but the index is 5', src/lib.rs:10:5
+	// Since Rust 1.73, the message is printed on the following line:
+	//   thread '<unnamed>' panicked at src/lib.rs:10:5:
+	//   index out of bounds: the len is 3 but the index is 5
+	rustPanicPattern = regexp.MustCompile(`^thread '.*' panicked at (?:'(?P<message>.*)', )?\S+:\d+:\d+:?$`)
+)
+
+const (
+	goPanicDetails   = "Go Panic"
+	rustPanicDetails = "Rust Panic"
 )
 
 var errNotFound = errors.New("not found")
@@ -73,6 +84,9 @@ type parser struct {
 	// attach them to the finding if they seem to belong to it
 	pendingFinding                       *report.Finding
 	numMetricsLinesSinceFindingIsPending int
+	// Whether the pending finding is a Rust panic whose message is
+	// printed on the next line
+	pendingRustPanicMessage bool
 
 	lastNewFeatureTime time.Time // Timestamp representing the point when the last new feature was reported
 	lastFeatures       int       // Last features reported by Libfuzzer
@@ -208,7 +222,7 @@ func (p *parser) parseLine(ctx context.Context, line string) error {
 	}
 
 	finding := p.parseAsNewFinding(line)
-	if finding != nil && !p.libFuzzerErrorFollowingGoPanic(finding) {
+	if finding != nil && !p.errorFollowingPanic(finding) {
 		// If there is still a pending finding, send it now, because
 		// we'll treat all further output lines as belonging to the new
 		// finding.
@@ -229,6 +243,12 @@ func (p *parser) parseLine(ctx context.Context, line string) error {
 		// The line is not a metrics line and doesn't mark a new finding,
 		// so we append it to the pending finding
 		p.pendingFinding.Logs = append(p.pendingFinding.Logs, line)
+
+		if p.pendingRustPanicMessage {
+			p.pendingRustPanicMessage = false
+			p.pendingFinding.Details = rustPanicDetails + ": " + line
+			return nil
+		}
 	}
 
 	// Check if the line contains the path to the test input file (which
@@ -266,6 +286,11 @@ func (p *parser) parseAsNewFinding(line string) *report.Finding {
 		return finding
 	}
 
+	finding = p.parseAsRustFinding(line)
+	if finding != nil {
+		return finding
+	}
+
 	finding = p.parseAsLibfuzzerFinding(line)
 	if finding != nil {
 		return finding
@@ -296,15 +321,48 @@ func (p *parser) parseAsGoFinding(line string) *report.Finding {
 	if _, found := regexutil.FindNamedGroupsMatch(goPanicPattern, line); found {
 		return &report.Finding{
 			Type:    report.ErrorType_CRASH,
-			Details: "Go Panic",
+			Details: goPanicDetails,
 			Logs:    []string{line},
 		}
 	}
 	return nil
 }
 
-func (p *parser) libFuzzerErrorFollowingGoPanic(report *report.Finding) bool {
-	return p.pendingFinding.GetDetails() == "Go Panic" && report.GetDetails() != "Go Panic"
+func (p *parser) parseAsRustFinding(line string) *report.Finding {
+	result, found := regexutil.FindNamedGroupsMatch(rustPanicPattern, line)
+	if !found {
+		return nil
+	}
+	details := rustPanicDetails
+	if result["message"] != "" {
+		details += ": " + result["message"]
+	} else {
+		p.pendingRustPanicMessage = true
+	}
+	return &report.Finding{
+		Type:    report.ErrorType_CRASH,
+		Details: details,
+		Logs:    []string{line},
+	}
+}
+
+// errorFollowingPanic returns true if the finding is an error which
+// is reported because of the pending Go or Rust panic, e.g. the
+// "deadly signal" error which libFuzzer reports after a Rust panic
+// aborted the process.
+func (p *parser) errorFollowingPanic(finding *report.Finding) bool {
+	pending := p.pendingFinding.GetDetails()
+	if pending == goPanicDetails {
+		return finding.GetDetails() != goPanicDetails
+	}
+	if isRustPanic(pending) {
+		return !isRustPanic(finding.GetDetails())
+	}
+	return false
+}
+
+func isRustPanic(details string) bool {
+	return details == rustPanicDetails || strings.HasPrefix(details, rustPanicDetails+": ")
 }
 
 func (p *parser) parseAsLibfuzzerFinding(line string) *report.Finding {
@@ -540,12 +598,26 @@ func (p *parser) sendPendingFinding(ctx context.Context) error {
 	}
 	p.pendingFinding = nil
 	p.numMetricsLinesSinceFindingIsPending = 0
+	p.pendingRustPanicMessage = false
 	return nil
 }
 
 func (p *parser) sendFinding(ctx context.Context, finding *report.Finding) error {
 	p.FindingReported = true
 
+	if finding.StackTrace == nil {
+		finding.StackTrace = p.parseStackTrace(finding)
+	}
+	if finding.Signature == "" {
+		finding.Signature = sanitizer.CrashSignature(finding.Details, finding.StackTrace)
+	}
+	if finding.MoreDetails == nil {
+		finding.MoreDetails = sanitizer.ParseErrorDetails(finding.Logs)
+	}
+	if finding.ShortDescription == "" {
+		finding.ShortDescription = sanitizer.ShortDescription(finding)
+	}
+
 	return p.sendReport(ctx, &report.Report{
 		Status:  report.RunStatus_RUNNING,
 		Finding: finding,
@@ -350,6 +350,40 @@ error info 2`,
 				},
 			},
 		},
+		{
+			name: "TSan Bugs",
+			logs: `
+INFO: A corpus is not provided, starting from an empty corpus
+==================
+WARNING: ThreadSanitizer: data race (pid=12345)
+  Write of size 4 at 0x7b0400000010 by thread T1:
+    #0 racy_write /src/race.c:5:3 (race_fuzz_test+0x4f5b4b)`,
+			expected: []*report.Report{
+				{Status: report.RunStatus_INITIALIZING},
+				{
+					Status: report.RunStatus_RUNNING,
+					Finding: &report.Finding{
+						Type:    report.ErrorType_CRASH,
+						Details: "data race",
+						Logs: []string{
+							"WARNING: ThreadSanitizer: data race (pid=12345)",
+							"  Write of size 4 at 0x7b0400000010 by thread T1:",
+							"    #0 racy_write /src/race.c:5:3 (race_fuzz_test+0x4f5b4b)",
+						},
+						StackTrace: []*report.StackFrame{
+							{
+								Address:  0x4f5b4b,
+								Function: "racy_write",
+								File:     "/src/race.c",
+								Line:     5,
+								Column:   3,
+								Module:   "race_fuzz_test",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "java libfuzzer driver crash",
 			logs: `
@@ -374,6 +408,10 @@ Base64: ZGVhZGJlZWY=`,
 						Details:   "java.lang.ArrayIndexOutOfBoundsException: Index 22 out of bounds for length 8",
 						InputData: testInput,
 						InputFile: testInputFile.Name(),
+						StackTrace: []*report.StackFrame{
+							{Function: "com.example.parser.Parser.parseBytes", File: "Parser.java", Line: 11},
+							{Function: "fuzz_targets.FuzzParser.fuzzerTestOneInput", File: "FuzzParser.java", Line: 23},
+						},
 						Logs: []string{
 							"== Java Exception: java.lang.ArrayIndexOutOfBoundsException: Index 22 out of bounds for length 8",
 							"\tat com.example.parser.Parser.parseBytes(Parser.java:11)",
@@ -412,6 +450,9 @@ Base64: UVFcb1w8L1xzY3JpcHQt`,
 						Details:   "Security Issue: Output contains </script",
 						InputData: testInput,
 						InputFile: testInputFile.Name(),
+						StackTrace: []*report.StackFrame{
+							{Function: "com.example.JsonSanitizerXSSFuzzer.fuzzerTestOneInput", File: "JsonSanitizerXSSFuzzer.java", Line: 44},
+						},
 						Logs: []string{
 							"== Java Exception: com.code_intelligence.jazzer.api.FuzzerSecurityIssueHigh: Output contains </script",
 							"at com.example.JsonSanitizerXSSFuzzer.fuzzerTestOneInput(JsonSanitizerXSSFuzzer.java:44)",
@@ -480,6 +521,15 @@ Base64: CiMKIQoDZm9vEhoaGGJeAABkZWFkYmVlZjEyMzQ1Njc4OVfHng==`,
 						Details:   "deadly signal",
 						InputData: testInput,
 						InputFile: testInputFile.Name(),
+						StackTrace: []*report.StackFrame{
+							{
+								Address:  0x4be181,
+								Function: "__sanitizer_print_stack_trace",
+								File:     "/llvmbuild/llvm-project-llvmorg-10.0.0/compiler-rt/lib/asan/asan_stack.cpp",
+								Line:     86,
+								Column:   3,
+							},
+						},
 						Logs: []string{
 							"==16== ERROR: libFuzzer: deadly signal",
 							"    #0 0x4be181 in __sanitizer_print_stack_trace /llvmbuild/llvm-project-llvmorg-10.0.0/compiler-rt/lib/asan/asan_stack.cpp:86:3",
@@ -528,6 +578,15 @@ Base64: J3JycnJiYXJycnJycnJycmZvb3IAcgAAAXJyAAAAAHJycnJycnJycnJycnJycnJycnJycnJy
 						Details:   "deadly signal",
 						InputData: testInput,
 						InputFile: testInputFile.Name(),
+						StackTrace: []*report.StackFrame{
+							{
+								Address:  0x4a0021,
+								Function: "__sanitizer_print_stack_trace",
+								File:     "/llvmbuild/llvm-project-llvmorg-10.0.0/compiler-rt/lib/asan/asan_stack.cpp",
+								Line:     86,
+								Column:   3,
+							},
+						},
 						Logs: []string{
 							"==16== ERROR: libFuzzer: deadly signal",
 							"    #0 0x4a0021 in __sanitizer_print_stack_trace /llvmbuild/llvm-project-llvmorg-10.0.0/compiler-rt/lib/asan/asan_stack.cpp:86:3",
@@ -650,6 +709,58 @@ SUMMARY: libFuzzer: timeout`,
 				},
 			},
 		},
+		{
+			name: "Rust panic",
+			logs: `
+thread '<unnamed>' panicked at 'index out of bounds: the len is 3 but the index is 5', src/lib.rs:10:5
+note: run with ` + "`RUST_BACKTRACE=1`" + ` environment variable to display a backtrace
+==42== ERROR: libFuzzer: deadly signal
+    #0 0x55f3c0 in __sanitizer_print_stack_trace
+    #1 0x55f3c1 in parser::parse /src/parser/src/lib.rs:10:5
+SUMMARY: libFuzzer: deadly signal`,
+			expected: []*report.Report{
+				{
+					Status: report.RunStatus_RUNNING,
+					Finding: &report.Finding{
+						Type:    report.ErrorType_CRASH,
+						Details: "Rust Panic: index out of bounds: the len is 3 but the index is 5",
+						Logs: []string{
+							"thread '<unnamed>' panicked at 'index out of bounds: the len is 3 but the index is 5', src/lib.rs:10:5",
+							"note: run with `RUST_BACKTRACE=1` environment variable to display a backtrace",
+							"==42== ERROR: libFuzzer: deadly signal",
+							"    #0 0x55f3c0 in __sanitizer_print_stack_trace",
+							"    #1 0x55f3c1 in parser::parse /src/parser/src/lib.rs:10:5",
+							"SUMMARY: libFuzzer: deadly signal",
+						},
+						StackTrace: []*report.StackFrame{
+							{Address: 0x55f3c0, Function: "__sanitizer_print_stack_trace"},
+							{Address: 0x55f3c1, Function: "parser::parse", File: "/src/parser/src/lib.rs", Line: 10, Column: 5},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Rust panic with message on the next line",
+			logs: `
+thread '<unnamed>' panicked at src/lib.rs:10:5:
+attempt to add with overflow
+==42== ERROR: libFuzzer: deadly signal`,
+			expected: []*report.Report{
+				{
+					Status: report.RunStatus_RUNNING,
+					Finding: &report.Finding{
+						Type:    report.ErrorType_CRASH,
+						Details: "Rust Panic: attempt to add with overflow",
+						Logs: []string{
+							"thread '<unnamed>' panicked at src/lib.rs:10:5:",
+							"attempt to add with overflow",
+							"==42== ERROR: libFuzzer: deadly signal",
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -681,6 +792,10 @@ SUMMARY: libFuzzer: timeout`,
 					removeTimestamps(report)
 					if report.GetFinding() != nil {
 						report.Finding.MoreDetails = nil
+						// The signature and the short description are
+						// tested in the sanitizer package
+						report.Finding.Signature = ""
+						report.Finding.ShortDescription = ""
 					}
 					require.Equal(t, tt.expected[i], report)
 					i += 1
@@ -719,7 +834,13 @@ func TestBufferOverflowCrashLogs(t *testing.T) {
 			"error info 1",
 			"artifact_prefix='./'; Test unit written to " + expectedCrashFile.Name(),
 			"Base64: Aio=",
-		})
+		},
+		&report.ErrorDetails{
+			Id:       "global-buffer-overflow",
+			Name:     "Global buffer overflow",
+			Severity: &report.Severity{Description: "Medium", Score: 5.5},
+		},
+		"global-buffer-overflow")
 }
 
 func TestOOMCrashLogs(t *testing.T) {
@@ -740,19 +861,27 @@ func TestOOMCrashLogs(t *testing.T) {
 			"error info 1",
 			"artifact_prefix='./'; Test unit written to " + expectedCrashFile.Name(),
 			"Base64: Aio=",
-		})
+		},
+		&report.ErrorDetails{
+			Id:       "out-of-memory",
+			Name:     "Out of memory",
+			Severity: &report.Severity{Description: "Low", Score: 3.0},
+		},
+		"out-of-memory")
 }
 
-func assertCorrectCrashesParsing(t *testing.T, errorDetails, crashFile string, crashingInput []byte, logs []string) {
+func assertCorrectCrashesParsing(t *testing.T, errorDetails, crashFile string, crashingInput []byte, logs []string, moreDetails *report.ErrorDetails, shortDescription string) {
 	expectedReports := []*report.Report{
 		{
 			Status: report.RunStatus_RUNNING,
 			Finding: &report.Finding{
-				Type:      report.ErrorType_CRASH,
-				InputData: crashingInput,
-				InputFile: crashFile,
-				Details:   errorDetails,
-				Logs:      logs,
+				Type:             report.ErrorType_CRASH,
+				InputData:        crashingInput,
+				InputFile:        crashFile,
+				Details:          errorDetails,
+				Logs:             logs,
+				MoreDetails:      moreDetails,
+				ShortDescription: shortDescription,
 			},
 		},
 	}
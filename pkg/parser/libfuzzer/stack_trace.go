@@ -0,0 +1,122 @@
+package libfuzzer_output_parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/parser/sanitizer"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/regexutil"
+)
+
+var (
+	// Matches the function line of a frame in a Go stack trace, e.g.
+	//     example.com/http.ParseHeader({0xc000014098, 0x4, 0x8})
+	goStackFrameFunctionPattern = regexp.MustCompile(`^(created by )?(?P<function>[^\s(]+(\(\*?[^\s()]+\)\.[^\s(]+)?)(\(.*\))?$`)
+	// Matches the location line of a frame in a Go stack trace, e.g.
+	//     /src/http/header.go:120 +0x1f4
+	goStackFrameLocationPattern = regexp.MustCompile(`^\t(?P<file>\S+):(?P<line>\d+)(\s+\+0x[0-9a-fA-F]+)?$`)
+
+	// Matches a frame of a Java stack trace, e.g.
+	//     at com.example.Parser.parse(Parser.java:42)
+	//     at java.base/java.lang.String.charAt(String.java:693)
+	javaStackFramePattern = regexp.MustCompile(
+		`^\s*at ((?P<module>[^\s/]+)/)?(?P<function>[^\s(/]+)\((?P<file>[^:)]+)(:(?P<line>\d+))?\)`)
+)
+
+// parseStackTrace fills in the stack trace of the finding from its logs,
+// using the format which belongs to the kind of finding.
+func (p *parser) parseStackTrace(finding *report.Finding) []*report.StackFrame {
+	if finding.Details == goPanicDetails {
+		// The logs of Go panics also contain the stack trace printed by
+		// libFuzzer, which only contains frames of the Go runtime.
+		return ParseGoStackTrace(finding.Logs)
+	}
+
+	frames := sanitizer.ParseStackTrace(finding.Logs)
+	if len(frames) == 0 && p.SupportJazzer {
+		frames = parseJavaStackTrace(finding.Logs)
+	}
+	return frames
+}
+
+// ParseGoStackTrace returns the frames of the stack trace of the
+// goroutine which panicked.
+func ParseGoStackTrace(logs []string) []*report.StackFrame {
+	var frames []*report.StackFrame
+	var inGoroutine bool
+	var pendingFunction string
+	for _, line := range logs {
+		if strings.HasPrefix(line, "goroutine ") {
+			if inGoroutine {
+				// Only the first goroutine is the one which panicked
+				break
+			}
+			inGoroutine = true
+			continue
+		}
+		if !inGoroutine {
+			continue
+		}
+
+		if pendingFunction != "" {
+			result, found := regexutil.FindNamedGroupsMatch(goStackFrameLocationPattern, line)
+			if found {
+				frame := &report.StackFrame{
+					Function: pendingFunction,
+					File:     result["file"],
+				}
+				lineNumber, err := strconv.ParseUint(result["line"], 10, 32)
+				if err == nil {
+					frame.Line = uint32(lineNumber)
+				}
+				frames = append(frames, frame)
+				pendingFunction = ""
+				continue
+			}
+		}
+
+		result, found := regexutil.FindNamedGroupsMatch(goStackFrameFunctionPattern, line)
+		if found {
+			pendingFunction = result["function"]
+			continue
+		}
+
+		if len(frames) > 0 {
+			// The stack trace ended
+			break
+		}
+	}
+	return frames
+}
+
+// parseJavaStackTrace returns the frames of the first stack trace of a
+// Java exception.
+func parseJavaStackTrace(logs []string) []*report.StackFrame {
+	var frames []*report.StackFrame
+	for _, line := range logs {
+		result, found := regexutil.FindNamedGroupsMatch(javaStackFramePattern, line)
+		if !found {
+			if len(frames) > 0 {
+				// The stack trace ended, we don't parse the stack
+				// traces of the causes of the exception.
+				break
+			}
+			continue
+		}
+		frame := &report.StackFrame{
+			Function: result["function"],
+			File:     result["file"],
+			Module:   result["module"],
+		}
+		if result["line"] != "" {
+			lineNumber, err := strconv.ParseUint(result["line"], 10, 32)
+			if err == nil {
+				frame.Line = uint32(lineNumber)
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
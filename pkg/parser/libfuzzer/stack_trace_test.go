@@ -0,0 +1,55 @@
+package libfuzzer_output_parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestParseGoStackTrace(t *testing.T) {
+	logs := []string{
+		"panic: runtime error: index out of range [3] with length 3",
+		"",
+		"goroutine 17 [running, locked to thread]:",
+		"example.com/http.(*Parser).Parse(0xc000014098, {0xc000012345, 0x4, 0x8})",
+		"\t/src/http/parser.go:42 +0x1f4",
+		"example.com/http.FuzzParser({0xc000012345, 0x4, 0x8})",
+		"\t/src/http/fuzz.go:12 +0x65",
+		"main.LLVMFuzzerTestOneInput(...)",
+		"\t./main.2341441.go:35",
+		"",
+		"goroutine 1 [syscall]:",
+		"main.main()",
+		"\t/src/main.go:5 +0x25",
+		"==1234== ERROR: libFuzzer: deadly signal",
+		"    #0 0x4be181 in __sanitizer_print_stack_trace /llvm-project/compiler-rt/lib/asan/asan_stack.cpp:86:3",
+	}
+
+	finding := &report.Finding{Details: "Go Panic", Logs: logs}
+	p := NewLibfuzzerOutputParser(nil)
+	assert.Equal(t, []*report.StackFrame{
+		{Function: "example.com/http.(*Parser).Parse", File: "/src/http/parser.go", Line: 42},
+		{Function: "example.com/http.FuzzParser", File: "/src/http/fuzz.go", Line: 12},
+		{Function: "main.LLVMFuzzerTestOneInput", File: "./main.2341441.go", Line: 35},
+	}, p.parseStackTrace(finding))
+	assert.Equal(t, "crashed in example.com/http.(*Parser).Parse() at parser.go:42", (&report.Finding{StackTrace: p.parseStackTrace(finding)}).CrashSummary())
+}
+
+func TestParseJavaStackTrace(t *testing.T) {
+	logs := []string{
+		"== Java Exception: java.lang.StringIndexOutOfBoundsException: index 8, length 8",
+		"\tat java.base/java.lang.String.charAt(String.java:693)",
+		"\tat com.example.Parser.parse(Parser.java:42)",
+		"\tat com.example.ParserFuzzer.fuzzerTestOneInput(ParserFuzzer.java:12)",
+		"Caused by: java.lang.IllegalStateException",
+		"\tat com.example.Other.method(Other.java:1)",
+	}
+
+	assert.Equal(t, []*report.StackFrame{
+		{Module: "java.base", Function: "java.lang.String.charAt", File: "String.java", Line: 693},
+		{Function: "com.example.Parser.parse", File: "Parser.java", Line: 42},
+		{Function: "com.example.ParserFuzzer.fuzzerTestOneInput", File: "ParserFuzzer.java", Line: 12},
+	}, parseJavaStackTrace(logs))
+}
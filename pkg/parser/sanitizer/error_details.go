@@ -0,0 +1,220 @@
+package sanitizer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/regexutil"
+)
+
+// The IDs of the error details of findings which are reported by the
+// sanitizers
+const (
+	MemoryLeakID         = "memory-leak"
+	DataRaceID           = "data-race"
+	UninitializedValueID = "use-of-uninitialized-value"
+)
+
+var (
+	leakReportPattern = regexp.MustCompile(`ERROR: LeakSanitizer: detected memory leaks`)
+	// Matches the first line of a leak in a LeakSanitizer report, e.g.
+	//     Direct leak of 32 byte(s) in 1 object(s) allocated from:
+	leakPattern = regexp.MustCompile(
+		`^(?P<kind>Direct|Indirect) leak of (?P<bytes>\d+) byte\(s\) in (?P<objects>\d+) object\(s\) allocated from:`,
+	)
+
+	dataRaceReportPattern = regexp.MustCompile(`WARNING: ThreadSanitizer: data race`)
+	// Matches the first line of a memory access in a ThreadSanitizer
+	// data race report, e.g.
+	//     Write of size 4 at 0x7b0400000010 by thread T1:
+	//     Previous atomic read of size 8 at 0x7b0400000010 by main thread (mutexes: write M1):
+	memoryAccessPattern = regexp.MustCompile(
+		`^\s*(?P<previous>Previous )?((?P<atomic>[Aa]tomic) )?(?P<kind>[Rr]ead|[Ww]rite) of size (?P<size>\d+)` +
+			` at 0x(?P<address>[0-9a-fA-F]+) by (?P<thread>main thread|thread T\d+)`,
+	)
+
+	uninitializedValueReportPattern = regexp.MustCompile(`WARNING: MemorySanitizer: use-of-uninitialized-value`)
+	// Matches the line which precedes the stack trace of the origin of
+	// an uninitialized value if MSan was built with origin tracking, e.g.
+	//     Uninitialized value was created by a heap allocation
+	//     Uninitialized value was created by an allocation of 'buf' in the stack frame of function 'parse'
+	originPattern = regexp.MustCompile(`^\s*Uninitialized value was created by (?P<origin>.+?)\s*$`)
+)
+
+// UninitializedValue is a use of an uninitialized value reported by
+// MemorySanitizer
+type UninitializedValue struct {
+	// The stack trace of the use of the uninitialized value
+	StackTrace []*report.StackFrame
+	// Nil if the fuzz test was built without origin tracking
+	Origin *report.Origin
+}
+
+// ParseErrorDetails returns the error details of the finding with the
+// given logs if they contain a sanitizer or libFuzzer report of a
+// known kind, else nil. The error details classify the kind of the bug
+// and include its severity.
+func ParseErrorDetails(logs []string) *report.ErrorDetails {
+	for _, line := range logs {
+		switch {
+		case leakReportPattern.MatchString(line):
+			return leakErrorDetails(ParseLeaks(logs))
+		case dataRaceReportPattern.MatchString(line):
+			return dataRaceErrorDetails(ParseDataRace(logs))
+		case uninitializedValueReportPattern.MatchString(line):
+			return uninitializedValueErrorDetails(ParseUninitializedValue(logs))
+		case libfuzzerOutOfMemoryPattern.MatchString(line):
+			return bugKindErrorDetails(OutOfMemoryID)
+		case libfuzzerTimeoutPattern.MatchString(line):
+			return bugKindErrorDetails(TimeoutID)
+		}
+		if result, found := regexutil.FindNamedGroupsMatch(crashReportPattern, line); found {
+			return crashErrorDetails(result["description"], logs)
+		}
+		if result, found := regexutil.FindNamedGroupsMatch(undefinedBehaviorReportPattern, line); found {
+			return undefinedBehaviorErrorDetails(result["description"])
+		}
+	}
+	return nil
+}
+
+// ParseLeaks returns the leaks of the LeakSanitizer report in the logs
+func ParseLeaks(logs []string) []*report.Leak {
+	var leaks []*report.Leak
+	var leak *report.Leak
+	for _, line := range logs {
+		if result, found := regexutil.FindNamedGroupsMatch(leakPattern, line); found {
+			leak = &report.Leak{Direct: result["kind"] == "Direct"}
+			leak.Bytes, _ = strconv.ParseUint(result["bytes"], 10, 64)
+			leak.Objects, _ = strconv.ParseUint(result["objects"], 10, 64)
+			leaks = append(leaks, leak)
+			continue
+		}
+		if leak == nil {
+			continue
+		}
+		frame, _, ok := parseStackFrame(line)
+		if !ok {
+			// The stack trace of the leak ended
+			leak = nil
+			continue
+		}
+		leak.StackTrace = append(leak.StackTrace, frame)
+	}
+	return leaks
+}
+
+// ParseDataRace returns the conflicting memory accesses of the
+// ThreadSanitizer data race report in the logs. The first one is the
+// access which triggered the report, the second one the previous
+// access.
+func ParseDataRace(logs []string) []*report.MemoryAccess {
+	var accesses []*report.MemoryAccess
+	var access *report.MemoryAccess
+	for _, line := range logs {
+		if result, found := regexutil.FindNamedGroupsMatch(memoryAccessPattern, line); found {
+			if len(accesses) == 2 {
+				// Only the first report is parsed
+				break
+			}
+			access = &report.MemoryAccess{
+				Write:  strings.EqualFold(result["kind"], "write"),
+				Atomic: result["atomic"] != "",
+				Thread: result["thread"],
+			}
+			access.Size, _ = strconv.ParseUint(result["size"], 10, 64)
+			access.Address, _ = strconv.ParseUint(result["address"], 16, 64)
+			accesses = append(accesses, access)
+			continue
+		}
+		if access == nil {
+			continue
+		}
+		frame, _, ok := parseStackFrame(line)
+		if !ok {
+			access = nil
+			continue
+		}
+		access.StackTrace = append(access.StackTrace, frame)
+	}
+	return accesses
+}
+
+// ParseUninitializedValue returns the use of an uninitialized value
+// reported by MemorySanitizer in the logs, including its origin if the
+// fuzz test was built with origin tracking.
+func ParseUninitializedValue(logs []string) *UninitializedValue {
+	value := &UninitializedValue{StackTrace: ParseStackTrace(logs)}
+	for _, line := range logs {
+		if result, found := regexutil.FindNamedGroupsMatch(originPattern, line); found {
+			value.Origin = &report.Origin{Description: result["origin"]}
+			continue
+		}
+		if value.Origin == nil {
+			continue
+		}
+		frame, _, ok := parseStackFrame(line)
+		if !ok {
+			if len(value.Origin.StackTrace) > 0 {
+				break
+			}
+			continue
+		}
+		value.Origin.StackTrace = append(value.Origin.StackTrace, frame)
+	}
+	return value
+}
+
+func leakErrorDetails(leaks []*report.Leak) *report.ErrorDetails {
+	name := "Memory leak"
+	var bytes, objects uint64
+	for _, leak := range leaks {
+		bytes += leak.Bytes
+		objects += leak.Objects
+	}
+	if len(leaks) > 0 {
+		name += fmt.Sprintf(" of %d byte(s) in %d object(s)", bytes, objects)
+	}
+	return &report.ErrorDetails{
+		Id:   MemoryLeakID,
+		Name: name,
+		// Leaks can only be exploited to exhaust the memory of a
+		// process
+		Severity: severity(3.0),
+		Leaks:    leaks,
+	}
+}
+
+func dataRaceErrorDetails(accesses []*report.MemoryAccess) *report.ErrorDetails {
+	name := "Data race"
+	if len(accesses) == 2 {
+		name += fmt.Sprintf(": %s by %s conflicts with previous %s by %s",
+			accesses[0].Description(), accesses[0].Thread, accesses[1].Description(), accesses[1].Thread)
+	}
+	return &report.ErrorDetails{
+		Id:   DataRaceID,
+		Name: name,
+		// The consequences of data races range from wrong results to
+		// memory corruption, but they are hard to trigger reliably
+		Severity: severity(5.0),
+		Accesses: accesses,
+	}
+}
+
+func uninitializedValueErrorDetails(value *UninitializedValue) *report.ErrorDetails {
+	name := "Use of uninitialized value"
+	if value.Origin != nil {
+		name += " created by " + value.Origin.Description
+	}
+	return &report.ErrorDetails{
+		Id:   UninitializedValueID,
+		Name: name,
+		// Uninitialized memory can leak sensitive data and cause
+		// control flow which depends on attacker-controlled values
+		Severity: severity(6.0),
+		Origin:   value.Origin,
+	}
+}
@@ -0,0 +1,259 @@
+package sanitizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+const leakSanitizerLogs = `
+=================================================================
+==1786==ERROR: LeakSanitizer: detected memory leaks
+
+Direct leak of 32 byte(s) in 1 object(s) allocated from:
+    #0 0x4c2a1d in malloc /llvm-project/compiler-rt/lib/asan/asan_malloc_linux.cpp:69:3
+    #1 0x4f5b4b in parse_header /src/http.c:110:15
+    #2 0x4f5d3e in LLVMFuzzerTestOneInput /src/fuzz_test.cpp:12:3
+
+Indirect leak of 16 byte(s) in 2 object(s) allocated from:
+    #0 0x4c2a1d in malloc /llvm-project/compiler-rt/lib/asan/asan_malloc_linux.cpp:69:3
+    #1 0x4f5c2d in parse_field /src/http.c:42:10
+
+SUMMARY: AddressSanitizer: 48 byte(s) leaked in 3 allocation(s).
+INFO: to ignore leaks on libFuzzer side use -detect_leaks=0.`
+
+const threadSanitizerLogs = `
+==================
+WARNING: ThreadSanitizer: data race (pid=9337)
+  Write of size 4 at 0x7b0400000010 by thread T1:
+    #0 worker /src/race.c:5:10 (race_fuzz_test+0x4f5b4b)
+    #1 <null> <null> (libc.so.6+0x94ac2)
+
+  Previous read of size 4 at 0x7b0400000010 by main thread:
+    #0 LLVMFuzzerTestOneInput /src/race.c:12:3 (race_fuzz_test+0x4f5c2d)
+    #1 fuzzer::Fuzzer::ExecuteCallback(unsigned char const*, unsigned long) <null> (race_fuzz_test+0x41d5ad)
+
+  Location is heap block of size 4 at 0x7b0400000010 allocated by main thread:
+    #0 malloc /llvm-project/compiler-rt/lib/tsan/rtl/tsan_interceptors_posix.cpp:667:5 (race_fuzz_test+0x44f1c2)
+    #1 LLVMFuzzerTestOneInput /src/race.c:10:15 (race_fuzz_test+0x4f5c0a)
+
+  Thread T1 (tid=9338, running) created by main thread at:
+    #0 pthread_create /llvm-project/compiler-rt/lib/tsan/rtl/tsan_interceptors_posix.cpp:1022:3 (race_fuzz_test+0x44f7ed)
+    #1 LLVMFuzzerTestOneInput /src/race.c:11:3 (race_fuzz_test+0x4f5c1b)
+
+SUMMARY: ThreadSanitizer: data race /src/race.c:5:10 in worker
+==================`
+
+const memorySanitizerLogs = `
+==2248837==WARNING: MemorySanitizer: use-of-uninitialized-value
+    #0 0x4a0f63 in parse_header /src/http.c:120:7
+    #1 0x4a1024 in LLVMFuzzerTestOneInput /src/fuzz_test.cpp:12:3
+
+  Uninitialized value was stored to memory at
+    #0 0x4a0e21 in copy_header /src/http.c:80:12
+
+  Uninitialized value was created by a heap allocation
+    #0 0x4325bd in malloc /llvm-project/compiler-rt/lib/msan/msan_interceptors.cpp:937:3
+    #1 0x4a0d9a in new_header /src/http.c:60:22
+
+SUMMARY: MemorySanitizer: use-of-uninitialized-value /src/http.c:120:7 in parse_header
+Exiting`
+
+func TestParseErrorDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		logs     string
+		expected *report.ErrorDetails
+	}{
+		{
+			name: "LeakSanitizer",
+			logs: leakSanitizerLogs,
+			expected: &report.ErrorDetails{
+				Id:       MemoryLeakID,
+				Name:     "Memory leak of 48 byte(s) in 3 object(s)",
+				Severity: &report.Severity{Description: "Low", Score: 3.0},
+				Leaks:    ParseLeaks(splitLogs(leakSanitizerLogs)),
+			},
+		},
+		{
+			name: "standalone LeakSanitizer",
+			logs: `
+==42==ERROR: LeakSanitizer: detected memory leaks
+
+Direct leak of 7 byte(s) in 1 object(s) allocated from:
+    #0 0x41b3e8 in malloc /llvm-project/compiler-rt/lib/lsan/lsan_interceptors.cpp:56:3
+    #1 0x42b1e6 in strdup_input /src/fuzz_test.c:8:14
+
+SUMMARY: LeakSanitizer: 7 byte(s) leaked in 1 allocation(s).`,
+			expected: &report.ErrorDetails{
+				Id:       MemoryLeakID,
+				Name:     "Memory leak of 7 byte(s) in 1 object(s)",
+				Severity: &report.Severity{Description: "Low", Score: 3.0},
+				Leaks: []*report.Leak{{
+					Direct:  true,
+					Bytes:   7,
+					Objects: 1,
+					StackTrace: []*report.StackFrame{
+						{Address: 0x41b3e8, Function: "malloc", File: "/llvm-project/compiler-rt/lib/lsan/lsan_interceptors.cpp", Line: 56, Column: 3},
+						{Address: 0x42b1e6, Function: "strdup_input", File: "/src/fuzz_test.c", Line: 8, Column: 14},
+					},
+				}},
+			},
+		},
+		{
+			name: "ThreadSanitizer data race",
+			logs: threadSanitizerLogs,
+			expected: &report.ErrorDetails{
+				Id:       DataRaceID,
+				Name:     "Data race: write of size 4 by thread T1 conflicts with previous read of size 4 by main thread",
+				Severity: &report.Severity{Description: "Medium", Score: 5.0},
+				Accesses: ParseDataRace(splitLogs(threadSanitizerLogs)),
+			},
+		},
+		{
+			name: "ThreadSanitizer data race with atomic access",
+			logs: `
+WARNING: ThreadSanitizer: data race (pid=17)
+  Atomic write of size 8 at 0x7b0800000020 by main thread (mutexes: write M9):
+    #0 __tsan_atomic64_store /llvm-project/compiler-rt/lib/tsan/rtl/tsan_interface_atomic.cpp:633:3 (fuzz_test+0x4a21b3)
+    #1 set_state /src/state.cpp:20:3 (fuzz_test+0x4f1b2c)
+
+  Previous read of size 8 at 0x7b0800000020 by thread T2:
+    #0 get_state /src/state.cpp:25:10 (fuzz_test+0x4f1c0d)`,
+			expected: &report.ErrorDetails{
+				Id:       DataRaceID,
+				Name:     "Data race: atomic write of size 8 by main thread conflicts with previous read of size 8 by thread T2",
+				Severity: &report.Severity{Description: "Medium", Score: 5.0},
+				Accesses: []*report.MemoryAccess{
+					{
+						Write:   true,
+						Atomic:  true,
+						Size:    8,
+						Address: 0x7b0800000020,
+						Thread:  "main thread",
+						StackTrace: []*report.StackFrame{
+							{Address: 0x4a21b3, Function: "__tsan_atomic64_store", File: "/llvm-project/compiler-rt/lib/tsan/rtl/tsan_interface_atomic.cpp", Line: 633, Column: 3, Module: "fuzz_test"},
+							{Address: 0x4f1b2c, Function: "set_state", File: "/src/state.cpp", Line: 20, Column: 3, Module: "fuzz_test"},
+						},
+					},
+					{
+						Size:    8,
+						Address: 0x7b0800000020,
+						Thread:  "thread T2",
+						StackTrace: []*report.StackFrame{
+							{Address: 0x4f1c0d, Function: "get_state", File: "/src/state.cpp", Line: 25, Column: 10, Module: "fuzz_test"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "MemorySanitizer with origin tracking",
+			logs: memorySanitizerLogs,
+			expected: &report.ErrorDetails{
+				Id:       UninitializedValueID,
+				Name:     "Use of uninitialized value created by a heap allocation",
+				Severity: &report.Severity{Description: "Medium", Score: 6.0},
+				Origin:   ParseUninitializedValue(splitLogs(memorySanitizerLogs)).Origin,
+			},
+		},
+		{
+			name: "MemorySanitizer without origin tracking",
+			logs: `
+==2248837==WARNING: MemorySanitizer: use-of-uninitialized-value
+    #0 0x4a0f63 in parse_header /src/http.c:120:7
+
+SUMMARY: MemorySanitizer: use-of-uninitialized-value /src/http.c:120:7 in parse_header`,
+			expected: &report.ErrorDetails{
+				Id:       UninitializedValueID,
+				Name:     "Use of uninitialized value",
+				Severity: &report.Severity{Description: "Medium", Score: 6.0},
+			},
+		},
+		{
+			name: "unknown report",
+			logs: `
+==16==ERROR: libFuzzer: deadly signal
+    #0 0x4be181 in __sanitizer_print_stack_trace /llvm-project/compiler-rt/lib/asan/asan_stack.cpp:86:3`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseErrorDetails(splitLogs(tt.logs)))
+		})
+	}
+}
+
+func splitLogs(logs string) []string {
+	return strings.Split(strings.TrimPrefix(logs, "\n"), "\n")
+}
+
+func TestParseLeaks(t *testing.T) {
+	leaks := ParseLeaks(strings.Split(leakSanitizerLogs, "\n"))
+	require.Len(t, leaks, 2)
+
+	assert.True(t, leaks[0].Direct)
+	assert.Equal(t, uint64(32), leaks[0].Bytes)
+	assert.Equal(t, uint64(1), leaks[0].Objects)
+	require.Len(t, leaks[0].StackTrace, 3)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x4f5b4b,
+		Function: "parse_header",
+		File:     "/src/http.c",
+		Line:     110,
+		Column:   15,
+	}, leaks[0].StackTrace[1])
+
+	assert.False(t, leaks[1].Direct)
+	assert.Equal(t, uint64(16), leaks[1].Bytes)
+	assert.Equal(t, uint64(2), leaks[1].Objects)
+	require.Len(t, leaks[1].StackTrace, 2)
+	assert.Equal(t, "parse_field", leaks[1].StackTrace[1].Function)
+}
+
+func TestParseDataRace(t *testing.T) {
+	accesses := ParseDataRace(strings.Split(threadSanitizerLogs, "\n"))
+	require.Len(t, accesses, 2)
+
+	assert.True(t, accesses[0].Write)
+	assert.False(t, accesses[0].Atomic)
+	assert.Equal(t, uint64(4), accesses[0].Size)
+	assert.Equal(t, uint64(0x7b0400000010), accesses[0].Address)
+	assert.Equal(t, "thread T1", accesses[0].Thread)
+	require.Len(t, accesses[0].StackTrace, 2)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x4f5b4b,
+		Function: "worker",
+		File:     "/src/race.c",
+		Line:     5,
+		Column:   10,
+		Module:   "race_fuzz_test",
+	}, accesses[0].StackTrace[0])
+
+	assert.False(t, accesses[1].Write)
+	assert.Equal(t, "main thread", accesses[1].Thread)
+	require.Len(t, accesses[1].StackTrace, 2)
+	assert.Equal(t, "LLVMFuzzerTestOneInput", accesses[1].StackTrace[0].Function)
+}
+
+func TestParseUninitializedValue(t *testing.T) {
+	value := ParseUninitializedValue(strings.Split(memorySanitizerLogs, "\n"))
+	require.Len(t, value.StackTrace, 2)
+	assert.Equal(t, "parse_header", value.StackTrace[0].Function)
+	require.NotNil(t, value.Origin)
+	assert.Equal(t, "a heap allocation", value.Origin.Description)
+	require.Len(t, value.Origin.StackTrace, 2)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x4a0d9a,
+		Function: "new_header",
+		File:     "/src/http.c",
+		Line:     60,
+		Column:   22,
+	}, value.Origin.StackTrace[1])
+}
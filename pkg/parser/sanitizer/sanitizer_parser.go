@@ -11,6 +11,12 @@ var (
 	errorPattern = regexp.MustCompile(
 		`==\d+==\s*(ERROR|WARNING):.*Sanitizer:\s(?P<error_type>.+)`,
 	)
+	// ThreadSanitizer reports don't start with the PID like the reports
+	// of the other sanitizers, but contain it at the end of the line:
+	//     WARNING: ThreadSanitizer: data race (pid=12345)
+	threadSanitizerPattern = regexp.MustCompile(
+		`^WARNING: ThreadSanitizer: (?P<error_type>.+?)(\s+\(pid=\d+\))?$`,
+	)
 	runtimeErrorStartPattern = regexp.MustCompile(
 		`\S+ runtime error: (?P<error_type>[^:]+)`,
 	)
@@ -27,6 +33,11 @@ func ParseAsFinding(line string) *report.Finding {
 		return finding
 	}
 
+	finding = parseAsThreadSanitizerReport(line)
+	if finding != nil {
+		return finding
+	}
+
 	return nil
 }
 
@@ -43,6 +54,18 @@ func parseAsErrorReport(log string) *report.Finding {
 	return nil
 }
 
+func parseAsThreadSanitizerReport(log string) *report.Finding {
+	result, found := regexutil.FindNamedGroupsMatch(threadSanitizerPattern, log)
+	if !found {
+		return nil
+	}
+	return &report.Finding{
+		Type:    report.ErrorType_CRASH,
+		Details: result["error_type"],
+		Logs:    []string{log},
+	}
+}
+
 func parseAsRuntimeReport(log string) *report.Finding {
 	result, found := regexutil.FindNamedGroupsMatch(runtimeErrorStartPattern, log)
 	if !found {
@@ -0,0 +1,300 @@
+package sanitizer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/regexutil"
+)
+
+// The IDs of the error details of findings which are classified by
+// the kind of the bug
+const (
+	DoubleFreeID              = "double-free"
+	BadFreeID                 = "bad-free"
+	AllocDeallocMismatchID    = "alloc-dealloc-mismatch"
+	NewDeleteTypeMismatchID   = "new-delete-type-mismatch"
+	MemcpyParamOverlapID      = "memcpy-param-overlap"
+	NegativeSizeParamID       = "negative-size-param"
+	CallocOverflowID          = "calloc-overflow"
+	AllocationSizeTooBigID    = "allocation-size-too-big"
+	OutOfMemoryID             = "out-of-memory"
+	TimeoutID                 = "timeout"
+	StackOverflowID           = "stack-overflow"
+	NullDereferenceID         = "null-dereference"
+	SegmentationFaultID       = "segmentation-fault"
+	DivisionByZeroID          = "division-by-zero"
+	SignedIntegerOverflowID   = "signed-integer-overflow"
+	UnsignedIntegerOverflowID = "unsigned-integer-overflow"
+	IndexOutOfBoundsID        = "index-out-of-bounds"
+	InvalidShiftID            = "invalid-shift"
+	InvalidValueID            = "invalid-value"
+	MisalignedAccessID        = "misaligned-access"
+)
+
+// Accesses of more than this number of bytes are considered "wide",
+// which increases the severity of invalid memory accesses: Wide reads
+// can leak a lot of memory and wide writes give an attacker a lot of
+// control over the corrupted memory.
+const maxNarrowAccessSize = 8
+
+var (
+	// Matches the first line of all sanitizer error reports, e.g.
+	//     ==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc ...
+	crashReportPattern = regexp.MustCompile(`==\d+==\s*ERROR: \w*Sanitizer: (?P<description>.+)`)
+	// Matches the line after the first line of an AddressSanitizer
+	// report of an invalid memory access, e.g.
+	//     WRITE of size 4 at 0x602000000011 thread T0
+	addressSanitizerAccessPattern = regexp.MustCompile(
+		`^\s*(?P<kind>READ|WRITE) of size (?P<size>\d+) at 0x(?P<address>[0-9a-fA-F]+) thread (?P<thread>T\d+)`,
+	)
+	// Matches the address of a SEGV report, e.g.
+	//     SEGV on unknown address 0x000000000000 (pc ...
+	segvAddressPattern = regexp.MustCompile(`on unknown address 0x(?P<address>[0-9a-fA-F]+)`)
+	// Matches the line of a SEGV report which specifies the kind of
+	// the memory access, e.g.
+	//     ==1234==The signal is caused by a WRITE memory access.
+	segvAccessPattern   = regexp.MustCompile(`The signal is caused by a (?P<kind>READ|WRITE|UNKNOWN) memory access`)
+	zeroPageHintPattern = regexp.MustCompile(`Hint: address points to the zero page`)
+
+	undefinedBehaviorReportPattern = regexp.MustCompile(`\S+ runtime error: (?P<description>.+)`)
+
+	libfuzzerOutOfMemoryPattern = regexp.MustCompile(`==\d+== ERROR: libFuzzer: out-of-memory`)
+	libfuzzerTimeoutPattern     = regexp.MustCompile(
+		`ALARM: working on the last Unit for \d+ seconds|==\d+== ERROR: libFuzzer: timeout`,
+	)
+)
+
+// invalidAccessKind is a kind of bug that is reported by
+// AddressSanitizer as an invalid read or write of memory
+type invalidAccessKind struct {
+	name string
+	// The scores of narrow reads and writes
+	readScore  float32
+	writeScore float32
+}
+
+var invalidAccessKinds = map[string]*invalidAccessKind{
+	// Accesses out of the bounds of the accessed object
+	"heap-buffer-overflow":          {name: "Heap buffer overflow", readScore: 5.5, writeScore: 8.0},
+	"stack-buffer-overflow":         {name: "Stack buffer overflow", readScore: 5.5, writeScore: 8.0},
+	"stack-buffer-underflow":        {name: "Stack buffer underflow", readScore: 5.5, writeScore: 8.0},
+	"dynamic-stack-buffer-overflow": {name: "Dynamic stack buffer overflow", readScore: 5.5, writeScore: 8.0},
+	"global-buffer-overflow":        {name: "Global buffer overflow", readScore: 5.5, writeScore: 8.0},
+	"container-overflow":            {name: "Container overflow", readScore: 5.5, writeScore: 8.0},
+	"intra-object-overflow":         {name: "Intra object overflow", readScore: 5.5, writeScore: 8.0},
+	// Accesses of memory whose lifetime has ended, which can often be
+	// reallocated with attacker-controlled data
+	"heap-use-after-free":    {name: "Heap use after free", readScore: 7.0, writeScore: 8.5},
+	"stack-use-after-return": {name: "Stack use after return", readScore: 7.0, writeScore: 8.5},
+	"stack-use-after-scope":  {name: "Stack use after scope", readScore: 7.0, writeScore: 8.5},
+	"use-after-poison":       {name: "Use after poison", readScore: 7.0, writeScore: 8.5},
+}
+
+// bugKind is a kind of bug whose severity doesn't depend on the
+// details of the report
+type bugKind struct {
+	name  string
+	score float32
+}
+
+var bugKinds = map[string]*bugKind{
+	DoubleFreeID:            {name: "Double free", score: 8.0},
+	BadFreeID:               {name: "Free of invalid pointer", score: 7.0},
+	AllocDeallocMismatchID:  {name: "Mismatch between allocation and deallocation function", score: 5.0},
+	NewDeleteTypeMismatchID: {name: "Mismatch between sizes of allocated and deleted object", score: 5.0},
+	MemcpyParamOverlapID:    {name: "Overlapping memory ranges passed to memcpy", score: 5.0},
+	NegativeSizeParamID:     {name: "Negative size passed to memory function", score: 6.0},
+	// Bugs which can only be used to crash the process or to exhaust
+	// its resources
+	NullDereferenceID:      {name: "Null pointer dereference", score: 4.0},
+	StackOverflowID:        {name: "Stack overflow", score: 4.0},
+	DivisionByZeroID:       {name: "Division by zero", score: 4.0},
+	CallocOverflowID:       {name: "Overflow of calloc size", score: 3.0},
+	AllocationSizeTooBigID: {name: "Allocation size too big", score: 3.0},
+	OutOfMemoryID:          {name: "Out of memory", score: 3.0},
+	TimeoutID:              {name: "Timeout", score: 3.0},
+	// Undefined behavior which doesn't corrupt memory by itself, but
+	// often causes memory corruption later on
+	IndexOutOfBoundsID:        {name: "Index out of bounds", score: 6.0},
+	SignedIntegerOverflowID:   {name: "Signed integer overflow", score: 3.0},
+	UnsignedIntegerOverflowID: {name: "Unsigned integer overflow", score: 2.0},
+	InvalidShiftID:            {name: "Invalid shift", score: 2.0},
+	InvalidValueID:            {name: "Load of invalid value", score: 2.0},
+	MisalignedAccessID:        {name: "Misaligned memory access", score: 2.0},
+}
+
+// severity returns the severity with the given score and the
+// description of the CVSS v3 rating that corresponds to that score
+func severity(score float32) *report.Severity {
+	var description string
+	switch {
+	case score >= 9.0:
+		description = "Critical"
+	case score >= 7.0:
+		description = "High"
+	case score >= 4.0:
+		description = "Medium"
+	default:
+		description = "Low"
+	}
+	return &report.Severity{Description: description, Score: score}
+}
+
+func bugKindErrorDetails(id string) *report.ErrorDetails {
+	kind, ok := bugKinds[id]
+	if !ok {
+		return nil
+	}
+	return &report.ErrorDetails{
+		Id:       id,
+		Name:     kind.name,
+		Severity: severity(kind.score),
+	}
+}
+
+// crashErrorDetails classifies the sanitizer error report in the logs
+// whose first line has the given description, e.g.
+// "heap-buffer-overflow on address 0x602000000011 at pc ..."
+func crashErrorDetails(description string, logs []string) *report.ErrorDetails {
+	bugType := crashBugType(description)
+
+	if kind, ok := invalidAccessKinds[bugType]; ok {
+		return invalidAccessErrorDetails(bugType, kind, ParseInvalidAccess(logs))
+	}
+
+	switch bugType {
+	case "SEGV":
+		return segvErrorDetails(description, logs)
+	case "FPE":
+		return bugKindErrorDetails(DivisionByZeroID)
+	}
+
+	return bugKindErrorDetails(bugType)
+}
+
+// crashBugType returns the kind of bug of a sanitizer error report
+// with the given description. For most reports, that's the first word
+// of the description.
+func crashBugType(description string) string {
+	switch {
+	case strings.HasPrefix(description, "attempting double-free"):
+		return DoubleFreeID
+	case strings.HasPrefix(description, "attempting free on address which was not malloc()-ed"):
+		return BadFreeID
+	case strings.HasPrefix(description, "requested allocation size"):
+		return AllocationSizeTooBigID
+	case strings.HasPrefix(description, "allocator is out of memory"):
+		return OutOfMemoryID
+	}
+	bugType, _, _ := strings.Cut(description, " ")
+	return strings.TrimSuffix(bugType, ":")
+}
+
+// ParseInvalidAccess returns the invalid memory access of the
+// AddressSanitizer report in the logs or nil if the report doesn't
+// contain one
+func ParseInvalidAccess(logs []string) *report.MemoryAccess {
+	for _, line := range logs {
+		result, found := regexutil.FindNamedGroupsMatch(addressSanitizerAccessPattern, line)
+		if !found {
+			continue
+		}
+		access := &report.MemoryAccess{
+			Write:  result["kind"] == "WRITE",
+			Thread: result["thread"],
+		}
+		access.Size, _ = strconv.ParseUint(result["size"], 10, 64)
+		access.Address, _ = strconv.ParseUint(result["address"], 16, 64)
+		return access
+	}
+	return nil
+}
+
+func invalidAccessErrorDetails(id string, kind *invalidAccessKind, access *report.MemoryAccess) *report.ErrorDetails {
+	name := kind.name
+	score := kind.readScore
+	if access != nil {
+		name += ": " + access.Description()
+		if access.Write {
+			score = kind.writeScore
+		}
+		if access.Size > maxNarrowAccessSize {
+			score += 1.0
+		}
+	}
+	if score > 10.0 {
+		score = 10.0
+	}
+	return &report.ErrorDetails{
+		Id:       id,
+		Name:     name,
+		Severity: severity(score),
+	}
+}
+
+// segvErrorDetails classifies a SEGV report. Accesses of the zero page
+// are null pointer dereferences, all other accesses are treated like
+// out-of-bounds accesses of unknown size.
+func segvErrorDetails(description string, logs []string) *report.ErrorDetails {
+	nullDereference := false
+	if result, found := regexutil.FindNamedGroupsMatch(segvAddressPattern, description); found {
+		address, err := strconv.ParseUint(result["address"], 16, 64)
+		nullDereference = err == nil && address < 0x1000
+	}
+
+	var write bool
+	for _, line := range logs {
+		if zeroPageHintPattern.MatchString(line) {
+			nullDereference = true
+		}
+		if result, found := regexutil.FindNamedGroupsMatch(segvAccessPattern, line); found {
+			write = result["kind"] == "WRITE"
+		}
+	}
+
+	if nullDereference {
+		return bugKindErrorDetails(NullDereferenceID)
+	}
+
+	name := "Segmentation fault"
+	score := float32(5.5)
+	if write {
+		name += " caused by a write"
+		score = 8.0
+	}
+	return &report.ErrorDetails{
+		Id:       SegmentationFaultID,
+		Name:     name,
+		Severity: severity(score),
+	}
+}
+
+// undefinedBehaviorErrorDetails classifies an UndefinedBehaviorSanitizer
+// runtime error with the given description, e.g. "signed integer
+// overflow: 2147483647 + 1 cannot be represented in type 'int'"
+func undefinedBehaviorErrorDetails(description string) *report.ErrorDetails {
+	switch {
+	case strings.HasPrefix(description, "signed integer overflow"),
+		strings.HasPrefix(description, "negation of"):
+		return bugKindErrorDetails(SignedIntegerOverflowID)
+	case strings.HasPrefix(description, "unsigned integer overflow"):
+		return bugKindErrorDetails(UnsignedIntegerOverflowID)
+	case strings.Contains(description, "null pointer"):
+		return bugKindErrorDetails(NullDereferenceID)
+	case strings.HasPrefix(description, "division by zero"):
+		return bugKindErrorDetails(DivisionByZeroID)
+	case strings.HasPrefix(description, "index ") && strings.Contains(description, "out of bounds"):
+		return bugKindErrorDetails(IndexOutOfBoundsID)
+	case strings.HasPrefix(description, "shift exponent"),
+		strings.HasPrefix(description, "left shift of"):
+		return bugKindErrorDetails(InvalidShiftID)
+	case strings.Contains(description, "misaligned address"):
+		return bugKindErrorDetails(MisalignedAccessID)
+	case strings.HasPrefix(description, "load of value"):
+		return bugKindErrorDetails(InvalidValueID)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package sanitizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestParseErrorDetails_Severity(t *testing.T) {
+	tests := []struct {
+		name     string
+		logs     string
+		expected *report.ErrorDetails
+	}{
+		{
+			name: "heap buffer overflow write",
+			logs: `
+==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x55f7e8 bp 0x7ffd sp 0x7ffd
+WRITE of size 4 at 0x602000000011 thread T0
+    #0 0x55f7e8 in parse_header /src/http.c:120:3`,
+			expected: &report.ErrorDetails{
+				Id:       "heap-buffer-overflow",
+				Name:     "Heap buffer overflow: write of size 4",
+				Severity: &report.Severity{Description: "High", Score: 8.0},
+			},
+		},
+		{
+			name: "wide heap buffer overflow write",
+			logs: `
+==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x55f7e8 bp 0x7ffd sp 0x7ffd
+WRITE of size 64 at 0x602000000011 thread T0
+    #0 0x4a1b2c in __asan_memcpy /llvm-project/compiler-rt/lib/asan/asan_interceptors_memintrinsics.cpp:22:3`,
+			expected: &report.ErrorDetails{
+				Id:       "heap-buffer-overflow",
+				Name:     "Heap buffer overflow: write of size 64",
+				Severity: &report.Severity{Description: "Critical", Score: 9.0},
+			},
+		},
+		{
+			name: "stack buffer overflow read",
+			logs: `
+==16==ERROR: AddressSanitizer: stack-buffer-overflow on address 0x7fffb9492184 at pc 0x0000004969aa bp 0x7fffb9492150 sp 0x7fffb9491918
+READ of size 1 at 0x7fffb9492184 thread T0`,
+			expected: &report.ErrorDetails{
+				Id:       "stack-buffer-overflow",
+				Name:     "Stack buffer overflow: read of size 1",
+				Severity: &report.Severity{Description: "Medium", Score: 5.5},
+			},
+		},
+		{
+			name: "wide global buffer overflow read",
+			logs: `
+==16==ERROR: AddressSanitizer: global-buffer-overflow on address 0x000000601c40 at pc 0x0000004969aa bp 0x7fffb9492150 sp 0x7fffb9491918
+READ of size 100 at 0x000000601c40 thread T0`,
+			expected: &report.ErrorDetails{
+				Id:       "global-buffer-overflow",
+				Name:     "Global buffer overflow: read of size 100",
+				Severity: &report.Severity{Description: "Medium", Score: 6.5},
+			},
+		},
+		{
+			name: "heap use after free read",
+			logs: `
+==42==ERROR: AddressSanitizer: heap-use-after-free on address 0x602000000010 at pc 0x4f5b4b bp 0x7ffd sp 0x7ffd
+READ of size 8 at 0x602000000010 thread T0`,
+			expected: &report.ErrorDetails{
+				Id:       "heap-use-after-free",
+				Name:     "Heap use after free: read of size 8",
+				Severity: &report.Severity{Description: "High", Score: 7.0},
+			},
+		},
+		{
+			name: "heap use after free write",
+			logs: `
+==42==ERROR: AddressSanitizer: heap-use-after-free on address 0x602000000010 at pc 0x4f5b4b bp 0x7ffd sp 0x7ffd
+WRITE of size 16 at 0x602000000010 thread T0`,
+			expected: &report.ErrorDetails{
+				Id:       "heap-use-after-free",
+				Name:     "Heap use after free: write of size 16",
+				Severity: &report.Severity{Description: "Critical", Score: 9.5},
+			},
+		},
+		{
+			name: "double free",
+			logs: `
+==42==ERROR: AddressSanitizer: attempting double-free on 0x602000000010 in thread T0:
+    #0 0x4c2a1d in free /llvm-project/compiler-rt/lib/asan/asan_malloc_linux.cpp:52:3`,
+			expected: &report.ErrorDetails{
+				Id:       DoubleFreeID,
+				Name:     "Double free",
+				Severity: &report.Severity{Description: "High", Score: 8.0},
+			},
+		},
+		{
+			name: "bad free",
+			logs: `
+==42==ERROR: AddressSanitizer: attempting free on address which was not malloc()-ed: 0x7ffc3c8b8d30 in thread T0`,
+			expected: &report.ErrorDetails{
+				Id:       BadFreeID,
+				Name:     "Free of invalid pointer",
+				Severity: &report.Severity{Description: "High", Score: 7.0},
+			},
+		},
+		{
+			name: "stack overflow",
+			logs: `
+==42==ERROR: AddressSanitizer: stack-overflow on address 0x7ffe1c8c6ff8 (pc 0x4f5b4b bp 0x7ffe1c8c7010 sp 0x7ffe1c8c7000 T0)`,
+			expected: &report.ErrorDetails{
+				Id:       StackOverflowID,
+				Name:     "Stack overflow",
+				Severity: &report.Severity{Description: "Medium", Score: 4.0},
+			},
+		},
+		{
+			name: "null dereference",
+			logs: `
+==16==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000 (pc 0x000000000000 bp 0x7fffb9492290 sp 0x7fffb9492158 T0)
+==16==The signal is caused by a READ memory access.
+==16==Hint: address points to the zero page.`,
+			expected: &report.ErrorDetails{
+				Id:       NullDereferenceID,
+				Name:     "Null pointer dereference",
+				Severity: &report.Severity{Description: "Medium", Score: 4.0},
+			},
+		},
+		{
+			name: "null dereference with offset",
+			logs: `
+==16==ERROR: UndefinedBehaviorSanitizer: SEGV on unknown address 0x000000000010 (pc 0x0000004969aa bp 0x7fffb9492290 sp 0x7fffb9492158 T0)
+==16==The signal is caused by a WRITE memory access.`,
+			expected: &report.ErrorDetails{
+				Id:       NullDereferenceID,
+				Name:     "Null pointer dereference",
+				Severity: &report.Severity{Description: "Medium", Score: 4.0},
+			},
+		},
+		{
+			name: "wild write",
+			logs: `
+==16==ERROR: AddressSanitizer: SEGV on unknown address 0x7f3c5a1b2000 (pc 0x0000004969aa bp 0x7fffb9492290 sp 0x7fffb9492158 T0)
+==16==The signal is caused by a WRITE memory access.`,
+			expected: &report.ErrorDetails{
+				Id:       SegmentationFaultID,
+				Name:     "Segmentation fault caused by a write",
+				Severity: &report.Severity{Description: "High", Score: 8.0},
+			},
+		},
+		{
+			name: "libFuzzer out of memory",
+			logs: `
+==18== ERROR: libFuzzer: out-of-memory (malloc(2147483648))
+   To change the out-of-memory limit use -rss_limit_mb=<N>`,
+			expected: &report.ErrorDetails{
+				Id:       OutOfMemoryID,
+				Name:     "Out of memory",
+				Severity: &report.Severity{Description: "Low", Score: 3.0},
+			},
+		},
+		{
+			name: "AddressSanitizer allocation size too big",
+			logs: `
+==18==ERROR: AddressSanitizer: requested allocation size 0xffffffffffffffff (0x800 after adjustments for alignment, red zones etc.) exceeds maximum supported size of 0x10000000000 (thread T0)`,
+			expected: &report.ErrorDetails{
+				Id:       AllocationSizeTooBigID,
+				Name:     "Allocation size too big",
+				Severity: &report.Severity{Description: "Low", Score: 3.0},
+			},
+		},
+		{
+			name: "libFuzzer timeout",
+			logs: `
+ALARM: working on the last Unit for 25 seconds
+       and the timeout value is 25 (use -timeout=N to change)
+==1== ERROR: libFuzzer: timeout after 25 seconds`,
+			expected: &report.ErrorDetails{
+				Id:       TimeoutID,
+				Name:     "Timeout",
+				Severity: &report.Severity{Description: "Low", Score: 3.0},
+			},
+		},
+		{
+			name: "signed integer overflow",
+			logs: `
+fuzz_targets/manual.cpp:6:5: runtime error: signed integer overflow: 2147483647 + 1 cannot be represented in type 'int'
+SUMMARY: UndefinedBehaviorSanitizer: undefined-behavior fuzz_targets/manual.cpp:6:5 in`,
+			expected: &report.ErrorDetails{
+				Id:       SignedIntegerOverflowID,
+				Name:     "Signed integer overflow",
+				Severity: &report.Severity{Description: "Low", Score: 3.0},
+			},
+		},
+		{
+			name: "undefined behavior null pointer",
+			logs: `
+src/parser.c:12:10: runtime error: load of null pointer of type 'int'`,
+			expected: &report.ErrorDetails{
+				Id:       NullDereferenceID,
+				Name:     "Null pointer dereference",
+				Severity: &report.Severity{Description: "Medium", Score: 4.0},
+			},
+		},
+		{
+			name: "undefined behavior index out of bounds",
+			logs: `
+src/parser.c:12:10: runtime error: index 5 out of bounds for type 'int[4]'`,
+			expected: &report.ErrorDetails{
+				Id:       IndexOutOfBoundsID,
+				Name:     "Index out of bounds",
+				Severity: &report.Severity{Description: "Medium", Score: 6.0},
+			},
+		},
+		{
+			name: "undefined behavior misaligned load",
+			logs: `
+src/parser.c:12:10: runtime error: load of misaligned address 0x000001e4a011 for type 'int', which requires 4 byte alignment`,
+			expected: &report.ErrorDetails{
+				Id:       MisalignedAccessID,
+				Name:     "Misaligned memory access",
+				Severity: &report.Severity{Description: "Low", Score: 2.0},
+			},
+		},
+		{
+			name: "unknown undefined behavior",
+			logs: `
+src/parser.c:12:10: runtime error: execution reached an unreachable program point`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := strings.Split(strings.TrimPrefix(tt.logs, "\n"), "\n")
+			assert.Equal(t, tt.expected, ParseErrorDetails(logs))
+		})
+	}
+}
+
+func TestParseInvalidAccess(t *testing.T) {
+	access := ParseInvalidAccess([]string{
+		"==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011",
+		"WRITE of size 4 at 0x602000000011 thread T0",
+	})
+	assert.Equal(t, &report.MemoryAccess{
+		Write:   true,
+		Size:    4,
+		Address: 0x602000000011,
+		Thread:  "T0",
+	}, access)
+
+	assert.Nil(t, ParseInvalidAccess([]string{
+		"==42==ERROR: AddressSanitizer: attempting double-free on 0x602000000010 in thread T0:",
+	}))
+}
+
+func TestKnowledgeBase(t *testing.T) {
+	ids := []string{MemoryLeakID, DataRaceID, UninitializedValueID, SegmentationFaultID}
+	for id := range invalidAccessKinds {
+		ids = append(ids, id)
+	}
+	for id := range bugKinds {
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		assert.NotNil(t, report.LookupKnowledge(id), "no knowledge about %s", id)
+	}
+}
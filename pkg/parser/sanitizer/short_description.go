@@ -0,0 +1,51 @@
+package sanitizer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// ShortDescription returns a one-line description of the finding which
+// contains the kind of bug, the invalid memory access if there is one
+// and the location of the crash, e.g.
+//
+//	heap-buffer-overflow (WRITE of size 4) in parse_header (src/http.c:120)
+//
+// The kind of bug is the ID of the error details if the finding has
+// one, else the first line of its details.
+func ShortDescription(finding *report.Finding) string {
+	var description string
+	if finding.MoreDetails != nil && finding.MoreDetails.Id != "" {
+		description = finding.MoreDetails.Id
+	} else {
+		description, _, _ = strings.Cut(finding.Details, "\n")
+	}
+	if description == "" {
+		return ""
+	}
+
+	if access := ParseInvalidAccess(finding.Logs); access != nil {
+		kind := "READ"
+		if access.Write {
+			kind = "WRITE"
+		}
+		description += fmt.Sprintf(" (%s of size %d)", kind, access.Size)
+	}
+
+	frame := finding.CrashLocation()
+	if frame == nil {
+		return description
+	}
+	description += " in " + frame.Function
+	if frame.File != "" {
+		location := filepath.ToSlash(frame.File)
+		if frame.Line != 0 {
+			location += fmt.Sprintf(":%d", frame.Line)
+		}
+		description += " (" + location + ")"
+	}
+	return description
+}
@@ -0,0 +1,67 @@
+package sanitizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestShortDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		finding  *report.Finding
+		expected string
+	}{
+		{
+			name: "invalid access",
+			finding: &report.Finding{
+				Details: "heap-buffer-overflow on address 0x602000000011 at pc 0x55f7e8 bp 0x7ffd sp 0x7ffd",
+				Logs: []string{
+					"==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x55f7e8 bp 0x7ffd sp 0x7ffd",
+					"WRITE of size 4 at 0x602000000011 thread T0",
+				},
+				MoreDetails: &report.ErrorDetails{Id: "heap-buffer-overflow"},
+				StackTrace: []*report.StackFrame{
+					{Function: "__asan_memcpy", File: "/llvm-project/compiler-rt/lib/asan/asan_interceptors_memintrinsics.cpp", Line: 22},
+					{Function: "parse_header", File: "src/http.c", Line: 120, Column: 3},
+				},
+			},
+			expected: "heap-buffer-overflow (WRITE of size 4) in parse_header (src/http.c:120)",
+		},
+		{
+			name: "without error details",
+			finding: &report.Finding{
+				Details:    "Go Panic",
+				StackTrace: []*report.StackFrame{{Function: "example.com/http.(*Parser).Parse", File: "/src/http/parser.go", Line: 42}},
+			},
+			expected: "Go Panic in example.com/http.(*Parser).Parse (/src/http/parser.go:42)",
+		},
+		{
+			name: "without file",
+			finding: &report.Finding{
+				Details:     "SEGV on unknown address 0x000000000000",
+				MoreDetails: &report.ErrorDetails{Id: NullDereferenceID},
+				StackTrace:  []*report.StackFrame{{Function: "parse", Module: "fuzz_test"}},
+			},
+			expected: "null-dereference in parse",
+		},
+		{
+			name:     "without stack trace",
+			finding:  &report.Finding{Details: "timeout after 25 seconds\nmore details"},
+			expected: "timeout after 25 seconds",
+		},
+		{
+			name:     "empty",
+			finding:  &report.Finding{},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ShortDescription(tt.finding))
+		})
+	}
+}
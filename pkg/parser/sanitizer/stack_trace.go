@@ -0,0 +1,151 @@
+package sanitizer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/regexutil"
+)
+
+// The number of stack frames which are used to compute the signature
+// of a crash
+const numSignatureFrames = 3
+
+var (
+	// Matches frames of stack traces printed by the sanitizers and
+	// libFuzzer, for example:
+	//     #0 0x4be181 in parse_header /src/http.c:120:7
+	//     #1 0x4be1a2 in main /src/main.c:12
+	//     #2 0x7f0a1b2c3d4e in __libc_start_main (/lib/x86_64-linux-gnu/libc.so.6+0x21bf6)
+	//     #3 0x41d5ad (/src/my_fuzz_test+0x41d5ad) (BuildId: 4a1b2c3d)
+	stackFramePattern = regexp.MustCompile(
+		`^\s*#(?P<frame_number>\d+)\s+0x(?P<address>[0-9a-fA-F]+)` +
+			`(\s+in\s+(?P<function>.+?))?` +
+			`(\s+(?P<file>[^\s()]+?):(?P<line>\d+)(:(?P<column>\d+))?)?` +
+			`(\s+\((?P<module>[^+()\s]+)(\+0x[0-9a-fA-F]+)?\))?` +
+			`(\s+\(BuildId: [0-9a-fA-F]+\))?\s*$`,
+	)
+
+	// Matches frames of stack traces printed by ThreadSanitizer, which
+	// don't contain the absolute address but the offset in the module,
+	// for example:
+	//     #0 racy_write /src/race.c:5:3 (race_fuzz_test+0x4f5b4b)
+	//     #1 <null> <null> (libc.so.6+0x94ac2)
+	threadSanitizerStackFramePattern = regexp.MustCompile(
+		`^\s*#(?P<frame_number>\d+)\s+(?P<function>.+?)` +
+			`\s+((?P<file>[^\s()]+?):(?P<line>\d+)(:(?P<column>\d+))?|<null>)` +
+			`\s+\((?P<module>[^+()\s]+)\+0x(?P<address>[0-9a-fA-F]+)\)\s*$`,
+	)
+
+	// Matches addresses and other numbers which differ between
+	// multiple occurrences of the same crash
+	volatileNumberPattern = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+`)
+)
+
+// ParseStackTrace returns the frames of the first stack trace in the
+// given log lines. Sanitizer reports often contain multiple stack
+// traces, for example of the allocation of the affected memory, but
+// the first one is the one of the crash itself.
+func ParseStackTrace(logs []string) []*report.StackFrame {
+	var frames []*report.StackFrame
+	for _, line := range logs {
+		frame, frameNumber, ok := parseStackFrame(line)
+		if !ok {
+			if len(frames) > 0 {
+				// The first stack trace ended
+				break
+			}
+			continue
+		}
+		if frameNumber == 0 && len(frames) > 0 {
+			// A new stack trace started directly after the first one
+			break
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func parseStackFrame(line string) (*report.StackFrame, int, bool) {
+	result, found := regexutil.FindNamedGroupsMatch(stackFramePattern, line)
+	if !found {
+		result, found = regexutil.FindNamedGroupsMatch(threadSanitizerStackFramePattern, line)
+		if !found {
+			return nil, 0, false
+		}
+		if result["function"] == "<null>" {
+			result["function"] = ""
+		}
+	}
+	frameNumber, err := strconv.Atoi(result["frame_number"])
+	if err != nil {
+		return nil, 0, false
+	}
+	address, err := strconv.ParseUint(result["address"], 16, 64)
+	if err != nil {
+		return nil, 0, false
+	}
+	frame := &report.StackFrame{
+		Address:  address,
+		Function: result["function"],
+		File:     result["file"],
+		Module:   result["module"],
+	}
+	if result["line"] != "" {
+		line, err := strconv.ParseUint(result["line"], 10, 32)
+		if err != nil {
+			return nil, 0, false
+		}
+		frame.Line = uint32(line)
+	}
+	if result["column"] != "" {
+		column, err := strconv.ParseUint(result["column"], 10, 32)
+		if err != nil {
+			return nil, 0, false
+		}
+		frame.Column = uint32(column)
+	}
+	return frame, frameNumber, true
+}
+
+// CrashSignature computes a signature from the error type and the top
+// frames of the stack trace of a crash which is the same for all
+// occurrences of the same bug, independent of the input which
+// triggered it. If the stack trace doesn't contain any frames which
+// identify the crash, an empty string is returned.
+func CrashSignature(errorType string, frames []*report.StackFrame) string {
+	var relevantFrames []string
+	for _, frame := range frames {
+		if frame.Function == "" || frame.IsRuntimeFrame() {
+			continue
+		}
+		// Only the function and the base name of the file are used, so
+		// that the signature neither depends on the directory in which
+		// the project was built nor changes when unrelated lines above
+		// the crash are edited.
+		relevantFrame := frame.Function
+		if frame.File != "" {
+			relevantFrame += " " + path.Base(filepath.ToSlash(frame.File))
+		}
+		relevantFrames = append(relevantFrames, relevantFrame)
+		if len(relevantFrames) == numSignatureFrames {
+			break
+		}
+	}
+	if len(relevantFrames) == 0 {
+		return ""
+	}
+
+	h := sha1.New()
+	h.Write([]byte(volatileNumberPattern.ReplaceAllString(strings.TrimSpace(errorType), "")))
+	for _, frame := range relevantFrames {
+		h.Write([]byte("\n" + frame))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,114 @@
+package sanitizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+var heapBufferOverflowLogs = []string{
+	"==16==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x0000004e5b4c bp 0x7ffd4a3b2c10 sp 0x7ffd4a3b2c08",
+	"WRITE of size 4 at 0x602000000011 thread T0",
+	"    #0 0x4e5b4b in parse_header /src/http.c:120:7",
+	"    #1 0x4e5c2d in handle_request(char const*, unsigned long) /src/server.cpp:42:3",
+	"    #2 0x4e5d3e in LLVMFuzzerTestOneInput /src/fuzz_test.cpp:12:3",
+	"    #3 0x7f0a1b2c3d4e in __libc_start_main (/lib/x86_64-linux-gnu/libc.so.6+0x21bf6)",
+	"",
+	"0x602000000011 is located 0 bytes to the right of 1-byte region [0x602000000010,0x602000000011)",
+	"allocated by thread T0 here:",
+	"    #0 0x4a1b2d in malloc /llvm-project/compiler-rt/lib/asan/asan_malloc_linux.cpp:145:3",
+	"    #1 0x4e5a1c in parse_header /src/http.c:110:15",
+}
+
+func TestParseStackTrace(t *testing.T) {
+	frames := ParseStackTrace(heapBufferOverflowLogs)
+	require.Len(t, frames, 4)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x4e5b4b,
+		Function: "parse_header",
+		File:     "/src/http.c",
+		Line:     120,
+		Column:   7,
+	}, frames[0])
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x4e5c2d,
+		Function: "handle_request(char const*, unsigned long)",
+		File:     "/src/server.cpp",
+		Line:     42,
+		Column:   3,
+	}, frames[1])
+	assert.Equal(t, "LLVMFuzzerTestOneInput", frames[2].Function)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x7f0a1b2c3d4e,
+		Function: "__libc_start_main",
+		Module:   "/lib/x86_64-linux-gnu/libc.so.6",
+	}, frames[3])
+}
+
+func TestParseStackTrace_Unsymbolized(t *testing.T) {
+	frames := ParseStackTrace([]string{
+		"    #0 0x41d5ad (/src/my_fuzz_test+0x41d5ad) (BuildId: 4a1b2c3d)",
+	})
+	require.Len(t, frames, 1)
+	assert.Equal(t, &report.StackFrame{Address: 0x41d5ad, Module: "/src/my_fuzz_test"}, frames[0])
+}
+
+func TestCrashSignature(t *testing.T) {
+	frames := ParseStackTrace(heapBufferOverflowLogs)
+	signature := CrashSignature("heap-buffer-overflow on address 0x602000000011", frames)
+	assert.NotEmpty(t, signature)
+
+	// The same crash triggered by a different input in a project which
+	// was built in a different directory
+	otherFrames := ParseStackTrace([]string{
+		"    #0 0x4f5b4b in __asan_memcpy /llvm-project/compiler-rt/lib/asan/asan_interceptors_memintrinsics.cpp:22:3",
+		"    #1 0x4f5b4c in parse_header /home/user/src/http.c:120:9",
+		"    #2 0x4f5c2d in handle_request(char const*, unsigned long) /home/user/src/server.cpp:42:3",
+		"    #3 0x4f5d3e in LLVMFuzzerTestOneInput /home/user/src/fuzz_test.cpp:12:3",
+	})
+	assert.Equal(t, signature, CrashSignature("heap-buffer-overflow on address 0x603000000042", otherFrames))
+
+	// The same crash after lines above the crash site were edited
+	movedFrames := ParseStackTrace([]string{
+		"    #0 0x4e5b4b in parse_header /src/http.c:135:7",
+		"    #1 0x4e5c2d in handle_request(char const*, unsigned long) /src/server.cpp:44:3",
+		"    #2 0x4e5d3e in LLVMFuzzerTestOneInput /src/fuzz_test.cpp:12:3",
+	})
+	assert.Equal(t, signature, CrashSignature("heap-buffer-overflow on address 0x602000000011", movedFrames))
+
+	// A different error type at the same location
+	assert.NotEqual(t, signature, CrashSignature("use-after-free on address 0x602000000011", frames))
+
+	// A crash at a different location
+	assert.NotEqual(t, signature, CrashSignature("heap-buffer-overflow on address 0x602000000011", frames[1:]))
+
+	// No frames which identify the crash
+	assert.Empty(t, CrashSignature("deadly signal", ParseStackTrace([]string{
+		"    #0 0x4be181 in __sanitizer_print_stack_trace /llvm-project/compiler-rt/lib/asan/asan_stack.cpp:86:3",
+	})))
+}
+
+func TestParseStackTrace_ThreadSanitizer(t *testing.T) {
+	frames := ParseStackTrace([]string{
+		"WARNING: ThreadSanitizer: data race (pid=12345)",
+		"  Write of size 4 at 0x7b0400000010 by thread T1:",
+		"    #0 racy_write /src/race.c:5:3 (race_fuzz_test+0x4f5b4b)",
+		"    #1 <null> <null> (libc.so.6+0x94ac2)",
+		"",
+		"  Previous read of size 4 at 0x7b0400000010 by main thread:",
+		"    #0 racy_read /src/race.c:10:10 (race_fuzz_test+0x4f5c2d)",
+	})
+	require.Len(t, frames, 2)
+	assert.Equal(t, &report.StackFrame{
+		Address:  0x4f5b4b,
+		Function: "racy_write",
+		File:     "/src/race.c",
+		Line:     5,
+		Column:   3,
+		Module:   "race_fuzz_test",
+	}, frames[0])
+	assert.Equal(t, &report.StackFrame{Address: 0x94ac2, Module: "libc.so.6"}, frames[1])
+}
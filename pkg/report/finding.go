@@ -1,21 +1,32 @@
 package report
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
 const nameCrashingInput = "crashing-input"
+const nameMinimizedInput = "crashing-input-minimized"
 const nameJsonFile = "finding.json"
 const nameFindingDir = ".cifuzz-findings"
 
+// Only the first bytes of large inputs are included in the human
+// readable input, to keep the finding.json small
+const maxHumanReadableInputSize = 1024
+
 type Finding struct {
 	Name               string        `json:"name,omitempty"`
 	Type               ErrorType     `json:"type,omitempty"`
@@ -26,7 +37,24 @@ type Finding struct {
 	MoreDetails        *ErrorDetails `json:"more_details,omitempty"`
 	Tag                uint64        `json:"tag,omitempty"`
 	ShortDescription   string        `json:"short_description,omitempty"`
+	StackTrace         []*StackFrame `json:"stack_trace,omitempty"`
 	InputFile          string
+	// The crashing input as it was reported by the fuzzer, if the
+	// crashing input was minimized. InputFile is the minimized input
+	// in that case.
+	OriginalInputFile string `json:"original_input_file,omitempty"`
+
+	// The name of the fuzz test which produced the finding
+	FuzzTest string `json:"fuzz_test,omitempty"`
+	// The ID of the fuzzing run which produced the finding
+	RunID string `json:"run_id,omitempty"`
+	// The time at which the finding was first seen
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// Identifies the bug which caused the finding, findings with the
+	// same signature are considered duplicates
+	Signature string `json:"signature,omitempty"`
+	// The number of times the finding was found
+	Count uint `json:"count,omitempty"`
 }
 
 func (f *Finding) GetDetails() string {
@@ -36,8 +64,47 @@ func (f *Finding) GetDetails() string {
 	return ""
 }
 
-func (f *Finding) Save() error {
-	findingDir := filepath.Join(nameFindingDir, f.Name)
+// HumanReadableInput returns a rendering of the input which can be
+// shown to users: An escaped string if the input is mostly printable
+// text, else a hexdump.
+func HumanReadableInput(input []byte) string {
+	if len(input) == 0 {
+		return ""
+	}
+
+	truncated := input
+	if len(truncated) > maxHumanReadableInputSize {
+		truncated = truncated[:maxHumanReadableInputSize]
+	}
+
+	var rendered string
+	if isMostlyPrintable(truncated) {
+		rendered = strconv.Quote(string(truncated))
+	} else {
+		rendered = strings.TrimSuffix(hex.Dump(truncated), "\n")
+	}
+	if len(truncated) < len(input) {
+		rendered += fmt.Sprintf("\n... (%d more bytes)", len(input)-len(truncated))
+	}
+	return rendered
+}
+
+// isMostlyPrintable returns true if at least 90% of the bytes are
+// printable ASCII characters or whitespace
+func isMostlyPrintable(data []byte) bool {
+	var printable int
+	for _, b := range data {
+		if (b >= 0x20 && b < 0x7f) || b == '\n' || b == '\r' || b == '\t' {
+			printable++
+		}
+	}
+	return printable*10 >= len(data)*9
+}
+
+// Save stores the finding and its crashing input in a directory named
+// after the finding in the findings dir of the project.
+func (f *Finding) Save(projectDir string) error {
+	findingDir := filepath.Join(FindingsDir(projectDir), f.Name)
 
 	if err := os.MkdirAll(findingDir, 0755); err != nil {
 		return errors.WithStack(err)
@@ -56,6 +123,143 @@ func (f *Finding) Save() error {
 	return nil
 }
 
+// SaveMinimizedInput stores the minimized crashing input in the finding
+// dir next to the original crashing input and makes it the crashing
+// input of the finding. The finding must have been stored via Save
+// before.
+func (f *Finding) SaveMinimizedInput(projectDir string, input []byte) error {
+	findingDir := filepath.Join(FindingsDir(projectDir), f.Name)
+
+	minimizedInputFile := filepath.Join(findingDir, nameMinimizedInput)
+	if err := os.WriteFile(minimizedInputFile, input, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	f.OriginalInputFile = filepath.Join(findingDir, nameCrashingInput)
+	f.InputFile = minimizedInputFile
+	f.InputData = input
+	f.HumanReadableInput = HumanReadableInput(input)
+
+	return f.saveJson(findingDir)
+}
+
+// LoadFinding reads the finding with the given name which was stored
+// via Save before.
+func LoadFinding(projectDir, name string) (*Finding, error) {
+	err := validateFindingName(name)
+	if err != nil {
+		return nil, err
+	}
+	findingDir := filepath.Join(FindingsDir(projectDir), name)
+
+	f, err := loadJson(findingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The input files might have been stored with a path which is not
+	// valid anymore (e.g. because the project was moved), so we always
+	// use the paths of the crashing inputs in the finding dir.
+	inputFile := filepath.Join(findingDir, nameCrashingInput)
+	minimizedInputFile := filepath.Join(findingDir, nameMinimizedInput)
+	exists, err := fileutil.Exists(minimizedInputFile)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		f.InputFile = minimizedInputFile
+		f.OriginalInputFile = inputFile
+		return f, nil
+	}
+	f.OriginalInputFile = ""
+
+	exists, err = fileutil.Exists(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		f.InputFile = inputFile
+	} else {
+		f.InputFile = ""
+	}
+
+	return f, nil
+}
+
+// ListFindings returns all findings stored in the findings dir of the
+// project, sorted by the time they were first seen.
+func ListFindings(projectDir string) ([]*Finding, error) {
+	entries, err := os.ReadDir(FindingsDir(projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var findings []*Finding
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		f, err := LoadFinding(projectDir, entry.Name())
+		if errors.Is(err, os.ErrNotExist) {
+			// Not a finding dir, e.g. a leftover of an aborted run
+			log.Debugf("Skipping %s: no %s found", entry.Name(), nameJsonFile)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].CreatedAt.Equal(findings[j].CreatedAt) {
+			return findings[i].Name < findings[j].Name
+		}
+		return findings[i].CreatedAt.Before(findings[j].CreatedAt)
+	})
+
+	return findings, nil
+}
+
+// DeleteFinding removes the finding with the given name, including its
+// crashing input, from the findings dir of the project.
+func DeleteFinding(projectDir, name string) error {
+	err := validateFindingName(name)
+	if err != nil {
+		return err
+	}
+	findingDir := filepath.Join(FindingsDir(projectDir), name)
+
+	exists, err := fileutil.Exists(findingDir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.WithStack(&os.PathError{Op: "delete", Path: findingDir, Err: os.ErrNotExist})
+	}
+
+	return errors.WithStack(os.RemoveAll(findingDir))
+}
+
+// validateFindingName returns an error if the name is not a single
+// path element, which would refer to a path outside of the findings
+// dir. A finding with such a name can't exist, so the error wraps
+// os.ErrNotExist.
+func validateFindingName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+		return errors.WithStack(fmt.Errorf("invalid finding name %q: %w", name, os.ErrNotExist))
+	}
+	return nil
+}
+
+// FindingsDir returns the directory in which findings of the project
+// are stored.
+func FindingsDir(projectDir string) string {
+	return filepath.Join(projectDir, nameFindingDir)
+}
+
 func (f *Finding) saveJson(findingDir string) error {
 	bytes, err := json.MarshalIndent(f, "", "  ")
 	if err != nil {
@@ -70,10 +274,33 @@ func (f *Finding) saveJson(findingDir string) error {
 	return nil
 }
 
+// loadJson is the inverse of saveJson, it reads the finding from the
+// JSON file in the finding dir.
+func loadJson(findingDir string) (*Finding, error) {
+	jsonPath := filepath.Join(findingDir, nameJsonFile)
+	bytes, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	f := &Finding{}
+	if err := json.Unmarshal(bytes, f); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", jsonPath)
+	}
+
+	return f, nil
+}
+
 // move the input file to a new location and update
 // the finding and the logs
 func (f *Finding) moveInputFile(findingDir string) error {
 	newPath := filepath.Join(findingDir, nameCrashingInput)
+	minimizedPath := filepath.Join(findingDir, nameMinimizedInput)
+	if f.InputFile == newPath || f.InputFile == minimizedPath {
+		// The input file was already moved, e.g. because the finding
+		// was loaded via LoadFinding
+		return nil
+	}
 
 	// We don't use os.Rename to avoid errors when source and target
 	// are not on the same mounted filesystem.
@@ -84,6 +311,12 @@ func (f *Finding) moveInputFile(findingDir string) error {
 		return errors.WithStack(err)
 	}
 
+	// A minimized input of a previous crashing input is outdated now
+	if err := os.Remove(minimizedPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	f.OriginalInputFile = ""
+
 	for i, line := range f.Logs {
 		f.Logs[i] = strings.ReplaceAll(line, f.InputFile, newPath)
 	}
@@ -1,10 +1,13 @@
 package report
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,7 +32,7 @@ func TestFinding_Save(t *testing.T) {
 		},
 	}
 
-	err := finding.Save()
+	err := finding.Save(".")
 	require.NoError(t, err)
 
 	findingDir := filepath.Join(nameFindingDir, finding.Name)
@@ -63,7 +66,7 @@ func TestFinding_MoveInputFile(t *testing.T) {
 		},
 	}
 
-	err = finding.Save()
+	err = finding.Save(".")
 	require.NoError(t, err)
 
 	newFilename := filepath.Join(nameFindingDir, finding.Name, nameCrashingInput)
@@ -71,3 +74,151 @@ func TestFinding_MoveInputFile(t *testing.T) {
 	// check if the log was updated
 	assert.Contains(t, finding.Logs[2], newFilename)
 }
+
+func TestLoadFinding(t *testing.T) {
+	testfile := "crash_456_test"
+	err := os.WriteFile(testfile, []byte("TEST"), 0644)
+	require.NoError(t, err)
+
+	finding := &Finding{
+		Name:      "test-load",
+		Type:      ErrorType_CRASH,
+		Details:   "heap-buffer-overflow",
+		InputFile: testfile,
+		FuzzTest:  "my_fuzz_test",
+		Logs:      []string{"Oops"},
+	}
+	err = finding.Save(".")
+	require.NoError(t, err)
+
+	loaded, err := LoadFinding(".", finding.Name)
+	require.NoError(t, err)
+	assert.Equal(t, finding.Name, loaded.Name)
+	assert.Equal(t, finding.Type, loaded.Type)
+	assert.Equal(t, finding.Details, loaded.Details)
+	assert.Equal(t, finding.FuzzTest, loaded.FuzzTest)
+	assert.Equal(t, finding.Logs, loaded.Logs)
+	assert.Equal(t, filepath.Join(nameFindingDir, finding.Name, nameCrashingInput), loaded.InputFile)
+}
+
+func TestFinding_SaveMinimizedInput(t *testing.T) {
+	testfile := "crash_789_test"
+	err := os.WriteFile(testfile, []byte("TEST INPUT"), 0644)
+	require.NoError(t, err)
+
+	finding := &Finding{
+		Name:      "test-minimized",
+		InputFile: testfile,
+		Logs:      []string{"Oops"},
+	}
+	err = finding.Save(".")
+	require.NoError(t, err)
+
+	err = finding.SaveMinimizedInput(".", []byte("TEST"))
+	require.NoError(t, err)
+
+	findingDir := filepath.Join(nameFindingDir, finding.Name)
+	originalInputFile := filepath.Join(findingDir, nameCrashingInput)
+	minimizedInputFile := filepath.Join(findingDir, nameMinimizedInput)
+	assert.Equal(t, minimizedInputFile, finding.InputFile)
+	assert.Equal(t, originalInputFile, finding.OriginalInputFile)
+	assert.FileExists(t, originalInputFile)
+	assert.FileExists(t, minimizedInputFile)
+
+	loaded, err := LoadFinding(".", finding.Name)
+	require.NoError(t, err)
+	assert.Equal(t, minimizedInputFile, loaded.InputFile)
+	assert.Equal(t, originalInputFile, loaded.OriginalInputFile)
+	assert.Equal(t, []byte("TEST"), loaded.InputData)
+
+	// Saving the finding with a new crashing input removes the outdated
+	// minimized input
+	err = os.WriteFile(testfile, []byte("NEW TEST INPUT"), 0644)
+	require.NoError(t, err)
+	loaded.InputFile = testfile
+	err = loaded.Save(".")
+	require.NoError(t, err)
+	assert.Equal(t, originalInputFile, loaded.InputFile)
+	assert.Empty(t, loaded.OriginalInputFile)
+	assert.NoFileExists(t, minimizedInputFile)
+}
+
+func TestLoadFinding_NotExist(t *testing.T) {
+	_, err := LoadFinding(".", "does-not-exist")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadFinding_InvalidName(t *testing.T) {
+	projectDir := t.TempDir()
+	// A finding.json outside of the findings dir must not be loaded
+	// or deleted
+	finding := &Finding{Name: "outside"}
+	require.NoError(t, finding.Save(projectDir))
+	require.NoError(t, os.Rename(
+		filepath.Join(FindingsDir(projectDir), "outside"),
+		filepath.Join(projectDir, "outside")))
+
+	for _, name := range []string{"", ".", "..", "../outside", "a/b", `a\b`, filepath.Join(projectDir, "outside")} {
+		_, err := LoadFinding(projectDir, name)
+		assert.ErrorIs(t, err, os.ErrNotExist, name)
+		assert.ErrorContains(t, err, "invalid finding name", name)
+
+		err = DeleteFinding(projectDir, name)
+		assert.ErrorIs(t, err, os.ErrNotExist, name)
+		assert.ErrorContains(t, err, "invalid finding name", name)
+	}
+	assert.DirExists(t, filepath.Join(projectDir, "outside"))
+	assert.DirExists(t, FindingsDir(projectDir))
+}
+
+func TestListFindings(t *testing.T) {
+	projectDir, err := os.MkdirTemp("", "list-findings-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	findings, err := ListFindings(projectDir)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	now := time.Now()
+	newer := &Finding{Name: "newer", CreatedAt: now}
+	older := &Finding{Name: "older", CreatedAt: now.Add(-time.Hour)}
+	require.NoError(t, newer.Save(projectDir))
+	require.NoError(t, older.Save(projectDir))
+	// A directory without a finding.json should be skipped
+	require.NoError(t, os.MkdirAll(filepath.Join(FindingsDir(projectDir), "no-finding"), 0755))
+
+	findings, err = ListFindings(projectDir)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "older", findings[0].Name)
+	assert.Equal(t, "newer", findings[1].Name)
+}
+
+func TestDeleteFinding(t *testing.T) {
+	finding := &Finding{Name: "test-delete"}
+	err := finding.Save(".")
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(nameFindingDir, finding.Name))
+
+	err = DeleteFinding(".", finding.Name)
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(nameFindingDir, finding.Name))
+
+	err = DeleteFinding(".", finding.Name)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHumanReadableInput(t *testing.T) {
+	assert.Equal(t, "", HumanReadableInput(nil))
+	assert.Equal(t, `"GET / HTTP/1.1\r\nHost: \x00\n"`, HumanReadableInput([]byte("GET / HTTP/1.1\r\nHost: \x00\n")))
+	assert.Equal(t,
+		"00000000  7f 45 4c 46 02 01 01 00                           |.ELF....|",
+		HumanReadableInput([]byte("\x7fELF\x02\x01\x01\x00")))
+
+	large := bytes.Repeat([]byte("A"), maxHumanReadableInputSize+10)
+	rendered := HumanReadableInput(large)
+	assert.True(t, strings.HasPrefix(rendered, `"AAAA`))
+	assert.True(t, strings.HasSuffix(rendered, "\n... (10 more bytes)"))
+}
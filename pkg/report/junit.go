@@ -0,0 +1,143 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// JUnitTestSuite describes the results of a fuzz test, which are
+// written as a testsuite element of a JUnit XML report.
+type JUnitTestSuite struct {
+	// The name of the fuzz test
+	Name     string
+	Duration time.Duration
+	// Properties of the suite, e.g. the final metrics of a fuzzing run
+	Properties []*JUnitProperty
+	TestCases  []*JUnitTestCase
+}
+
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase is either a finding or an input which was replayed in
+// a regression test.
+type JUnitTestCase struct {
+	Name     string
+	Duration time.Duration
+	// Nil if the test case passed
+	Failure *JUnitFailure
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitTestCaseFromFinding returns a failed test case which carries
+// the details and logs of the finding.
+func NewJUnitTestCaseFromFinding(f *Finding) *JUnitTestCase {
+	message := f.ShortDescription
+	if message == "" {
+		message = f.Details
+	}
+	return &JUnitTestCase{
+		Name: f.Name,
+		Failure: &JUnitFailure{
+			Message: message,
+			Type:    string(f.Type),
+			Text:    strings.Join(f.Logs, "\n"),
+		},
+	}
+}
+
+// The types below define the XML structure of the JUnit report, see
+// https://github.com/testmoapp/junitxml for a description of the format
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []*junitTestCase `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Properties []*JUnitProperty `xml:"property"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// WriteJUnit writes a JUnit XML report with one testsuite per fuzz test
+// to out.
+func WriteJUnit(out io.Writer, suites []*JUnitTestSuite) error {
+	report := &junitTestSuites{}
+	var totalDuration time.Duration
+	for _, suite := range suites {
+		s := &junitTestSuite{
+			Name:  suite.Name,
+			Tests: len(suite.TestCases),
+			Time:  junitTime(suite.Duration),
+		}
+		if len(suite.Properties) > 0 {
+			s.Properties = &junitProperties{Properties: suite.Properties}
+		}
+		for _, testCase := range suite.TestCases {
+			s.TestCases = append(s.TestCases, &junitTestCase{
+				Name:      testCase.Name,
+				ClassName: suite.Name,
+				Time:      junitTime(testCase.Duration),
+				Failure:   testCase.Failure,
+			})
+			if testCase.Failure != nil {
+				s.Failures += 1
+			}
+		}
+
+		report.Suites = append(report.Suites, s)
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		totalDuration += suite.Duration
+	}
+	report.Time = junitTime(totalDuration)
+
+	_, err := io.WriteString(out, xml.Header)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.WriteString(out, "\n")
+	return errors.WithStack(err)
+}
+
+// junitTime formats the duration in seconds, which is the unit used by
+// JUnit reports
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,60 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	finding := &report.Finding{
+		Name:    "lucid_turing",
+		Type:    report.ErrorType_CRASH,
+		Details: "heap-buffer-overflow",
+		Logs: []string{
+			"==1==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011",
+			"READ of size 1 at 0x602000000011 thread T0",
+		},
+	}
+	suites := []*report.JUnitTestSuite{
+		{
+			Name:     "my_fuzz_test",
+			Duration: 10 * time.Second,
+			Properties: []*report.JUnitProperty{
+				{Name: "new_seeds", Value: "3"},
+			},
+			TestCases: []*report.JUnitTestCase{report.NewJUnitTestCaseFromFinding(finding)},
+		},
+		{
+			Name:      "other_fuzz_test",
+			Duration:  1500 * time.Millisecond,
+			TestCases: []*report.JUnitTestCase{{Name: "seed_1", Duration: 1500 * time.Millisecond}},
+		},
+	}
+
+	var out bytes.Buffer
+	err := report.WriteJUnit(&out, suites)
+	require.NoError(t, err)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" time="11.500">
+  <testsuite name="my_fuzz_test" tests="1" failures="1" time="10.000">
+    <properties>
+      <property name="new_seeds" value="3"></property>
+    </properties>
+    <testcase name="lucid_turing" classname="my_fuzz_test" time="0.000">
+      <failure message="heap-buffer-overflow" type="CRASH">==1==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011&#xA;READ of size 1 at 0x602000000011 thread T0</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="other_fuzz_test" tests="1" failures="0" time="1.500">
+    <testcase name="seed_1" classname="other_fuzz_test" time="1.500"></testcase>
+  </testsuite>
+</testsuites>
+`
+	assert.Equal(t, expected, out.String())
+}
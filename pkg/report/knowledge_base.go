@@ -0,0 +1,171 @@
+package report
+
+// Knowledge describes a kind of bug and how to fix it
+type Knowledge struct {
+	Description string `json:"description,omitempty"`
+	// The typical root cause of bugs of this kind
+	RootCause string `json:"root_cause,omitempty"`
+	// How bugs of this kind are typically fixed
+	Mitigation string `json:"mitigation,omitempty"`
+}
+
+var outOfBoundsKnowledge = &Knowledge{
+	Description: "The program accessed memory outside of the bounds of a buffer. " +
+		"Out-of-bounds reads can leak sensitive data, out-of-bounds writes can corrupt " +
+		"other data and often allow attackers to execute arbitrary code.",
+	RootCause: "An index or length which is derived from the input is used without checking it " +
+		"against the size of the buffer, or the size is off by one, e.g. because of a missing " +
+		"null terminator.",
+	Mitigation: "Check all indices and lengths against the actual size of the buffer before " +
+		"accessing it and prefer APIs which take the size of the destination buffer, " +
+		"e.g. snprintf instead of sprintf or std::vector::at instead of operator[].",
+}
+
+var useAfterFreeKnowledge = &Knowledge{
+	Description: "The program accessed memory after its lifetime ended. The memory might " +
+		"already be reused for other data, which attackers can often control to execute " +
+		"arbitrary code.",
+	RootCause: "A pointer or reference to an object is used after the object was freed or went " +
+		"out of scope, e.g. because the object has multiple owners or a pointer to a local " +
+		"variable is returned.",
+	Mitigation: "Make the ownership of the object explicit, e.g. with std::unique_ptr or " +
+		"std::shared_ptr, and set pointers to NULL after freeing the memory they point to.",
+}
+
+var knowledgeBase = map[string]*Knowledge{
+	"heap-buffer-overflow":          outOfBoundsKnowledge,
+	"stack-buffer-overflow":         outOfBoundsKnowledge,
+	"stack-buffer-underflow":        outOfBoundsKnowledge,
+	"dynamic-stack-buffer-overflow": outOfBoundsKnowledge,
+	"global-buffer-overflow":        outOfBoundsKnowledge,
+	"container-overflow":            outOfBoundsKnowledge,
+	"intra-object-overflow":         outOfBoundsKnowledge,
+	"heap-use-after-free":           useAfterFreeKnowledge,
+	"stack-use-after-return":        useAfterFreeKnowledge,
+	"stack-use-after-scope":         useAfterFreeKnowledge,
+	"use-after-poison":              useAfterFreeKnowledge,
+	"double-free": {
+		Description: "The program freed the same memory twice, which corrupts the state of " +
+			"the allocator and often allows attackers to execute arbitrary code.",
+		RootCause:  "Multiple owners of an object free it, or a pointer is not reset after freeing it.",
+		Mitigation: "Make sure that each object has exactly one owner which frees it and set pointers to NULL after freeing them.",
+	},
+	"bad-free": {
+		Description: "The program freed a pointer which was not returned by the allocator, " +
+			"which corrupts the state of the allocator.",
+		RootCause:  "A pointer to a stack or global variable or a pointer into the middle of an allocation is freed.",
+		Mitigation: "Only free pointers which were returned by malloc, calloc, realloc or new.",
+	},
+	"alloc-dealloc-mismatch": {
+		Description: "The program freed memory with a function which doesn't match the function " +
+			"which allocated it, e.g. memory allocated with new[] was freed with delete.",
+		RootCause:  "Memory allocated in one part of the program is freed in another part which assumes a different allocation function.",
+		Mitigation: "Use the matching deallocation function (free for malloc, delete for new, delete[] for new[]) or use smart pointers.",
+	},
+	"new-delete-type-mismatch": {
+		Description: "The program deleted an object with a size which differs from the size it was allocated with.",
+		RootCause:   "An object of a derived class is deleted via a pointer to a base class which doesn't have a virtual destructor.",
+		Mitigation:  "Declare the destructor of polymorphic base classes virtual.",
+	},
+	"memcpy-param-overlap": {
+		Description: "The program passed overlapping source and destination buffers to memcpy or a similar function, which results in undefined behavior.",
+		RootCause:   "The source and destination buffers of a copy are derived from the same buffer.",
+		Mitigation:  "Use memmove instead of memcpy if the buffers can overlap.",
+	},
+	"negative-size-param": {
+		Description: "The program passed a negative size to a memory function like memcpy.",
+		RootCause:   "A size is calculated by subtracting values which are derived from the input without checking that the result is positive.",
+		Mitigation:  "Check the operands of size calculations and use unsigned types for sizes.",
+	},
+	"calloc-overflow": {
+		Description: "The program called calloc with a number and size of elements whose product overflows.",
+		RootCause:   "The number of elements is derived from the input without checking it.",
+		Mitigation:  "Limit the number of elements to a sensible maximum before allocating memory.",
+	},
+	"allocation-size-too-big": {
+		Description: "The program tried to allocate more memory than the allocator supports.",
+		RootCause:   "An allocation size is derived from the input without checking it, or a negative size was converted to an unsigned type.",
+		Mitigation:  "Limit allocation sizes to a sensible maximum and check size calculations for overflows.",
+	},
+	"out-of-memory": {
+		Description: "The program used more memory than allowed, which attackers can exploit to exhaust the memory of the system.",
+		RootCause:   "Memory is allocated in proportion to a size which is derived from the input, or memory is leaked in a loop.",
+		Mitigation:  "Limit allocation sizes and the size of data structures which grow with the input to a sensible maximum.",
+	},
+	"timeout": {
+		Description: "The program didn't finish processing the input in time, which attackers can exploit to exhaust the CPU of the system.",
+		RootCause:   "An endless loop or recursion, or an algorithm whose runtime grows too fast with the size of the input.",
+		Mitigation:  "Make sure that all loops terminate and limit the size of the input which is processed.",
+	},
+	"stack-overflow": {
+		Description: "The program used more stack memory than available, which crashes the program.",
+		RootCause:   "An unbounded recursion, typically when parsing nested data structures, or a very large local variable.",
+		Mitigation:  "Limit the recursion depth or replace the recursion with a loop and allocate large buffers on the heap.",
+	},
+	"null-dereference": {
+		Description: "The program dereferenced a null pointer, which crashes the program.",
+		RootCause:   "The result of a function which can return NULL, e.g. malloc or a lookup, is used without checking it.",
+		Mitigation:  "Check pointers which can be NULL before dereferencing them.",
+	},
+	"segmentation-fault": {
+		Description: "The program accessed an invalid memory address. Invalid writes can often be exploited to execute arbitrary code.",
+		RootCause:   "A wild or uninitialized pointer, or a pointer calculated from an unchecked offset which is derived from the input.",
+		Mitigation:  "Initialize all pointers and check offsets which are derived from the input before using them.",
+	},
+	"division-by-zero": {
+		Description: "The program divided by zero, which results in undefined behavior and typically crashes the program.",
+		RootCause:   "A divisor which is derived from the input is not checked.",
+		Mitigation:  "Check divisors for zero before dividing.",
+	},
+	"signed-integer-overflow": {
+		Description: "The result of a signed integer operation couldn't be represented in its type, which results in undefined behavior.",
+		RootCause:   "Arithmetic on values which are derived from the input without checking their range.",
+		Mitigation:  "Check the operands before the operation or use functions like __builtin_add_overflow which detect overflows.",
+	},
+	"unsigned-integer-overflow": {
+		Description: "The result of an unsigned integer operation wrapped around, which often leads to too small allocations.",
+		RootCause:   "Arithmetic on values which are derived from the input without checking their range.",
+		Mitigation:  "Check the operands before the operation or use functions like __builtin_mul_overflow which detect overflows.",
+	},
+	"index-out-of-bounds": {
+		Description: "The program indexed an array with an index outside of its bounds.",
+		RootCause:   "An index which is derived from the input is used without checking it against the size of the array.",
+		Mitigation:  "Check indices against the size of the array before using them.",
+	},
+	"invalid-shift": {
+		Description: "The program shifted a value by a negative amount or by at least its width, which results in undefined behavior.",
+		RootCause:   "A shift amount which is derived from the input is not checked.",
+		Mitigation:  "Check that shift amounts are in the range from zero to the width of the type minus one.",
+	},
+	"invalid-value": {
+		Description: "The program loaded a value which is not valid for its type, e.g. a bool which is neither 0 nor 1.",
+		RootCause:   "Memory is reinterpreted as a different type or an enum is initialized from an unchecked integer.",
+		Mitigation:  "Validate values which are converted to bool or enum types.",
+	},
+	"misaligned-access": {
+		Description: "The program accessed memory through a pointer which is not aligned for its type, which results in undefined behavior.",
+		RootCause:   "A byte buffer is cast to a pointer to a larger type.",
+		Mitigation:  "Copy the data with memcpy instead of casting the pointer.",
+	},
+	"memory-leak": {
+		Description: "The program didn't free memory which it allocated, which attackers can exploit to exhaust the memory of the system.",
+		RootCause:   "Memory is not freed on all code paths, typically on error paths.",
+		Mitigation:  "Free memory on all code paths or use RAII types like std::unique_ptr which free it automatically.",
+	},
+	"data-race": {
+		Description: "Multiple threads accessed the same memory concurrently without synchronization and at least one of the accesses was a write.",
+		RootCause:   "Shared data is accessed without holding a lock, or a lock is released too early.",
+		Mitigation:  "Protect shared data with a mutex or use atomic operations.",
+	},
+	"use-of-uninitialized-value": {
+		Description: "The program's behavior depends on memory which was never initialized, which can leak sensitive data.",
+		RootCause:   "A variable or a buffer is read before it was written, e.g. because an input is shorter than expected.",
+		Mitigation:  "Initialize variables when declaring them and check that all data was read before using it.",
+	},
+}
+
+// LookupKnowledge returns the knowledge about the kind of bug with the
+// given error details ID or nil if there is none
+func LookupKnowledge(id string) *Knowledge {
+	return knowledgeBase[id]
+}
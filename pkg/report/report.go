@@ -1,6 +1,7 @@
 package report
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -8,6 +9,18 @@ type Handler interface {
 	Handle(report *Report) error
 }
 
+// FindingCollector is a Handler which stores all findings it receives
+type FindingCollector struct {
+	Findings []*Finding
+}
+
+func (c *FindingCollector) Handle(r *Report) error {
+	if r.Finding != nil {
+		c.Findings = append(c.Findings, r.Finding)
+	}
+	return nil
+}
+
 type Report struct {
 	Status   RunStatus      `json:"status,omitempty"`
 	Metric   *FuzzingMetric `json:"metric,omitempty"`
@@ -69,9 +82,75 @@ type ErrorDetails struct {
 	Id       string    `json:"id,omitempty"`
 	Name     string    `json:"name,omitempty"`
 	Severity *Severity `json:"severity,omitempty"`
+	// What is known about this kind of bug, looked up by the ID
+	Knowledge *Knowledge `json:"knowledge,omitempty"`
+
+	// The leaks of a LeakSanitizer report
+	Leaks []*Leak `json:"leaks,omitempty"`
+	// The conflicting memory accesses of a ThreadSanitizer data race
+	// report. The first one is the access which triggered the report,
+	// the second one the previous access.
+	Accesses []*MemoryAccess `json:"accesses,omitempty"`
+	// Where an uninitialized value reported by MemorySanitizer was
+	// created. Nil if the fuzz test was built without origin tracking.
+	Origin *Origin `json:"origin,omitempty"`
 }
 
 type Severity struct {
 	Description string  `json:"description,omitempty"`
 	Score       float32 `json:"score,omitempty"`
 }
+
+// Leak is a single leak of a LeakSanitizer report
+type Leak struct {
+	// Indirect leaks are only referenced by other leaked memory
+	Direct  bool   `json:"direct,omitempty"`
+	Bytes   uint64 `json:"bytes,omitempty"`
+	Objects uint64 `json:"objects,omitempty"`
+	// The stack trace of the allocation of the leaked memory
+	StackTrace []*StackFrame `json:"stack_trace,omitempty"`
+}
+
+// Description returns a description of the leak like "Direct leak of
+// 32 byte(s) in 1 object(s)"
+func (l *Leak) Description() string {
+	kind := "Indirect"
+	if l.Direct {
+		kind = "Direct"
+	}
+	return fmt.Sprintf("%s leak of %d byte(s) in %d object(s)", kind, l.Bytes, l.Objects)
+}
+
+// MemoryAccess is an invalid memory access reported by AddressSanitizer
+// or one of the conflicting memory accesses of a data race reported by
+// ThreadSanitizer
+type MemoryAccess struct {
+	Write   bool   `json:"write,omitempty"`
+	Atomic  bool   `json:"atomic,omitempty"`
+	Size    uint64 `json:"size,omitempty"`
+	Address uint64 `json:"address,omitempty"`
+	// The thread which performed the access, e.g. "thread T1" or
+	// "main thread"
+	Thread     string        `json:"thread,omitempty"`
+	StackTrace []*StackFrame `json:"stack_trace,omitempty"`
+}
+
+// Description returns a description of the access like "write of
+// size 4"
+func (a *MemoryAccess) Description() string {
+	kind := "read"
+	if a.Write {
+		kind = "write"
+	}
+	if a.Atomic {
+		kind = "atomic " + kind
+	}
+	return fmt.Sprintf("%s of size %d", kind, a.Size)
+}
+
+// Origin describes where an uninitialized value was created
+type Origin struct {
+	// How the value was created, e.g. "a heap allocation"
+	Description string        `json:"description,omitempty"`
+	StackTrace  []*StackFrame `json:"stack_trace,omitempty"`
+}
@@ -0,0 +1,408 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// The URI base ID which is used for all paths relative to the
+	// project directory
+	sarifSrcRoot = "%SRCROOT%"
+
+	sarifLevelError   = "error"
+	sarifLevelWarning = "warning"
+	sarifLevelNote    = "note"
+)
+
+// The types below are the subset of the SARIF 2.1.0 object model which
+// is needed to describe findings, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               *sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]*sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []*sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifToolComponent `json:"driver"`
+}
+
+type sarifToolComponent struct {
+	Name           string                `json:"name"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []*sarifReportingRule `json:"rules,omitempty"`
+}
+
+type sarifReportingRule struct {
+	ID               string                 `json:"id"`
+	Name             string                 `json:"name,omitempty"`
+	ShortDescription *sarifMessage          `json:"shortDescription,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             *sarifMessage          `json:"message"`
+	Locations           []*sarifLocation       `json:"locations,omitempty"`
+	CodeFlows           []*sarifCodeFlow       `json:"codeFlows,omitempty"`
+	Attachments         []*sarifAttachment     `json:"attachments,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation  `json:"physicalLocation,omitempty"`
+	LogicalLocations []*sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion           `json:"region,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   uint32 `json:"startLine,omitempty"`
+	StartColumn uint32 `json:"startColumn,omitempty"`
+}
+
+type sarifCodeFlow struct {
+	Message     *sarifMessage      `json:"message,omitempty"`
+	ThreadFlows []*sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+	Locations []*sarifThreadFlowLocation `json:"locations"`
+}
+
+type sarifThreadFlowLocation struct {
+	Location *sarifLocation `json:"location"`
+}
+
+type sarifAttachment struct {
+	Description      *sarifMessage          `json:"description,omitempty"`
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log to out. Paths of
+// source files and crashing inputs are made relative to the project
+// directory if they are below it.
+func WriteSARIF(out io.Writer, findings []*Finding, projectDir string) error {
+	projectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	run := &sarifRun{
+		Tool: &sarifTool{
+			Driver: &sarifToolComponent{
+				Name:           "cifuzz",
+				InformationURI: "https://github.com/CodeIntelligenceTesting/cifuzz",
+			},
+		},
+		OriginalURIBaseIDs: map[string]*sarifArtifactLocation{
+			sarifSrcRoot: {URI: fileURI(projectDir) + "/"},
+		},
+		// Results must be an empty array instead of null if there are
+		// no findings, which means that the run didn't find anything
+		Results: []*sarifResult{},
+	}
+
+	rules := map[string]*sarifReportingRule{}
+	// The highest severity score of the findings of each rule
+	scores := map[string]float32{}
+	for _, f := range findings {
+		result := sarifResultFromFinding(f, projectDir)
+		run.Results = append(run.Results, result)
+
+		if _, ok := rules[result.RuleID]; !ok {
+			rules[result.RuleID] = sarifRule(result.RuleID)
+		}
+		if score := severityScore(f); score > scores[result.RuleID] {
+			scores[result.RuleID] = score
+		}
+	}
+
+	// Sort the rules to produce a deterministic output
+	for id, rule := range rules {
+		if scores[id] != 0 {
+			// This property is used by GitHub code scanning to
+			// determine the severity of security issues. The
+			// severity of findings of the same kind can differ,
+			// e.g. out-of-bounds writes are more severe than reads,
+			// so the rule gets the highest severity of its findings.
+			rule.Properties = map[string]interface{}{
+				"security-severity": sarifSecuritySeverity(scores[id]),
+			}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	sarif := &sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []*sarifRun{run},
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	// Function names of C++ templates contain angle brackets, which
+	// should not be escaped
+	encoder.SetEscapeHTML(false)
+	return errors.WithStack(encoder.Encode(sarif))
+}
+
+func sarifResultFromFinding(f *Finding, projectDir string) *sarifResult {
+	message := f.ShortDescription
+	if message == "" {
+		message = f.Details
+	}
+	if summary := f.CrashSummary(); summary != "" && f.ShortDescription == "" {
+		message = fmt.Sprintf("%s (%s)", message, summary)
+	}
+
+	result := &sarifResult{
+		RuleID:  sarifRuleID(f),
+		Level:   sarifLevel(f),
+		Message: &sarifMessage{Text: message},
+		Properties: map[string]interface{}{
+			"name": f.Name,
+		},
+	}
+	if f.FuzzTest != "" {
+		result.Properties["fuzzTest"] = f.FuzzTest
+	}
+	if score := severityScore(f); score != 0 {
+		result.Properties["security-severity"] = sarifSecuritySeverity(score)
+	}
+	if f.Signature != "" {
+		result.PartialFingerprints = map[string]string{"cifuzzSignature/v1": f.Signature}
+	}
+
+	// The location of the crash is the first frame in the code under
+	// test, the full stack trace is described as a code flow.
+	if crashLocation := f.CrashLocation(); crashLocation != nil {
+		if location := sarifLocationFromFrame(crashLocation, projectDir); location != nil {
+			result.Locations = []*sarifLocation{location}
+		}
+	}
+	if codeFlow := sarifCodeFlowFromStackTrace("", f.StackTrace, projectDir); codeFlow != nil {
+		result.CodeFlows = append(result.CodeFlows, codeFlow)
+	}
+	// The stack traces of the leaks, the conflicting accesses of a
+	// data race and the origin of an uninitialized value are further
+	// code flows
+	if details := f.MoreDetails; details != nil {
+		for _, leak := range details.Leaks {
+			codeFlow := sarifCodeFlowFromStackTrace(leak.Description()+" allocated here", leak.StackTrace, projectDir)
+			if codeFlow != nil {
+				result.CodeFlows = append(result.CodeFlows, codeFlow)
+			}
+		}
+		for i, access := range details.Accesses {
+			label := "Access"
+			if i > 0 {
+				label = "Previous access"
+			}
+			message := fmt.Sprintf("%s: %s by %s", label, access.Description(), access.Thread)
+			codeFlow := sarifCodeFlowFromStackTrace(message, access.StackTrace, projectDir)
+			if codeFlow != nil {
+				result.CodeFlows = append(result.CodeFlows, codeFlow)
+			}
+		}
+		if origin := details.Origin; origin != nil {
+			message := "Uninitialized value was created by " + origin.Description
+			codeFlow := sarifCodeFlowFromStackTrace(message, origin.StackTrace, projectDir)
+			if codeFlow != nil {
+				result.CodeFlows = append(result.CodeFlows, codeFlow)
+			}
+		}
+	}
+
+	if f.InputFile != "" {
+		result.Attachments = []*sarifAttachment{{
+			Description:      &sarifMessage{Text: "Crashing input"},
+			ArtifactLocation: sarifArtifactLocationFromPath(f.InputFile, projectDir),
+		}}
+	}
+
+	return result
+}
+
+// sarifCodeFlowFromStackTrace describes the stack trace as a code flow
+// with the given message. It returns nil if none of the frames has a
+// location.
+func sarifCodeFlowFromStackTrace(message string, stackTrace []*StackFrame, projectDir string) *sarifCodeFlow {
+	var threadFlowLocations []*sarifThreadFlowLocation
+	// SARIF expects the locations of a thread flow in the order in
+	// which they were executed, which is the reverse order of the
+	// stack trace.
+	for i := len(stackTrace) - 1; i >= 0; i-- {
+		location := sarifLocationFromFrame(stackTrace[i], projectDir)
+		if location != nil {
+			threadFlowLocations = append(threadFlowLocations, &sarifThreadFlowLocation{Location: location})
+		}
+	}
+	if len(threadFlowLocations) == 0 {
+		return nil
+	}
+	codeFlow := &sarifCodeFlow{
+		ThreadFlows: []*sarifThreadFlow{{Locations: threadFlowLocations}},
+	}
+	if message != "" {
+		codeFlow.Message = &sarifMessage{Text: message}
+	}
+	return codeFlow
+}
+
+// sarifRule returns the rule with the given ID. The rule describes the
+// kind of bug, so its name is derived from the ID instead of the
+// details of a finding, which are specific to that finding (e.g.
+// "Heap buffer overflow: write of size 4").
+func sarifRule(id string) *sarifReportingRule {
+	name := id
+	if strings.ToUpper(name) == name {
+		// Error types like RUNTIME_ERROR
+		name = strings.ToLower(name)
+	}
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	name = strings.ToUpper(name[:1]) + name[1:]
+	return &sarifReportingRule{
+		ID:               id,
+		Name:             name,
+		ShortDescription: &sarifMessage{Text: name},
+	}
+}
+
+func sarifSecuritySeverity(score float32) string {
+	return fmt.Sprintf("%.1f", score)
+}
+
+// severityScore returns the severity score of the finding or zero if
+// the finding doesn't have one
+func severityScore(f *Finding) float32 {
+	if f.MoreDetails == nil || f.MoreDetails.Severity == nil {
+		return 0
+	}
+	return f.MoreDetails.Severity.Score
+}
+
+// sarifRuleID returns the ID of the rule which the finding violates,
+// which is the ID of the error details if available or else the type
+// of the finding.
+func sarifRuleID(f *Finding) string {
+	if f.MoreDetails != nil && f.MoreDetails.Id != "" {
+		return f.MoreDetails.Id
+	}
+	if f.Type != "" {
+		return string(f.Type)
+	}
+	return string(ErrorType_UNKNOWN_ERROR)
+}
+
+func sarifLevel(f *Finding) string {
+	if score := severityScore(f); score != 0 {
+		switch {
+		case score >= 7.0:
+			return sarifLevelError
+		case score >= 4.0:
+			return sarifLevelWarning
+		default:
+			return sarifLevelNote
+		}
+	}
+
+	switch f.Type {
+	case ErrorType_CRASH, ErrorType_RUNTIME_ERROR:
+		return sarifLevelError
+	case ErrorType_WARNING:
+		return sarifLevelWarning
+	default:
+		return sarifLevelNote
+	}
+}
+
+func sarifLocationFromFrame(frame *StackFrame, projectDir string) *sarifLocation {
+	if frame.File == "" {
+		return nil
+	}
+	location := &sarifLocation{
+		PhysicalLocation: &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocationFromPath(frame.File, projectDir),
+		},
+	}
+	if frame.Line != 0 {
+		location.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   frame.Line,
+			StartColumn: frame.Column,
+		}
+	}
+	if frame.Function != "" {
+		location.LogicalLocations = []*sarifLogicalLocation{{
+			FullyQualifiedName: frame.Function,
+			Kind:               "function",
+		}}
+	}
+	return location
+}
+
+// sarifArtifactLocationFromPath returns a location relative to the
+// project directory if the path is below it or else an absolute file
+// URI.
+func sarifArtifactLocationFromPath(path string, projectDir string) *sarifArtifactLocation {
+	if !filepath.IsAbs(path) {
+		// Relative paths in stack traces and the paths of crashing
+		// inputs are relative to the project directory
+		return &sarifArtifactLocation{URI: filepath.ToSlash(path), URIBaseID: sarifSrcRoot}
+	}
+
+	relPath, err := filepath.Rel(projectDir, path)
+	if err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return &sarifArtifactLocation{URI: filepath.ToSlash(relPath), URIBaseID: sarifSrcRoot}
+	}
+	return &sarifArtifactLocation{URI: fileURI(path)}
+}
+
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths like C:/foo
+		path = "/" + path
+	}
+	u := &url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
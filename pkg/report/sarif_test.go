@@ -0,0 +1,215 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/parser/sanitizer"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+var updateGoldenFiles = flag.Bool("update", false, "Update the golden files of the SARIF tests")
+
+// The tests of the report package change the working directory, so
+// we have to determine the absolute path of the test data before.
+var sarifTestDataDir, _ = filepath.Abs(filepath.Join("testdata", "sarif"))
+
+func TestWriteSARIF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The golden files contain Unix paths")
+	}
+
+	tests := []struct {
+		name     string
+		findings []*report.Finding
+	}{
+		{
+			name:     "no_findings",
+			findings: nil,
+		},
+		{
+			name: "heap_buffer_overflow",
+			findings: []*report.Finding{
+				findingFromLog(t, "heap_buffer_overflow", "lucid_turing"),
+			},
+		},
+		{
+			name: "undefined_behavior",
+			findings: []*report.Finding{
+				findingFromLog(t, "undefined_behavior", "brave_hopper"),
+			},
+		},
+		{
+			name: "memory_leak",
+			findings: []*report.Finding{
+				findingFromLog(t, "memory_leak", "eager_curie"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := report.WriteSARIF(&out, tt.findings, "/project")
+			require.NoError(t, err)
+
+			goldenFile := filepath.Join(sarifTestDataDir, tt.name+".sarif")
+			if *updateGoldenFiles {
+				err = os.WriteFile(goldenFile, out.Bytes(), 0644)
+				require.NoError(t, err)
+			}
+			expected, err := os.ReadFile(goldenFile)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), out.String())
+		})
+	}
+}
+
+func TestWriteSARIF_Level(t *testing.T) {
+	tests := []struct {
+		name             string
+		errorType        report.ErrorType
+		severity         *report.Severity
+		expectedLevel    string
+		expectedSeverity string
+	}{
+		{name: "critical", severity: &report.Severity{Score: 9.5}, expectedLevel: "error", expectedSeverity: "9.5"},
+		{name: "high", severity: &report.Severity{Score: 7.0}, expectedLevel: "error", expectedSeverity: "7.0"},
+		{name: "below high", severity: &report.Severity{Score: 6.9}, expectedLevel: "warning", expectedSeverity: "6.9"},
+		{name: "medium", severity: &report.Severity{Score: 4.0}, expectedLevel: "warning", expectedSeverity: "4.0"},
+		{name: "low", severity: &report.Severity{Score: 3.9}, expectedLevel: "note", expectedSeverity: "3.9"},
+		// Without a severity, the level is derived from the type
+		{name: "no severity crash", errorType: report.ErrorType_CRASH, expectedLevel: "error"},
+		{name: "no severity warning", errorType: report.ErrorType_WARNING, expectedLevel: "warning"},
+		{name: "no severity unknown", errorType: report.ErrorType_UNKNOWN_ERROR, expectedLevel: "note"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finding := &report.Finding{
+				Name:        "lucid_turing",
+				Type:        tt.errorType,
+				Details:     "oops",
+				MoreDetails: &report.ErrorDetails{Id: "some-bug", Severity: tt.severity},
+			}
+			var out bytes.Buffer
+			err := report.WriteSARIF(&out, []*report.Finding{finding}, "/project")
+			require.NoError(t, err)
+
+			var sarif struct {
+				Runs []struct {
+					Tool struct {
+						Driver struct {
+							Rules []struct {
+								Properties map[string]string `json:"properties"`
+							} `json:"rules"`
+						} `json:"driver"`
+					} `json:"tool"`
+					Results []struct {
+						Level string `json:"level"`
+					} `json:"results"`
+				} `json:"runs"`
+			}
+			err = json.Unmarshal(out.Bytes(), &sarif)
+			require.NoError(t, err)
+			require.Len(t, sarif.Runs, 1)
+			require.Len(t, sarif.Runs[0].Results, 1)
+			assert.Equal(t, tt.expectedLevel, sarif.Runs[0].Results[0].Level)
+			require.Len(t, sarif.Runs[0].Tool.Driver.Rules, 1)
+			assert.Equal(t, tt.expectedSeverity, sarif.Runs[0].Tool.Driver.Rules[0].Properties["security-severity"])
+		})
+	}
+}
+
+func TestWriteSARIF_RuleOfMultipleFindings(t *testing.T) {
+	findings := []*report.Finding{
+		{
+			Name: "lucid_turing",
+			MoreDetails: &report.ErrorDetails{
+				Id:       "heap-buffer-overflow",
+				Name:     "Heap buffer overflow: read of size 4",
+				Severity: &report.Severity{Score: 5.5},
+			},
+		},
+		{
+			Name: "brave_hopper",
+			MoreDetails: &report.ErrorDetails{
+				Id:       "heap-buffer-overflow",
+				Name:     "Heap buffer overflow: write of size 4",
+				Severity: &report.Severity{Score: 8.0},
+			},
+		},
+	}
+	var out bytes.Buffer
+	err := report.WriteSARIF(&out, findings, "/project")
+	require.NoError(t, err)
+
+	var sarif struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						Name       string            `json:"name"`
+						Properties map[string]string `json:"properties"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				Level      string                 `json:"level"`
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	err = json.Unmarshal(out.Bytes(), &sarif)
+	require.NoError(t, err)
+	require.Len(t, sarif.Runs, 1)
+
+	// The rule describes the kind of bug and has the highest severity
+	// of its findings
+	require.Len(t, sarif.Runs[0].Tool.Driver.Rules, 1)
+	rule := sarif.Runs[0].Tool.Driver.Rules[0]
+	assert.Equal(t, "Heap buffer overflow", rule.Name)
+	assert.Equal(t, "8.0", rule.Properties["security-severity"])
+
+	// Each result has the severity of its finding
+	require.Len(t, sarif.Runs[0].Results, 2)
+	assert.Equal(t, "warning", sarif.Runs[0].Results[0].Level)
+	assert.Equal(t, "5.5", sarif.Runs[0].Results[0].Properties["security-severity"])
+	assert.Equal(t, "error", sarif.Runs[0].Results[1].Level)
+	assert.Equal(t, "8.0", sarif.Runs[0].Results[1].Properties["security-severity"])
+}
+
+// findingFromLog creates a finding from a sanitizer report in the
+// test data, the same way the libFuzzer output parser does.
+func findingFromLog(t *testing.T, name, findingName string) *report.Finding {
+	bytes, err := os.ReadFile(filepath.Join(sarifTestDataDir, name+".log"))
+	require.NoError(t, err)
+	logs := strings.Split(strings.TrimSpace(string(bytes)), "\n")
+
+	var finding *report.Finding
+	for _, line := range logs {
+		finding = sanitizer.ParseAsFinding(line)
+		if finding != nil {
+			break
+		}
+	}
+	require.NotNil(t, finding)
+
+	finding.Name = findingName
+	finding.FuzzTest = "my_fuzz_test"
+	finding.Logs = logs
+	finding.StackTrace = sanitizer.ParseStackTrace(logs)
+	finding.Signature = sanitizer.CrashSignature(finding.Details, finding.StackTrace)
+	finding.MoreDetails = sanitizer.ParseErrorDetails(logs)
+	finding.InputFile = filepath.Join("/project", ".cifuzz-findings", findingName, "crashing-input")
+	return finding
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type StackFrame struct {
+	Address  uint64 `json:"address,omitempty"`
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     uint32 `json:"line,omitempty"`
+	Column   uint32 `json:"column,omitempty"`
+	// The binary or library which contains the function, if the frame
+	// is not symbolized
+	Module string `json:"module,omitempty"`
+}
+
+// Frames of functions with these prefixes belong to the sanitizer
+// runtimes, the fuzzing engines or the language runtimes and don't
+// help to identify where a crash happened
+var runtimeFunctionPrefixes = []string{
+	// Sanitizer runtimes and libFuzzer
+	"__sanitizer",
+	"__asan",
+	"__msan",
+	"__tsan",
+	"__lsan",
+	"__ubsan",
+	"__interceptor_",
+	"__interception::",
+	"fuzzer::Fuzzer::",
+	"fuzzer::RunOneTest",
+	"fuzzer::FuzzerDriver",
+	// libc
+	"__libc_start",
+	// Go
+	"runtime.",
+	"runtime/",
+	"testing.",
+	"reflect.",
+	// Rust and libfuzzer-sys. We can't match all of Rust's std
+	// namespace, because that's also the namespace of the C++ standard
+	// library.
+	"std::panicking::",
+	"std::panic::",
+	"std::process::abort",
+	"std::sys::",
+	"std::rt::",
+	"core::panicking::",
+	"core::ops::function::",
+	"__rust_",
+	"rust_panic",
+	"libfuzzer_sys::",
+	// Java and Jazzer
+	"java.",
+	"jdk.internal.",
+	"sun.",
+	"com.code_intelligence.jazzer.",
+}
+
+// IsRuntimeFrame returns true if the frame belongs to a sanitizer
+// runtime, a fuzzing engine or a language runtime instead of the code
+// under test.
+func (f *StackFrame) IsRuntimeFrame() bool {
+	switch f.Function {
+	case "panic":
+		// The Go builtin which was called to panic
+		return true
+	case "_start":
+		// The entry point of the program
+		return true
+	}
+	for _, prefix := range runtimeFunctionPrefixes {
+		if strings.HasPrefix(f.Function, prefix) {
+			return true
+		}
+	}
+	// Frames in the sanitizer runtimes which are not covered by the
+	// function prefixes, e.g. interceptors of libc functions
+	return strings.Contains(filepath.ToSlash(f.File), "compiler-rt/")
+}
+
+// Location returns the base name of the file and the line of the frame
+// in the form "bar.c:42", or the module if the frame is not symbolized.
+func (f *StackFrame) Location() string {
+	if f.File == "" {
+		return f.Module
+	}
+	location := path.Base(filepath.ToSlash(f.File))
+	if f.Line != 0 {
+		location += fmt.Sprintf(":%d", f.Line)
+	}
+	return location
+}
+
+// CrashLocation returns the first frame of the stack trace which
+// belongs to the code under test, or nil if there is no such frame.
+func (f *Finding) CrashLocation() *StackFrame {
+	for _, frame := range f.StackTrace {
+		if frame.Function != "" && !frame.IsRuntimeFrame() {
+			return frame
+		}
+	}
+	return nil
+}
+
+// CrashSummary returns a short summary of where the crash happened,
+// like "crashed in foo() at bar.c:42", or an empty string if the
+// location is unknown.
+func (f *Finding) CrashSummary() string {
+	frame := f.CrashLocation()
+	if frame == nil {
+		return ""
+	}
+
+	function := frame.Function
+	if !strings.HasSuffix(function, ")") {
+		function += "()"
+	}
+	summary := "crashed in " + function
+	if location := frame.Location(); location != "" {
+		summary += " at " + location
+	}
+	return summary
+}
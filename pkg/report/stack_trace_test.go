@@ -0,0 +1,74 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinding_CrashSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		stackTrace []*StackFrame
+		expected   string
+	}{
+		{
+			name:       "no stack trace",
+			stackTrace: nil,
+			expected:   "",
+		},
+		{
+			name: "sanitizer frames are skipped",
+			stackTrace: []*StackFrame{
+				{Function: "__asan_memcpy", File: "/llvm-project/compiler-rt/lib/asan/asan_interceptors_memintrinsics.cpp", Line: 22},
+				{Function: "parse_header", File: "/src/http.c", Line: 120, Column: 7},
+				{Function: "LLVMFuzzerTestOneInput", File: "/src/fuzz_test.cpp", Line: 12},
+			},
+			expected: "crashed in parse_header() at http.c:120",
+		},
+		{
+			name: "Go runtime frames are skipped",
+			stackTrace: []*StackFrame{
+				{Function: "panic", File: "/usr/lib/go/src/runtime/panic.go", Line: 1038},
+				{Function: "example.com/http.(*Parser).Parse", File: "/src/http/parser.go", Line: 42},
+			},
+			expected: "crashed in example.com/http.(*Parser).Parse() at parser.go:42",
+		},
+		{
+			name: "libFuzzer and libc frames are skipped",
+			stackTrace: []*StackFrame{
+				{Function: "fuzzer::Fuzzer::ExecuteCallback(unsigned char const*, unsigned long)", File: "/llvm-project/compiler-rt/lib/fuzzer/FuzzerLoop.cpp", Line: 611},
+				{Function: "_start"},
+				{Function: "_start_parser", File: "/src/parser.c", Line: 7},
+			},
+			expected: "crashed in _start_parser() at parser.c:7",
+		},
+		{
+			name: "user namespace fuzzer is not skipped",
+			stackTrace: []*StackFrame{
+				{Function: "fuzzer::ParseConfig(std::string const&)", File: "/src/fuzzer/config.cpp", Line: 30},
+			},
+			expected: "crashed in fuzzer::ParseConfig(std::string const&) at config.cpp:30",
+		},
+		{
+			name: "C++ function with parameters",
+			stackTrace: []*StackFrame{
+				{Function: "handle_request(char const*, unsigned long)", File: "/src/server.cpp", Line: 42},
+			},
+			expected: "crashed in handle_request(char const*, unsigned long) at server.cpp:42",
+		},
+		{
+			name: "unsymbolized frames",
+			stackTrace: []*StackFrame{
+				{Address: 0x41d5ad, Module: "/src/my_fuzz_test"},
+			},
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finding := &Finding{StackTrace: tt.stackTrace}
+			assert.Equal(t, tt.expected, finding.CrashSummary())
+		})
+	}
+}
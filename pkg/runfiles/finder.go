@@ -12,6 +12,38 @@ type RunfilesFinderImpl struct {
 	InstallDir string
 }
 
+func (f RunfilesFinderImpl) AFLClangPath() (string, error) {
+	// Prefer afl-clang-lto, which provides collision-free edge coverage,
+	// over afl-clang-fast if it's available
+	path, err := exec.LookPath("afl-clang-lto")
+	if err == nil {
+		return path, nil
+	}
+	path, err = exec.LookPath("afl-clang-fast")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) AFLFuzzPath() (string, error) {
+	path, err := exec.LookPath("afl-fuzz")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) BazelPath() (string, error) {
+	// Bazelisk is usually installed as "bazel", but we also support it
+	// being installed under its own name
+	path, err := exec.LookPath("bazel")
+	if err == nil {
+		return path, nil
+	}
+	path, err = exec.LookPath("bazelisk")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) CargoPath() (string, error) {
+	path, err := exec.LookPath("cargo")
+	return path, errors.WithStack(err)
+}
+
 func (f RunfilesFinderImpl) CIFuzzIncludePath() (string, error) {
 	return f.findFollowSymlinks("share/cifuzz/include/cifuzz")
 }
@@ -21,6 +53,33 @@ func (f RunfilesFinderImpl) ClangPath() (string, error) {
 	return path, errors.WithStack(err)
 }
 
+func (f RunfilesFinderImpl) GoPath() (string, error) {
+	path, err := exec.LookPath("go")
+	return path, errors.WithStack(err)
+}
+
+// GoFuzzBuildPath returns the path of go-118-fuzz-build, which builds
+// native Go fuzz tests as libFuzzer fuzz targets
+func (f RunfilesFinderImpl) GoFuzzBuildPath() (string, error) {
+	path, err := exec.LookPath("go-118-fuzz-build")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) GradlePath() (string, error) {
+	path, err := exec.LookPath("gradle")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) HonggfuzzClangPath() (string, error) {
+	path, err := exec.LookPath("hfuzz-clang")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) HonggfuzzPath() (string, error) {
+	path, err := exec.LookPath("honggfuzz")
+	return path, errors.WithStack(err)
+}
+
 func (f RunfilesFinderImpl) JazzerAgentDeployJarPath() (string, error) {
 	return f.findFollowSymlinks("bin/jazzer_driver")
 }
@@ -48,6 +107,16 @@ func (f RunfilesFinderImpl) LLVMSymbolizerPath() (string, error) {
 	return path, errors.WithStack(err)
 }
 
+func (f RunfilesFinderImpl) MavenPath() (string, error) {
+	path, err := exec.LookPath("mvn")
+	return path, errors.WithStack(err)
+}
+
+func (f RunfilesFinderImpl) MesonPath() (string, error) {
+	path, err := exec.LookPath("meson")
+	return path, errors.WithStack(err)
+}
+
 func (f RunfilesFinderImpl) Minijail0Path() (string, error) {
 	return f.findFollowSymlinks("bin/minijail0")
 }
@@ -60,6 +129,11 @@ func (f RunfilesFinderImpl) ReplayerSourcePath() (string, error) {
 	return f.findFollowSymlinks("share/cifuzz/src/replayer.c")
 }
 
+func (f RunfilesFinderImpl) RustcPath() (string, error) {
+	path, err := exec.LookPath("rustc")
+	return path, errors.WithStack(err)
+}
+
 func (f RunfilesFinderImpl) findFollowSymlinks(relativePath string) (string, error) {
 	absolutePath := filepath.Join(f.InstallDir, relativePath)
 
@@ -12,17 +12,29 @@ import (
 )
 
 type RunfilesFinder interface {
+	AFLClangPath() (string, error)
+	AFLFuzzPath() (string, error)
+	BazelPath() (string, error)
+	CargoPath() (string, error)
 	CIFuzzIncludePath() (string, error)
 	ClangPath() (string, error)
+	GoPath() (string, error)
+	GoFuzzBuildPath() (string, error)
+	GradlePath() (string, error)
+	HonggfuzzClangPath() (string, error)
+	HonggfuzzPath() (string, error)
 	JazzerAgentDeployJarPath() (string, error)
 	JazzerDriverPath() (string, error)
 	LibMinijailPreloadPath() (string, error)
 	LLVMCovPath() (string, error)
 	LLVMProfDataPath() (string, error)
 	LLVMSymbolizerPath() (string, error)
+	MavenPath() (string, error)
+	MesonPath() (string, error)
 	Minijail0Path() (string, error)
 	ProcessWrapperPath() (string, error)
 	ReplayerSourcePath() (string, error)
+	RustcPath() (string, error)
 }
 
 var Finder RunfilesFinder
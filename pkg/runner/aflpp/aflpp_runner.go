@@ -0,0 +1,480 @@
+package aflpp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/minijail"
+	aflpp_parser "code-intelligence.com/cifuzz/pkg/parser/aflpp"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	// The interval in which the fuzzer_stats file and the crashes
+	// directory are checked for updates
+	pollInterval = time.Second
+	// ExitGracePeriod is the time we give afl-fuzz to exit after the
+	// timeout was exceeded.
+	ExitGracePeriod = time.Second * 5
+	// The maximum time a crashing input is replayed to obtain the
+	// sanitizer output
+	replayTimeout = time.Minute
+)
+
+type RunnerOptions struct {
+	FuzzTarget         string
+	GeneratedCorpusDir string
+	SeedCorpusDirs     []string
+	Dictionary         string
+	LibraryDirs        []string
+	EnvVars            []string
+	EngineArgs         []string
+	FuzzTestArgs       []string
+	ReportHandler      report.Handler
+	Timeout            time.Duration
+	UseMinijail        bool
+	Verbose            bool
+	LogOutput          io.Writer
+}
+
+func (options *RunnerOptions) ValidateOptions() error {
+	if runtime.GOOS == "windows" {
+		return errors.New("AFL++ is not supported on Windows")
+	}
+
+	if options.UseMinijail {
+		if runtime.GOOS != "linux" {
+			return errors.Errorf("Minijail is only supported on Linux")
+		}
+
+		// To be able to make the fuzz target accessible to minijail,
+		// its path must be absolute and all symlinks must be resolved.
+		var err error
+		options.FuzzTarget, err = filepath.EvalSymlinks(options.FuzzTarget)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		options.FuzzTarget, err = filepath.Abs(options.FuzzTarget)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if options.LogOutput == nil {
+		options.LogOutput = os.Stderr
+	}
+
+	return nil
+}
+
+// Runner runs afl-fuzz on a fuzz test which was built with the AFL++
+// compiler wrappers. In contrast to libFuzzer, afl-fuzz doesn't print
+// its findings and metrics in a format which we can parse, so instead
+// we poll the fuzzer_stats file and the crashes directory which it
+// writes to its output directory.
+type Runner struct {
+	*RunnerOptions
+
+	cmd *executil.Cmd
+}
+
+func NewRunner(options *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: options}
+}
+
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	aflFuzz, err := runfiles.Finder.AFLFuzzPath()
+	if err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "cifuzz-afl-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(workDir)
+	// Ensure that symlinks are resolved to be able to add minijail
+	// bindings for the directories.
+	workDir, err = filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	inputDir := filepath.Join(workDir, "input")
+	outputDir := filepath.Join(workDir, "output")
+	crashesDir := filepath.Join(workDir, "crashes")
+	for _, dir := range []string{inputDir, outputDir, crashesDir} {
+		err = os.Mkdir(dir, 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// afl-fuzz only supports a single input directory, so we copy the
+	// inputs of the generated corpus and the seed corpus directories
+	// into one.
+	numSeeds, err := fuzzer_runner.CopyInputs(append([]string{r.GeneratedCorpusDir}, r.SeedCorpusDirs...), inputDir)
+	if err != nil {
+		return err
+	}
+	if numSeeds == 0 {
+		// afl-fuzz refuses to start with an empty input directory
+		err = os.WriteFile(filepath.Join(inputDir, "empty"), []byte("\n"), 0644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = r.ReportHandler.Handle(&report.Report{Status: report.RunStatus_INITIALIZING, NumSeeds: numSeeds})
+	if err != nil {
+		return err
+	}
+
+	args := []string{aflFuzz, "-i", inputDir, "-o", outputDir}
+	if r.Timeout > 0 {
+		// Tell afl-fuzz to exit after the timeout
+		args = append(args, "-V", strconv.FormatInt(int64(r.Timeout.Seconds()), 10))
+	}
+	if r.Dictionary != "" {
+		args = append(args, "-x", r.Dictionary)
+	}
+	// Add user-specified afl-fuzz options
+	args = append(args, r.EngineArgs...)
+	args = append(args, "--", r.FuzzTarget)
+	args = append(args, r.FuzzTestArgs...)
+
+	fuzzerEnv, err := r.fuzzerEnvironment()
+	if err != nil {
+		return err
+	}
+	wrapperEnv := os.Environ()
+
+	if r.UseMinijail {
+		bindings := []*minijail.Binding{
+			{Source: r.FuzzTarget},
+			{Source: inputDir},
+			{Source: outputDir, Writable: minijail.ReadWrite},
+		}
+		if r.Dictionary != "" {
+			bindings = append(bindings, &minijail.Binding{Source: r.Dictionary})
+		}
+		mj, err := minijail.NewMinijail(&minijail.Options{
+			Args:     args,
+			Bindings: bindings,
+			Env:      fuzzerEnv,
+		})
+		if err != nil {
+			return err
+		}
+		defer mj.Cleanup()
+		args = mj.Args
+	} else {
+		for key, value := range envutil.ToMap(fuzzerEnv) {
+			wrapperEnv, err = envutil.Setenv(wrapperEnv, key, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// afl-fuzz exits on its own after the timeout, because we
+	// specified -V above. For the case that it does not, it's
+	// terminated a bit later.
+	var cmdCtx context.Context
+	var cancelCmdCtx context.CancelFunc
+	if r.Timeout > 0 {
+		cmdCtx, cancelCmdCtx = context.WithTimeout(ctx, r.Timeout+ExitGracePeriod)
+	} else {
+		cmdCtx, cancelCmdCtx = context.WithCancel(ctx)
+	}
+	defer cancelCmdCtx()
+	r.cmd = executil.CommandContext(cmdCtx, args[0], args[1:]...)
+	r.cmd.Env = wrapperEnv
+
+	// The output of afl-fuzz is not parsed, so it's only printed in
+	// verbose mode. Else, it's kept to print it in case afl-fuzz fails.
+	var output bytes.Buffer
+	if r.Verbose {
+		r.cmd.Stdout = log.NewPTermWriter(r.LogOutput)
+		r.cmd.Stderr = log.NewPTermWriter(r.LogOutput)
+	} else {
+		r.cmd.Stdout = &output
+		r.cmd.Stderr = &output
+	}
+
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(r.cmd.Args), " "))
+	err = r.cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	waitErrCh := make(chan error, 1)
+	go func() {
+		waitErrCh <- r.cmd.Wait()
+	}()
+
+	// afl-fuzz creates a directory for each fuzzer instance in the
+	// output directory, the default instance is called "default"
+	parser := aflpp_parser.NewParser(filepath.Join(outputDir, "default"))
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var waitErr error
+	running := true
+	for running {
+		select {
+		case waitErr = <-waitErrCh:
+			running = false
+		case <-ticker.C:
+		}
+		// Check for updates once more after afl-fuzz exited, to not
+		// miss any crashes which were found right before
+		err = r.reportUpdates(ctx, parser, crashesDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	if waitErr != nil && !r.cmd.TerminatedAfterContextDone() {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return errors.WithStack(waitErr)
+		}
+		if !r.Verbose {
+			log.Print(output.String())
+		}
+		return cmdutils.WrapExecError(waitErr, r.cmd.Cmd)
+	}
+
+	// Add the inputs which afl-fuzz found to the generated corpus
+	_, err = fuzzer_runner.CopyInputs([]string{filepath.Join(outputDir, "default", "queue")}, r.GeneratedCorpusDir)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reportUpdates sends reports for new metrics and findings to the
+// report handler.
+func (r *Runner) reportUpdates(ctx context.Context, parser *aflpp_parser.Parser, crashesDir string) error {
+	metric, err := parser.NewMetric()
+	if err != nil {
+		return err
+	}
+	if metric != nil {
+		err = r.ReportHandler.Handle(&report.Report{Status: report.RunStatus_RUNNING, Metric: metric})
+		if err != nil {
+			return err
+		}
+	}
+
+	findings, err := parser.NewFindings()
+	if err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		// The names of the crashing inputs written by afl-fuzz contain
+		// commas and colons, which are separators in minijail bindings
+		// and the like, so we copy the input to a path with a simple
+		// name.
+		hash := sha1.Sum(finding.InputData)
+		inputFile := filepath.Join(crashesDir, "crash-"+hex.EncodeToString(hash[:]))
+		err = os.WriteFile(inputFile, finding.InputData, 0644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		finding.InputFile = inputFile
+
+		logs, err := r.replay(ctx, inputFile)
+		if err != nil {
+			return err
+		}
+		aflpp_parser.AddReplayOutput(finding, logs)
+
+		err = r.ReportHandler.Handle(&report.Report{Status: report.RunStatus_RUNNING, Finding: finding})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replay executes the fuzz test on the crashing input to obtain the
+// sanitizer output, which afl-fuzz doesn't keep.
+func (r *Runner) replay(ctx context.Context, input string) ([]string, error) {
+	args := []string{r.FuzzTarget}
+	args = append(args, r.FuzzTestArgs...)
+	// The AFL++ driver executes the fuzz test on the files passed as
+	// arguments
+	args = append(args, input)
+
+	env, err := r.replayEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	wrapperEnv := os.Environ()
+
+	if r.UseMinijail {
+		mj, err := minijail.NewMinijail(&minijail.Options{
+			Args:     args,
+			Bindings: []*minijail.Binding{{Source: r.FuzzTarget}, {Source: input}},
+			Env:      env,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer mj.Cleanup()
+		args = mj.Args
+	} else {
+		for key, value := range envutil.ToMap(env) {
+			wrapperEnv, err = envutil.Setenv(wrapperEnv, key, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	replayCtx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+	cmd := executil.CommandContext(replayCtx, args[0], args[1:]...)
+	cmd.Env = wrapperEnv
+	var output bytes.Buffer
+	if r.UseMinijail {
+		cmd.Stderr = minijail.NewOutputFilter(&output)
+	} else {
+		cmd.Stderr = &output
+	}
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+	// The fuzz test is expected to fail, so the error is ignored
+	_ = cmd.Run()
+
+	return strings.Split(strings.TrimRight(output.String(), "\n"), "\n"), nil
+}
+
+func (r *Runner) commonEnvironment() ([]string, error) {
+	env, err := fuzzer_runner.FuzzerEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	env, err = fuzzer_runner.SetLDLibraryPath(env, r.LibraryDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the user-specified environment variables. We do this after
+	// setting our defaults but before setting sanitizer options,
+	// because there we take care of overriding options which we need
+	// to override and keeping other options.
+	env, err = fuzzer_runner.AddEnvFlags(env, r.EnvVars)
+	if err != nil {
+		return nil, err
+	}
+
+	return fuzzer_runner.SetCommonSanitizerOptions(env)
+}
+
+func (r *Runner) fuzzerEnvironment() ([]string, error) {
+	env, err := r.commonEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	// afl-fuzz detects crashes via signals, so the sanitizers must
+	// abort instead of exiting. Symbolizing is not needed, because we
+	// replay the crashes to obtain the sanitizer output, and would only
+	// slow down the fuzzing.
+	env, err = fuzzer_runner.SetASANOptions(env, nil, map[string]string{
+		"abort_on_error": "1",
+		"symbolize":      "0",
+	})
+	if err != nil {
+		return nil, err
+	}
+	ubsanOptions := fuzzer_runner.SetSanitizerOptions(envutil.Getenv(env, "UBSAN_OPTIONS"), nil, map[string]string{
+		"halt_on_error":  "1",
+		"abort_on_error": "1",
+	})
+	env, err = envutil.Setenv(env, "UBSAN_OPTIONS", ubsanOptions)
+	if err != nil {
+		return nil, err
+	}
+	// afl-fuzz refuses to run with MSAN_OPTIONS which don't contain
+	// its expected exit code and symbolize=0
+	msanOptions := fuzzer_runner.SetSanitizerOptions(envutil.Getenv(env, "MSAN_OPTIONS"), nil, map[string]string{
+		"exit_code":      "86",
+		"abort_on_error": "1",
+		"symbolize":      "0",
+	})
+	env, err = envutil.Setenv(env, "MSAN_OPTIONS", msanOptions)
+	if err != nil {
+		return nil, err
+	}
+	tsanOptions := fuzzer_runner.SetSanitizerOptions(envutil.Getenv(env, "TSAN_OPTIONS"), nil, map[string]string{
+		"abort_on_error": "1",
+		"symbolize":      "0",
+	})
+	env, err = envutil.Setenv(env, "TSAN_OPTIONS", tsanOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	aflOptions := map[string]string{
+		// Print status lines instead of the interactive UI
+		"AFL_NO_UI": "1",
+		// Exit when the first crash is found, like libFuzzer does
+		"AFL_BENCH_UNTIL_CRASH": "1",
+		// Don't fail on systems which are not configured for maximum
+		// performance, e.g. in containers where the CPU frequency
+		// scaling and core dump settings can't be changed
+		"AFL_SKIP_CPUFREQ":                      "1",
+		"AFL_I_DONT_CARE_ABOUT_MISSING_CRASHES": "1",
+	}
+	for key, value := range aflOptions {
+		env, err = envutil.Setenv(env, key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+func (r *Runner) replayEnvironment() ([]string, error) {
+	env, err := r.commonEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	return fuzzer_runner.SetASANOptions(env, nil, map[string]string{"abort_on_error": "0"})
+}
+
+func (r *Runner) Cleanup() {
+	if r.cmd != nil {
+		err := r.cmd.TerminateProcessGroup()
+		if err != nil {
+			log.Error(err, err.Error())
+		}
+	}
+}
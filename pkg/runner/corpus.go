@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// CopyInputs copies the regular files in the source directories to the
+// target directory, named after the SHA-1 hash of their content (like
+// libFuzzer names the inputs it adds to the corpus), so that inputs
+// with the same content are only copied once. It returns the number of
+// copied inputs.
+func CopyInputs(sourceDirs []string, targetDir string) (uint, error) {
+	var numCopied uint
+	for _, dir := range sourceDirs {
+		exists, err := fileutil.Exists(dir)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			continue
+		}
+		err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Skip hidden directories, like the .state directory which
+			// afl-fuzz creates in the queue directory
+			if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			hash := sha1.Sum(data)
+			target := filepath.Join(targetDir, hex.EncodeToString(hash[:]))
+			exists, err := fileutil.Exists(target)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return nil
+			}
+			err = os.WriteFile(target, data, 0644)
+			if err != nil {
+				return err
+			}
+			numCopied++
+			return nil
+		})
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+	return numCopied, nil
+}
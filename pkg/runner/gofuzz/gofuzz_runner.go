@@ -0,0 +1,370 @@
+package gofuzz
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/minijail"
+	gofuzz_parser "code-intelligence.com/cifuzz/pkg/parser/gofuzz"
+	"code-intelligence.com/cifuzz/pkg/report"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// ExitGracePeriod is the time we give the fuzz test to exit after the
+// timeout was exceeded.
+const ExitGracePeriod = time.Second * 5
+
+// The header of the files in Go's corpus format
+const corpusFileHeader = "go test fuzz v1\n"
+
+type RunnerOptions struct {
+	// The test binary built with "go test -c -fuzz"
+	FuzzTarget string
+	// The name of the fuzz function, e.g. FuzzParse
+	FuzzTestName string
+	// The directory of the fuzz test's package, which is used as the
+	// working directory of the test binary
+	PackageDir         string
+	GeneratedCorpusDir string
+	SeedCorpusDirs     []string
+	EnvVars            []string
+	EngineArgs         []string
+	FuzzTestArgs       []string
+	ReportHandler      report.Handler
+	Timeout            time.Duration
+	Jobs               int
+	UseMinijail        bool
+	Verbose            bool
+	KeepColor          bool
+	LogOutput          io.Writer
+}
+
+func (options *RunnerOptions) ValidateOptions() error {
+	var err error
+	// The paths must be absolute and all symlinks must be resolved to
+	// be able to make them accessible to minijail and because the test
+	// binary is not executed in the current working directory.
+	options.FuzzTarget, err = filepath.EvalSymlinks(options.FuzzTarget)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	options.FuzzTarget, err = filepath.Abs(options.FuzzTarget)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	options.PackageDir, err = filepath.EvalSymlinks(options.PackageDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	options.PackageDir, err = filepath.Abs(options.PackageDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !strings.HasPrefix(options.FuzzTestName, "Fuzz") {
+		return errors.Errorf("Invalid Go fuzz test name: %q", options.FuzzTestName)
+	}
+
+	if options.LogOutput == nil {
+		options.LogOutput = os.Stderr
+	}
+
+	if options.Jobs < 0 {
+		return errors.Errorf("Invalid number of jobs: %d", options.Jobs)
+	}
+
+	return nil
+}
+
+// Runner runs a native Go fuzz test with Go's fuzzing engine, by
+// executing the test binary with -test.fuzz.
+type Runner struct {
+	*RunnerOptions
+
+	cmd *executil.Cmd
+}
+
+func NewRunner(options *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: options}
+}
+
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	// Go's fuzzing engine stores the generated corpus of a fuzz test in
+	// the subdirectory of the cache directory which is named after the
+	// fuzz test. We let that subdirectory point to the generated corpus
+	// directory.
+	cacheDir, err := os.MkdirTemp("", "cifuzz-go-fuzz-cache-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(cacheDir)
+	cacheDir, err = filepath.EvalSymlinks(cacheDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.Symlink(r.GeneratedCorpusDir, filepath.Join(cacheDir, r.FuzzTestName))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	numSeeds, err := r.prepareCorpus()
+	if err != nil {
+		return err
+	}
+	err = r.ReportHandler.Handle(&report.Report{Status: report.RunStatus_INITIALIZING, NumSeeds: numSeeds})
+	if err != nil {
+		return err
+	}
+
+	jobs := r.Jobs
+	if jobs == 0 {
+		jobs = 1
+	}
+	fuzzTestPattern := "^" + r.FuzzTestName + "$"
+	args := []string{
+		r.FuzzTarget,
+		// Don't run the other tests of the package
+		"-test.run=" + fuzzTestPattern,
+		"-test.fuzz=" + fuzzTestPattern,
+		"-test.fuzzcachedir=" + cacheDir,
+		"-test.parallel=" + strconv.Itoa(jobs),
+	}
+	if r.Timeout > 0 {
+		// Tell the fuzzing engine to stop after the timeout
+		args = append(args, fmt.Sprintf("-test.fuzztime=%ds", int64(r.Timeout.Seconds())))
+	}
+	// Add user-specified flags of the testing package
+	args = append(args, r.EngineArgs...)
+	args = append(args, r.FuzzTestArgs...)
+
+	fuzzerEnv, err := fuzzer_runner.FuzzerEnvironment()
+	if err != nil {
+		return err
+	}
+	fuzzerEnv, err = fuzzer_runner.AddEnvFlags(fuzzerEnv, r.EnvVars)
+	if err != nil {
+		return err
+	}
+	wrapperEnv := os.Environ()
+
+	if r.UseMinijail {
+		bindings := []*minijail.Binding{
+			// The fuzzing engine writes the failing inputs to the
+			// testdata directory of the package
+			{Source: r.PackageDir, Writable: minijail.ReadWrite},
+			{Source: cacheDir, Writable: minijail.ReadWrite},
+			{Source: r.GeneratedCorpusDir, Writable: minijail.ReadWrite},
+		}
+		mj, err := minijail.NewMinijail(&minijail.Options{
+			Args:     args,
+			Bindings: bindings,
+			Env:      fuzzerEnv,
+			WorkDir:  r.PackageDir,
+		})
+		if err != nil {
+			return err
+		}
+		defer mj.Cleanup()
+		args = mj.Args
+	} else {
+		for key, value := range envutil.ToMap(fuzzerEnv) {
+			wrapperEnv, err = envutil.Setenv(wrapperEnv, key, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The fuzzing engine exits on its own after the timeout, because we
+	// specified -test.fuzztime above. For the case that it does not,
+	// it's terminated a bit later.
+	var cmdCtx context.Context
+	var cancelCmdCtx context.CancelFunc
+	if r.Timeout > 0 {
+		cmdCtx, cancelCmdCtx = context.WithTimeout(ctx, r.Timeout+ExitGracePeriod)
+	} else {
+		cmdCtx, cancelCmdCtx = context.WithCancel(ctx)
+	}
+	defer cancelCmdCtx()
+	r.cmd = executil.CommandContext(cmdCtx, args[0], args[1:]...)
+	r.cmd.Dir = r.PackageDir
+	r.cmd.Env = wrapperEnv
+
+	// The testing package prints the failed tests to stdout and the
+	// fuzzing engine prints its status to stderr, so we parse both
+	var outputPipe io.ReadCloser
+	if r.Verbose {
+		var output io.Writer = log.NewPTermWriter(r.LogOutput)
+		if r.UseMinijail {
+			output = minijail.NewOutputFilter(output)
+		}
+		outputPipe, err = r.cmd.StdoutTeePipe(output)
+	} else {
+		outputPipe, err = r.cmd.StdoutPipe()
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.cmd.Stderr = r.cmd.Stdout
+
+	log.Debugf("Working directory: %s", r.cmd.Dir)
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(r.cmd.Args), " "))
+	err = r.cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Parse the output in a go routine and pass the reports to the
+	// report handler
+	parser := gofuzz_parser.NewOutputParser(&gofuzz_parser.Options{
+		KeepColor: r.KeepColor,
+		WorkDir:   r.PackageDir,
+	})
+	reportsCh := make(chan *report.Report)
+	parseErrCh := make(chan error, 1)
+	go func() {
+		parseErrCh <- parser.Parse(ctx, outputPipe, reportsCh)
+	}()
+	var numFindings int
+	for rep := range reportsCh {
+		if rep.Finding != nil {
+			numFindings++
+			if rep.Finding.InputFile != "" {
+				// The fuzzing engine writes the failing input to the
+				// testdata directory, where it's used as a regression
+				// test by "go test". Saving the finding moves the input
+				// file, so we pass a copy to the report handler.
+				rep.Finding.InputFile, err = copyInputFile(rep.Finding.InputFile, cacheDir)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		err = r.ReportHandler.Handle(rep)
+		if err != nil {
+			return err
+		}
+	}
+	err = <-parseErrCh
+	if err != nil {
+		return err
+	}
+
+	err = r.cmd.Wait()
+	// The test binary exits with a non-zero exit code if the fuzz test
+	// failed, which we already reported as a finding
+	if err != nil && !r.cmd.TerminatedAfterContextDone() && numFindings == 0 {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return errors.WithStack(err)
+		}
+		return cmdutils.WrapExecError(err, r.cmd.Cmd)
+	}
+
+	return nil
+}
+
+// prepareCorpus adds the inputs of the user-specified seed corpus
+// directories to the generated corpus, because Go's fuzzing engine
+// only reads the seed corpus from the testdata directory of the package
+// and the generated corpus from its cache directory. It returns the
+// number of inputs the fuzz test is started with.
+func (r *Runner) prepareCorpus() (uint, error) {
+	testdataCorpusDir := filepath.Join(r.PackageDir, "testdata", "fuzz", r.FuzzTestName)
+	for _, dir := range r.SeedCorpusDirs {
+		// The testdata corpus is read by the fuzzing engine itself
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil && resolved == testdataCorpusDir {
+			continue
+		}
+		err := addSeeds(dir, r.GeneratedCorpusDir)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var numSeeds uint
+	for _, dir := range []string{testdataCorpusDir, r.GeneratedCorpusDir} {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				numSeeds++
+			}
+		}
+	}
+	return numSeeds, nil
+}
+
+// addSeeds copies the inputs in the source directory to the target
+// directory in Go's corpus format. Inputs which are not already in that
+// format are stored as a single []byte value, so they can only be used
+// by fuzz tests which take a single []byte argument.
+func addSeeds(sourceDir string, targetDir string) error {
+	return errors.WithStack(filepath.WalkDir(sourceDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(string(data), corpusFileHeader) {
+			data = []byte(fmt.Sprintf("%s[]byte(%q)\n", corpusFileHeader, data))
+		}
+		hash := sha1.Sum(data)
+		return os.WriteFile(filepath.Join(targetDir, hex.EncodeToString(hash[:])), data, 0644)
+	}))
+}
+
+func copyInputFile(inputFile string, targetDir string) (string, error) {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	hash := sha1.Sum(data)
+	target := filepath.Join(targetDir, "crash-"+hex.EncodeToString(hash[:]))
+	err = os.WriteFile(target, data, 0644)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return target, nil
+}
+
+func (r *Runner) Cleanup() {
+	if r.cmd != nil {
+		err := r.cmd.TerminateProcessGroup()
+		if err != nil {
+			log.Error(err, err.Error())
+		}
+	}
+}
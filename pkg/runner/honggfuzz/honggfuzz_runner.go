@@ -0,0 +1,354 @@
+package honggfuzz
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/minijail"
+	honggfuzz_parser "code-intelligence.com/cifuzz/pkg/parser/honggfuzz"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	// ExitGracePeriod is the time we give honggfuzz to exit after the
+	// timeout was exceeded.
+	ExitGracePeriod = time.Second * 5
+	// The name of the file in the workspace to which honggfuzz writes
+	// the details of the crashes
+	reportFileName = "HONGGFUZZ.REPORT.TXT"
+)
+
+type RunnerOptions struct {
+	FuzzTarget         string
+	GeneratedCorpusDir string
+	SeedCorpusDirs     []string
+	Dictionary         string
+	LibraryDirs        []string
+	EnvVars            []string
+	EngineArgs         []string
+	FuzzTestArgs       []string
+	ReportHandler      report.Handler
+	Timeout            time.Duration
+	Jobs               int
+	UseMinijail        bool
+	Verbose            bool
+	KeepColor          bool
+	LogOutput          io.Writer
+}
+
+func (options *RunnerOptions) ValidateOptions() error {
+	if runtime.GOOS != "linux" {
+		return errors.New("Honggfuzz is only supported on Linux")
+	}
+
+	if options.UseMinijail {
+		// To be able to make the fuzz target accessible to minijail,
+		// its path must be absolute and all symlinks must be resolved.
+		var err error
+		options.FuzzTarget, err = filepath.EvalSymlinks(options.FuzzTarget)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		options.FuzzTarget, err = filepath.Abs(options.FuzzTarget)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if options.LogOutput == nil {
+		options.LogOutput = os.Stderr
+	}
+
+	if options.Jobs < 0 {
+		return errors.Errorf("Invalid number of jobs: %d", options.Jobs)
+	}
+
+	return nil
+}
+
+// Runner runs honggfuzz on a fuzz test which was built with hfuzz-clang.
+// The status and metrics are parsed from the output of honggfuzz, the
+// crashes from the report file which it writes to its workspace.
+type Runner struct {
+	*RunnerOptions
+
+	cmd *executil.Cmd
+}
+
+func NewRunner(options *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: options}
+}
+
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	honggfuzz, err := runfiles.Finder.HonggfuzzPath()
+	if err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "cifuzz-honggfuzz-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(workDir)
+	// Ensure that symlinks are resolved to be able to add minijail
+	// bindings for the directories.
+	workDir, err = filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	inputDir := filepath.Join(workDir, "input")
+	outputDir := filepath.Join(workDir, "output")
+	workspaceDir := filepath.Join(workDir, "workspace")
+	for _, dir := range []string{inputDir, outputDir, workspaceDir} {
+		err = os.Mkdir(dir, 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// honggfuzz only supports a single input directory, so we copy the
+	// inputs of the generated corpus and the seed corpus directories
+	// into one.
+	numSeeds, err := fuzzer_runner.CopyInputs(append([]string{r.GeneratedCorpusDir}, r.SeedCorpusDirs...), inputDir)
+	if err != nil {
+		return err
+	}
+	err = r.ReportHandler.Handle(&report.Report{Status: report.RunStatus_INITIALIZING, NumSeeds: numSeeds})
+	if err != nil {
+		return err
+	}
+
+	jobs := r.Jobs
+	if jobs == 0 {
+		jobs = 1
+	}
+	args := []string{
+		honggfuzz,
+		"--input", inputDir,
+		"--output", outputDir,
+		"--workspace", workspaceDir,
+		// Print log lines which we can parse instead of the
+		// interactive UI
+		"--verbose",
+		// Exit when the first crash is found, like libFuzzer does
+		"--exit_upon_crash",
+		"--threads", strconv.Itoa(jobs),
+	}
+	if r.Timeout > 0 {
+		// Tell honggfuzz to exit after the timeout
+		args = append(args, "--run_time", strconv.FormatInt(int64(r.Timeout.Seconds()), 10))
+	}
+	if r.Dictionary != "" {
+		args = append(args, "--dict", r.Dictionary)
+	}
+	// Add user-specified honggfuzz options
+	args = append(args, r.EngineArgs...)
+	args = append(args, "--", r.FuzzTarget)
+	args = append(args, r.FuzzTestArgs...)
+
+	fuzzerEnv, err := r.fuzzerEnvironment()
+	if err != nil {
+		return err
+	}
+	wrapperEnv := os.Environ()
+
+	if r.UseMinijail {
+		bindings := []*minijail.Binding{
+			{Source: r.FuzzTarget},
+			{Source: inputDir},
+			{Source: outputDir, Writable: minijail.ReadWrite},
+			{Source: workspaceDir, Writable: minijail.ReadWrite},
+		}
+		if r.Dictionary != "" {
+			bindings = append(bindings, &minijail.Binding{Source: r.Dictionary})
+		}
+		mj, err := minijail.NewMinijail(&minijail.Options{
+			Args:     args,
+			Bindings: bindings,
+			Env:      fuzzerEnv,
+		})
+		if err != nil {
+			return err
+		}
+		defer mj.Cleanup()
+		args = mj.Args
+	} else {
+		for key, value := range envutil.ToMap(fuzzerEnv) {
+			wrapperEnv, err = envutil.Setenv(wrapperEnv, key, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// honggfuzz exits on its own after the timeout, because we
+	// specified --run_time above. For the case that it does not, it's
+	// terminated a bit later.
+	var cmdCtx context.Context
+	var cancelCmdCtx context.CancelFunc
+	if r.Timeout > 0 {
+		cmdCtx, cancelCmdCtx = context.WithTimeout(ctx, r.Timeout+ExitGracePeriod)
+	} else {
+		cmdCtx, cancelCmdCtx = context.WithCancel(ctx)
+	}
+	defer cancelCmdCtx()
+	r.cmd = executil.CommandContext(cmdCtx, args[0], args[1:]...)
+	r.cmd.Env = wrapperEnv
+
+	// honggfuzz writes its log lines to stderr
+	var stderrPipe io.ReadCloser
+	if r.Verbose {
+		ptermWriter := log.NewPTermWriter(r.LogOutput)
+		r.cmd.Stdout = ptermWriter
+		var stderrOutput io.Writer
+		if r.UseMinijail {
+			stderrOutput = minijail.NewOutputFilter(ptermWriter)
+		} else {
+			stderrOutput = ptermWriter
+		}
+		stderrPipe, err = r.cmd.StderrTeePipe(stderrOutput)
+	} else {
+		stderrPipe, err = r.cmd.StderrPipe()
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(r.cmd.Args), " "))
+	err = r.cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Parse the output in a go routine and pass the reports to the
+	// report handler
+	parser := honggfuzz_parser.NewOutputParser(&honggfuzz_parser.Options{KeepColor: r.KeepColor})
+	reportsCh := make(chan *report.Report)
+	parseErrCh := make(chan error, 1)
+	go func() {
+		parseErrCh <- parser.Parse(ctx, stderrPipe, reportsCh)
+	}()
+	for rep := range reportsCh {
+		err = r.ReportHandler.Handle(rep)
+		if err != nil {
+			return err
+		}
+	}
+	err = <-parseErrCh
+	if err != nil {
+		return err
+	}
+
+	waitErr := r.cmd.Wait()
+
+	numFindings, err := r.reportFindings(workspaceDir)
+	if err != nil {
+		return err
+	}
+
+	if waitErr != nil && !r.cmd.TerminatedAfterContextDone() && numFindings == 0 {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return errors.WithStack(waitErr)
+		}
+		return cmdutils.WrapExecError(waitErr, r.cmd.Cmd)
+	}
+
+	// Add the inputs which honggfuzz found to the generated corpus
+	_, err = fuzzer_runner.CopyInputs([]string{outputDir}, r.GeneratedCorpusDir)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reportFindings sends the crashes from the report file in the
+// workspace to the report handler and returns the number of findings.
+func (r *Runner) reportFindings(workspaceDir string) (int, error) {
+	reportFile, err := os.Open(filepath.Join(workspaceDir, reportFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer reportFile.Close()
+
+	findings, err := honggfuzz_parser.ParseReport(reportFile)
+	if err != nil {
+		return 0, err
+	}
+	for _, finding := range findings {
+		if finding.InputFile != "" {
+			finding.InputData, err = os.ReadFile(finding.InputFile)
+			if err != nil {
+				return 0, errors.WithStack(err)
+			}
+			// The names of the crashing inputs written by honggfuzz
+			// are very long and contain the details of the crash, so
+			// we use a simple name instead, like libFuzzer does.
+			hash := sha1.Sum(finding.InputData)
+			inputFile := filepath.Join(workspaceDir, "crash-"+hex.EncodeToString(hash[:]))
+			err = os.Rename(finding.InputFile, inputFile)
+			if err != nil {
+				return 0, errors.WithStack(err)
+			}
+			finding.InputFile = inputFile
+		}
+		err = r.ReportHandler.Handle(&report.Report{Status: report.RunStatus_RUNNING, Finding: finding})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(findings), nil
+}
+
+func (r *Runner) fuzzerEnvironment() ([]string, error) {
+	env, err := fuzzer_runner.FuzzerEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	env, err = fuzzer_runner.SetLDLibraryPath(env, r.LibraryDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the user-specified environment variables. honggfuzz sets
+	// the sanitizer options it needs itself.
+	return fuzzer_runner.AddEnvFlags(env, r.EnvVars)
+}
+
+func (r *Runner) Cleanup() {
+	if r.cmd != nil {
+		err := r.cmd.TerminateProcessGroup()
+		if err != nil {
+			log.Error(err, err.Error())
+		}
+	}
+}
@@ -70,6 +70,15 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
+	// The Jazzer driver is the executable which runs the fuzz test
+	if r.FuzzTarget == "" {
+		r.FuzzTarget = driverPath
+	}
+	err = r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
 	args := []string{driverPath}
 
 	// ----------------------
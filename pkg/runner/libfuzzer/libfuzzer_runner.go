@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -23,6 +24,7 @@ import (
 	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
 	"code-intelligence.com/cifuzz/util/envutil"
 	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/sliceutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
@@ -48,6 +50,7 @@ type RunnerOptions struct {
 	FuzzTestArgs       []string
 	ReportHandler      report.Handler
 	Timeout            time.Duration
+	Jobs               int
 	UseMinijail        bool
 	Verbose            bool
 	KeepColor          bool
@@ -77,6 +80,10 @@ func (options *RunnerOptions) ValidateOptions() error {
 		options.LogOutput = os.Stderr
 	}
 
+	if options.Jobs < 0 {
+		return errors.Errorf("Invalid number of jobs: %d", options.Jobs)
+	}
+
 	return nil
 }
 
@@ -84,7 +91,15 @@ type Runner struct {
 	*RunnerOptions
 	SupportJazzer bool
 
-	cmd *executil.Cmd
+	// Guards cmd and workers, which Cleanup accesses concurrently to
+	// the run, e.g. from a signal handler
+	mutex sync.Mutex
+	cmd   *executil.Cmd
+	// The directory to which a worker writes artifacts, it's set when
+	// the worker is created and not modified afterwards
+	artifactDir string
+	// The runners of the libFuzzer processes if multiple jobs are run
+	workers []*Runner
 }
 
 func NewRunner(options *RunnerOptions) *Runner {
@@ -123,6 +138,294 @@ func (r *Runner) Run(ctx context.Context) error {
 		args = append(args, r.FuzzTestArgs...)
 	}
 
+	bindings := []*minijail.Binding{
+		// The first corpus directory must be writable, because
+		// libfuzzer writes new test inputs to it
+		{Source: r.GeneratedCorpusDir, Writable: minijail.ReadWrite},
+	}
+	for _, dir := range r.SeedCorpusDirs {
+		bindings = append(bindings, &minijail.Binding{Source: dir})
+	}
+
+	if r.Jobs > 1 {
+		return r.runJobs(ctx, args, bindings)
+	}
+
+	return r.runWithBindings(ctx, args, bindings, "")
+}
+
+// runJobs runs the given number of libFuzzer processes in parallel.
+// We don't use libFuzzer's -jobs and -workers flags, because with
+// those, the workers write their output to log files instead of
+// stderr, which we parse. Instead, each worker runs in its own minijail
+// (if that's enabled) with its own artifact directory, its output is
+// parsed by its own parser and the reports of all workers are merged
+// into a single stream of reports for the report handler.
+// When one of the workers exits, for example because it found a crash,
+// the other workers are stopped.
+func (r *Runner) runJobs(ctx context.Context, args []string, bindings []*minijail.Binding) error {
+	aggregator := newMetricsAggregator(r.ReportHandler, r.Jobs)
+
+	var workers []*Runner
+	for i := 0; i < r.Jobs; i++ {
+		artifactDir, err := r.createArtifactDir()
+		if err != nil {
+			return err
+		}
+		defer fileutil.Cleanup(artifactDir)
+
+		workerOpts := *r.RunnerOptions
+		workerOpts.Jobs = 1
+		workerOpts.ReportHandler = aggregator.workerHandler(i)
+		workers = append(workers, &Runner{
+			RunnerOptions: &workerOpts,
+			SupportJazzer: r.SupportJazzer,
+			artifactDir:   artifactDir,
+		})
+	}
+	r.mutex.Lock()
+	r.workers = workers
+	r.mutex.Unlock()
+
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	routines := errgroup.Group{}
+	for _, worker := range workers {
+		worker := worker
+		// Each worker needs its own copy of the arguments and bindings,
+		// because they are modified when the worker is started
+		workerArgs := append([]string{}, args...)
+		workerBindings := append([]*minijail.Binding{}, bindings...)
+		routines.Go(func() error {
+			defer stopWorkers()
+			err := worker.runWithBindings(workersCtx, workerArgs, workerBindings, worker.artifactDir)
+			if errors.Is(err, context.Canceled) && ctx.Err() == nil {
+				// The worker was stopped because another worker exited
+				return nil
+			}
+			return err
+		})
+	}
+
+	return routines.Wait()
+}
+
+// createArtifactDir creates a directory for the artifacts of a worker.
+// If minijail is used, the directory is created in the minijail output
+// directory, which is accessible from inside the jail.
+func (r *Runner) createArtifactDir() (string, error) {
+	parentDir := ""
+	if r.UseMinijail {
+		err := os.MkdirAll(minijail.OutputDir, 0700)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		parentDir = minijail.OutputDir
+	}
+	dir, err := os.MkdirTemp(parentDir, "cifuzz-artifacts-")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return dir, nil
+}
+
+// RunOnInputs executes the fuzz target once on each of the given input
+// files without fuzzing and reports any findings to the report handler.
+func (r *Runner) RunOnInputs(ctx context.Context, inputs []string) error {
+	err := r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	args := []string{r.FuzzTarget}
+
+	// Add user-specified libfuzzer options
+	args = append(args, r.EngineArgs...)
+
+	if !r.UseMinijail {
+		// libFuzzer writes the input which caused a crash to the
+		// current working directory by default. The inputs already
+		// exist, so we let libFuzzer write them to a temporary
+		// directory instead. When minijail is used, the artifacts are
+		// written to the minijail output directory.
+		artifactDir, err := os.MkdirTemp("", "cifuzz-artifacts-")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer fileutil.Cleanup(artifactDir)
+		args = append(args, "-artifact_prefix="+artifactDir+string(filepath.Separator))
+	}
+
+	// libFuzzer executes the inputs passed as positional arguments
+	// instead of fuzzing if they are files
+	args = append(args, inputs...)
+
+	if len(r.FuzzTestArgs) > 0 {
+		// separate the libfuzzer and fuzz test arguments with a "--"
+		args = append(args, "--")
+		args = append(args, r.FuzzTestArgs...)
+	}
+
+	var bindings []*minijail.Binding
+	for _, input := range inputs {
+		bindings = append(bindings, &minijail.Binding{Source: input})
+	}
+
+	return r.runWithBindings(ctx, args, bindings, "")
+}
+
+// RunOnCorpusDirs executes the fuzz target once on each input of the
+// given corpus directories without fuzzing and reports any findings to
+// the report handler. In contrast to RunOnInputs, the number of inputs
+// is not limited by the maximum length of the command line and only
+// the directories have to be made accessible to minijail.
+func (r *Runner) RunOnCorpusDirs(ctx context.Context, dirs []string) error {
+	err := r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	// libFuzzer executes all inputs of the corpus directories during
+	// initialization, -runs=0 makes it exit afterwards instead of
+	// starting to fuzz
+	args := []string{r.FuzzTarget, "-runs=0"}
+
+	// Add user-specified libfuzzer options
+	args = append(args, r.EngineArgs...)
+
+	if !r.UseMinijail {
+		// Don't let libFuzzer write the inputs which caused a crash to
+		// the current working directory, see RunOnInputs
+		artifactDir, err := os.MkdirTemp("", "cifuzz-artifacts-")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer fileutil.Cleanup(artifactDir)
+		args = append(args, "-artifact_prefix="+artifactDir+string(filepath.Separator))
+	}
+
+	args = append(args, dirs...)
+
+	if len(r.FuzzTestArgs) > 0 {
+		// separate the libfuzzer and fuzz test arguments with a "--"
+		args = append(args, "--")
+		args = append(args, r.FuzzTestArgs...)
+	}
+
+	var bindings []*minijail.Binding
+	for _, dir := range dirs {
+		bindings = append(bindings, &minijail.Binding{Source: dir})
+	}
+
+	return r.runWithBindings(ctx, args, bindings, "")
+}
+
+// Merge uses libFuzzer's -merge=1 mode to copy those inputs of the
+// input directories to the output directory which add coverage that's
+// not already provided by the inputs in the output directory.
+// If controlFile is not empty, it's used as libFuzzer's merge control
+// file, which allows resuming an interrupted merge of the same inputs.
+func (r *Runner) Merge(ctx context.Context, outputDir string, inputDirs []string, controlFile string) error {
+	err := r.ValidateOptions()
+	if err != nil {
+		return err
+	}
+
+	args := []string{r.FuzzTarget, "-merge=1"}
+	if controlFile != "" {
+		args = append(args, "-merge_control_file="+controlFile)
+	}
+
+	// Add user-specified libfuzzer options
+	args = append(args, r.EngineArgs...)
+
+	// The first corpus directory is the one the inputs are merged into
+	args = append(args, outputDir)
+	args = append(args, inputDirs...)
+
+	if len(r.FuzzTestArgs) > 0 {
+		// separate the libfuzzer and fuzz test arguments with a "--"
+		args = append(args, "--")
+		args = append(args, r.FuzzTestArgs...)
+	}
+
+	bindings := []*minijail.Binding{
+		{Source: outputDir, Writable: minijail.ReadWrite},
+	}
+	for _, dir := range inputDirs {
+		bindings = append(bindings, &minijail.Binding{Source: dir})
+	}
+	if controlFile != "" {
+		// libFuzzer creates the control file if it doesn't exist yet
+		bindings = append(bindings, &minijail.Binding{Source: filepath.Dir(controlFile), Writable: minijail.ReadWrite})
+	}
+
+	return r.runWithBindings(ctx, args, bindings, "")
+}
+
+// MinimizeCrash uses libFuzzer's -minimize_crash=1 mode to search for a
+// smaller input which still crashes the fuzz target and returns it. If
+// libFuzzer couldn't find a smaller input, nil is returned. The time
+// spent minimizing the input is limited by the timeout of the runner.
+func (r *Runner) MinimizeCrash(ctx context.Context, input string) ([]byte, error) {
+	err := r.ValidateOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	// libFuzzer writes the minimized input to the exact artifact path,
+	// which must be accessible from inside minijail if that's used
+	artifactDir, err := r.createArtifactDir()
+	if err != nil {
+		return nil, err
+	}
+	defer fileutil.Cleanup(artifactDir)
+	minimizedInput := filepath.Join(artifactDir, "minimized-input")
+
+	args := []string{r.FuzzTarget, "-minimize_crash=1"}
+	if r.Timeout > 0 {
+		// Tell libfuzzer to stop minimizing after the timeout
+		timeoutSeconds := strconv.FormatInt(int64(r.Timeout.Seconds()), 10)
+		args = append(args, "-max_total_time="+timeoutSeconds)
+	}
+	args = append(args, "-exact_artifact_path="+minimizedInput)
+
+	// Add user-specified libfuzzer options
+	args = append(args, r.EngineArgs...)
+
+	args = append(args, input)
+
+	if len(r.FuzzTestArgs) > 0 {
+		// separate the libfuzzer and fuzz test arguments with a "--"
+		args = append(args, "--")
+		args = append(args, r.FuzzTestArgs...)
+	}
+
+	bindings := []*minijail.Binding{{Source: input}}
+
+	err = r.runWithBindings(ctx, args, bindings, artifactDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// libFuzzer only writes the exact artifact path if it found a
+	// smaller input which still crashes the fuzz target
+	data, err := os.ReadFile(minimizedInput)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
+
+// runWithBindings runs libfuzzer with the given arguments, via minijail
+// if that's enabled, in which case the specified bindings are added in
+// addition to the fuzz target. libFuzzer writes artifacts to
+// artifactDir. If it's empty, the minijail output directory or the
+// current working directory is used.
+func (r *Runner) runWithBindings(ctx context.Context, args []string, bindings []*minijail.Binding, artifactDir string) error {
 	// The environment to run libfuzzer in
 	fuzzerEnv, err := r.FuzzerEnvironment()
 	if err != nil {
@@ -137,19 +440,13 @@ func (r *Runner) Run(ctx context.Context) error {
 
 		// Make libfuzzer create artifacts (e.g. crash files) in the
 		// minijail output directory.
-		libfuzzerArgs = append(libfuzzerArgs, "-artifact_prefix="+minijail.OutputDir+"/")
-
-		bindings := []*minijail.Binding{
-			// The fuzz target must be accessible
-			{Source: r.FuzzTarget},
-			// The first corpus directory must be writable, because
-			// libfuzzer writes new test inputs to it
-			{Source: r.GeneratedCorpusDir, Writable: minijail.ReadWrite},
+		if artifactDir == "" {
+			artifactDir = minijail.OutputDir
 		}
+		libfuzzerArgs = append(libfuzzerArgs, "-artifact_prefix="+artifactDir+"/")
 
-		for _, dir := range r.SeedCorpusDirs {
-			bindings = append(bindings, &minijail.Binding{Source: dir})
-		}
+		// The fuzz target must be accessible
+		bindings = append([]*minijail.Binding{{Source: r.FuzzTarget}}, bindings...)
 
 		// Set up Minijail
 		mj, err := minijail.NewMinijail(&minijail.Options{
@@ -165,6 +462,10 @@ func (r *Runner) Run(ctx context.Context) error {
 		// Use the command which runs libfuzzer via minijail
 		args = mj.Args
 	} else {
+		if artifactDir != "" {
+			args = append(args, "-artifact_prefix="+artifactDir+string(filepath.Separator))
+		}
+
 		// We don't use minijail, so we can set the environment
 		// variables for the fuzzer in the wrapper environment
 		for key, value := range envutil.ToMap(fuzzerEnv) {
@@ -197,8 +498,8 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 		cmdCtx, cancelCmdCtx = context.WithCancel(ctx)
 	}
 	defer cancelCmdCtx()
-	r.cmd = executil.CommandContext(cmdCtx, args[0], args[1:]...)
-	r.cmd.Env = env
+	cmd := executil.CommandContext(cmdCtx, args[0], args[1:]...)
+	cmd.Env = env
 
 	var stderrPipe io.ReadCloser
 	if r.Verbose {
@@ -209,7 +510,7 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 		// stderr, which is what we want, because we only want reports
 		// printed to stdout.
 		ptermWriter := log.NewPTermWriter(r.LogOutput)
-		r.cmd.Stdout = ptermWriter
+		cmd.Stdout = ptermWriter
 
 		// Write the command's stderr to both a pipe and the pterm
 		// writer which prints it to stderr, so that we can parse the
@@ -222,19 +523,27 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 		} else {
 			stderrOutput = ptermWriter
 		}
-		stderrPipe, err = r.cmd.StderrTeePipe(stderrOutput)
+		stderrPipe, err = cmd.StderrTeePipe(stderrOutput)
 		if err != nil {
 			return err
 		}
 	} else {
-		stderrPipe, err = r.cmd.StderrPipe()
+		stderrPipe, err = cmd.StderrPipe()
 		if err != nil {
 			return err
 		}
 	}
 
-	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(r.cmd.Args), " "))
-	err = r.cmd.Start()
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+	// Only make the command available to Cleanup once it was started,
+	// so that Cleanup never sees a command which is being set up or
+	// which has no process group to terminate
+	r.mutex.Lock()
+	err = cmd.Start()
+	if err == nil {
+		r.cmd = cmd
+	}
+	r.mutex.Unlock()
 	if err != nil {
 		return err
 	}
@@ -262,7 +571,7 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 		// Wait for the command to exit in a go routine, so that below
 		// we can cancel waiting when the context is done
 		go func() {
-			waitErrCh <- r.cmd.Wait()
+			waitErrCh <- cmd.Wait()
 		}()
 
 		// Wait until the reporter has finished parsing stderr, so that
@@ -274,7 +583,7 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 
 		select {
 		case err := <-waitErrCh:
-			if r.cmd.TerminatedAfterContextDone() {
+			if cmd.TerminatedAfterContextDone() {
 				// The command was terminated because the timeout exceeded. We
 				// don't return an error in that case.
 				return nil
@@ -293,7 +602,7 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 				if !r.Verbose {
 					log.Print(startupOutput.String())
 				}
-				return cmdutils.WrapExecError(err, r.cmd.Cmd)
+				return cmdutils.WrapExecError(err, cmd.Cmd)
 			}
 
 			if !reporter.FindingReported {
@@ -359,12 +668,7 @@ func (r *Runner) FuzzerEnvironment() ([]string, error) {
 		return nil, err
 	}
 
-	env, err = fuzzer_runner.SetCommonUBSANOptions(env)
-	if err != nil {
-		return nil, err
-	}
-
-	env, err = fuzzer_runner.SetCommonASANOptions(env)
+	env, err = fuzzer_runner.SetCommonSanitizerOptions(env)
 	if err != nil {
 		return nil, err
 	}
@@ -384,8 +688,18 @@ func (r *Runner) FuzzerEnvironment() ([]string, error) {
 }
 
 func (r *Runner) Cleanup() {
-	if r.cmd != nil {
-		err := r.cmd.TerminateProcessGroup()
+	// Don't hold the lock while terminating the processes, which can
+	// take a few seconds
+	r.mutex.Lock()
+	workers := r.workers
+	cmd := r.cmd
+	r.mutex.Unlock()
+
+	for _, worker := range workers {
+		worker.Cleanup()
+	}
+	if cmd != nil {
+		err := cmd.TerminateProcessGroup()
 		if err != nil {
 			log.Error(err, err.Error())
 		}
@@ -0,0 +1,40 @@
+package libfuzzer
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// Cleanup is called from the signal handler while the runner is still
+// starting or running the command, which must not race (run with -race)
+func TestRunner_CleanupDuringRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test uses the sleep command")
+	}
+
+	r := NewRunner(&RunnerOptions{ReportHandler: &report.FindingCollector{}})
+	done := make(chan error)
+	go func() {
+		done <- r.RunLibfuzzerAndReport(context.Background(), []string{"sleep", "30"}, os.Environ())
+	}()
+
+	deadline := time.After(20 * time.Second)
+	for {
+		r.Cleanup()
+		select {
+		case <-done:
+			return
+		case <-deadline:
+			assert.Fail(t, "The command was not terminated by Cleanup")
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package libfuzzer
+
+import (
+	"sync"
+	"time"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// metricsAggregator merges the reports of multiple libFuzzer workers
+// into a single stream of reports for the report handler. Findings and
+// status reports are passed through, metrics are replaced by the
+// aggregate of the last metrics of all workers.
+type metricsAggregator struct {
+	handler report.Handler
+	// The last metric reported by each worker
+	metrics []*report.FuzzingMetric
+	// Report handlers are not safe for concurrent use, so all reports
+	// are passed to the handler while holding the mutex
+	mutex sync.Mutex
+}
+
+func newMetricsAggregator(handler report.Handler, numWorkers int) *metricsAggregator {
+	return &metricsAggregator{
+		handler: handler,
+		metrics: make([]*report.FuzzingMetric, numWorkers),
+	}
+}
+
+// workerHandler returns the report handler for the worker with the
+// given index.
+func (a *metricsAggregator) workerHandler(worker int) report.Handler {
+	return &workerHandler{aggregator: a, worker: worker}
+}
+
+func (a *metricsAggregator) handle(worker int, r *report.Report) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if r.Metric != nil {
+		a.metrics[worker] = r.Metric
+		aggregated := *r
+		aggregated.Metric = a.aggregate(r.Metric.Timestamp)
+		r = &aggregated
+	}
+	return a.handler.Handle(r)
+}
+
+// aggregate merges the last metrics of all workers. Executions are
+// summed up. The workers share the corpus, so for the coverage the
+// maximum of the workers is used instead. The timestamp is the one of
+// the metric which triggered the aggregation.
+func (a *metricsAggregator) aggregate(timestamp time.Time) *report.FuzzingMetric {
+	aggregated := &report.FuzzingMetric{Timestamp: timestamp}
+	first := true
+	for _, m := range a.metrics {
+		if m == nil {
+			continue
+		}
+		aggregated.ExecutionsPerSecond += m.ExecutionsPerSecond
+		aggregated.TotalExecutions += m.TotalExecutions
+		if m.Features > aggregated.Features {
+			aggregated.Features = m.Features
+		}
+		if m.Edges > aggregated.Edges {
+			aggregated.Edges = m.Edges
+		}
+		if m.CorpusSize > aggregated.CorpusSize {
+			aggregated.CorpusSize = m.CorpusSize
+		}
+		if first || m.SecondsSinceLastFeature < aggregated.SecondsSinceLastFeature {
+			aggregated.SecondsSinceLastFeature = m.SecondsSinceLastFeature
+		}
+		if first || m.SecondsSinceLastEdge < aggregated.SecondsSinceLastEdge {
+			aggregated.SecondsSinceLastEdge = m.SecondsSinceLastEdge
+		}
+		first = false
+	}
+	return aggregated
+}
+
+type workerHandler struct {
+	aggregator *metricsAggregator
+	worker     int
+}
+
+func (h *workerHandler) Handle(r *report.Report) error {
+	return h.aggregator.handle(h.worker, r)
+}
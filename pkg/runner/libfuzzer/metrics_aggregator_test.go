@@ -0,0 +1,71 @@
+package libfuzzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+type recordingHandler struct {
+	reports []*report.Report
+}
+
+func (h *recordingHandler) Handle(r *report.Report) error {
+	h.reports = append(h.reports, r)
+	return nil
+}
+
+func TestMetricsAggregator(t *testing.T) {
+	handler := &recordingHandler{}
+	aggregator := newMetricsAggregator(handler, 2)
+	worker0 := aggregator.workerHandler(0)
+	worker1 := aggregator.workerHandler(1)
+
+	now := time.Now()
+	require.NoError(t, worker0.Handle(&report.Report{
+		Status: report.RunStatus_RUNNING,
+		Metric: &report.FuzzingMetric{
+			Timestamp:               now,
+			ExecutionsPerSecond:     100,
+			TotalExecutions:         1000,
+			Features:                50,
+			Edges:                   20,
+			CorpusSize:              10,
+			SecondsSinceLastFeature: 5,
+			SecondsSinceLastEdge:    7,
+		},
+	}))
+	require.NoError(t, worker1.Handle(&report.Report{
+		Status: report.RunStatus_RUNNING,
+		Metric: &report.FuzzingMetric{
+			Timestamp:               now.Add(time.Second),
+			ExecutionsPerSecond:     200,
+			TotalExecutions:         3000,
+			Features:                40,
+			Edges:                   30,
+			CorpusSize:              12,
+			SecondsSinceLastFeature: 2,
+			SecondsSinceLastEdge:    9,
+		},
+	}))
+	finding := &report.Finding{Details: "heap-buffer-overflow"}
+	require.NoError(t, worker1.Handle(&report.Report{Status: report.RunStatus_RUNNING, Finding: finding}))
+
+	require.Len(t, handler.reports, 3)
+	assert.Equal(t, int32(100), handler.reports[0].Metric.ExecutionsPerSecond)
+	assert.Equal(t, &report.FuzzingMetric{
+		Timestamp:               now.Add(time.Second),
+		ExecutionsPerSecond:     300,
+		TotalExecutions:         4000,
+		Features:                50,
+		Edges:                   30,
+		CorpusSize:              12,
+		SecondsSinceLastFeature: 2,
+		SecondsSinceLastEdge:    7,
+	}, handler.reports[1].Metric)
+	assert.Same(t, finding, handler.reports[2].Finding)
+}
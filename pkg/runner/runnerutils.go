@@ -140,6 +140,71 @@ func SetCommonUBSANOptions(env []string) ([]string, error) {
 	return envutil.Setenv(env, "UBSAN_OPTIONS", options)
 }
 
+func SetCommonMSANOptions(env []string) ([]string, error) {
+	defaultOptions := maps.Clone(defaultSanitizerOptions)
+	overrideOptions := map[string]string{
+		// MSan exits with 77 by default, which is the exit code that
+		// libFuzzer uses when it reports a bug itself, so we use the
+		// same exit code as for the other sanitizers here.
+		"exitcode": strconv.Itoa(SanitizerErrorExitCode),
+	}
+	options := envutil.Getenv(env, "MSAN_OPTIONS")
+	options = SetSanitizerOptions(options, defaultOptions, overrideOptions)
+	return envutil.Setenv(env, "MSAN_OPTIONS", options)
+}
+
+func SetCommonTSANOptions(env []string) ([]string, error) {
+	defaultOptions := maps.Clone(defaultSanitizerOptions)
+	maps.Copy(defaultOptions, map[string]string{
+		// By default, TSan continues after reporting a data race and
+		// only exits with a non-zero exit code when the process exits,
+		// which never happens while fuzzing. Halt on the first report
+		// instead, so that the fuzzer stores the input which triggered
+		// it.
+		"halt_on_error": "1",
+	})
+	overrideOptions := map[string]string{
+		"exitcode": strconv.Itoa(SanitizerErrorExitCode),
+	}
+	options := envutil.Getenv(env, "TSAN_OPTIONS")
+	options = SetSanitizerOptions(options, defaultOptions, overrideOptions)
+	return envutil.Setenv(env, "TSAN_OPTIONS", options)
+}
+
+func SetCommonLSANOptions(env []string) ([]string, error) {
+	// LSAN_OPTIONS is read by the standalone LeakSanitizer as well as
+	// by the leak detection of ASan, so we use the same exit code as
+	// in ASAN_OPTIONS.
+	defaultOptions := maps.Clone(defaultSanitizerOptions)
+	overrideOptions := map[string]string{
+		"exitcode": strconv.Itoa(SanitizerErrorExitCode),
+	}
+	options := envutil.Getenv(env, "LSAN_OPTIONS")
+	options = SetSanitizerOptions(options, defaultOptions, overrideOptions)
+	return envutil.Setenv(env, "LSAN_OPTIONS", options)
+}
+
+// SetCommonSanitizerOptions sets the common options of all sanitizers
+// which are supported by cifuzz. Options of sanitizers which the fuzz
+// test wasn't built with are ignored by the sanitizer runtimes, so it's
+// safe to always set all of them.
+func SetCommonSanitizerOptions(env []string) ([]string, error) {
+	var err error
+	for _, set := range []func([]string) ([]string, error){
+		SetCommonUBSANOptions,
+		SetCommonASANOptions,
+		SetCommonMSANOptions,
+		SetCommonTSANOptions,
+		SetCommonLSANOptions,
+	} {
+		env, err = set(env)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
 func AddEnvFlags(env []string, envVars []string) ([]string, error) {
 	var err error
 	for _, e := range envVars {
@@ -170,6 +235,11 @@ func FuzzerEnvironment() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	// MemorySanitizer reads the path from its own environment variable
+	env, err = envutil.Setenv(env, "MSAN_SYMBOLIZER_PATH", llvmSymbolizer)
+	if err != nil {
+		return nil, err
+	}
 
 	// Tell llvm-symbolizer to strip the build dir from paths, to have
 	// stack traces printed in the logs with relative paths, which are
@@ -1,10 +1,16 @@
 package stubs
 
 import (
+	"bytes"
 	_ "embed"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
 
 	"github.com/pkg/errors"
 
@@ -15,6 +21,12 @@ import (
 //go:embed fuzz-test.cpp.tmpl
 var cppStub []byte
 
+//go:embed fuzz-test.go.tmpl
+var goStubTemplate string
+
+//go:embed fuzz-test.java.tmpl
+var javaStubTemplate string
+
 // Create creates a stub based for the given test type
 func Create(path string, testType config.FuzzTestType) error {
 	exists, err := fileutil.Exists(path)
@@ -30,6 +42,16 @@ func Create(path string, testType config.FuzzTestType) error {
 	switch testType {
 	case config.CPP:
 		content = cppStub
+	case config.GO:
+		content, err = goStub(path)
+		if err != nil {
+			return err
+		}
+	case config.JAVA:
+		content, err = javaStub(path)
+		if err != nil {
+			return err
+		}
 	}
 
 	// write stub
@@ -41,21 +63,158 @@ func Create(path string, testType config.FuzzTestType) error {
 	return nil
 }
 
+// goStub returns the content of a Go fuzz test stub for the given path.
+// The package is taken from the other Go files in the same directory
+// and the name of the fuzz function is derived from the file name.
+func goStub(path string) ([]byte, error) {
+	pkg, err := goPackageName(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New("go_stub").Parse(goStubTemplate)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, struct{ Package, Name string }{pkg, GoFuzzTestName(path)})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GoFuzzTestName derives the name of the fuzz function from the file
+// name of a Go fuzz test, e.g. "FuzzParseHeader" for
+// "parse_header_test.go".
+func GoFuzzTestName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), ".go")
+	base = strings.TrimSuffix(base, "_test")
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	name := "Fuzz"
+	for _, word := range words {
+		runes := []rune(word)
+		name += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+	return name
+}
+
+// goPackageName returns the name of the package of the Go files in the
+// given directory. If there are none, the package is named after the
+// directory.
+func goPackageName(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for _, match := range matches {
+		f, err := parser.ParseFile(token.NewFileSet(), match, nil, parser.PackageClauseOnly)
+		if err != nil {
+			// Ignore files which can't be parsed
+			continue
+		}
+		return f.Name.Name, nil
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(absDir))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "fuzz_" + name
+	}
+	return name, nil
+}
+
+// javaStub returns the content of a Java fuzz test stub for the given
+// path. The class is named after the file and the package is derived
+// from the path relative to the Java source directory.
+func javaStub(path string) ([]byte, error) {
+	t, err := template.New("java_stub").Parse(javaStubTemplate)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, struct{ Package, ClassName string }{javaPackageName(path), javaClassName(path)})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// JavaFuzzTestClass returns the fully qualified name of the class
+// defined in the Java fuzz test, e.g. "com.example.MyFuzzTest" for
+// "src/test/java/com/example/MyFuzzTest.java"
+func JavaFuzzTestClass(path string) string {
+	pkg := javaPackageName(path)
+	if pkg == "" {
+		return javaClassName(path)
+	}
+	return pkg + "." + javaClassName(path)
+}
+
+// javaClassName returns the name of the class defined in the Java file,
+// which must match the file name
+func javaClassName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".java")
+}
+
+// javaPackageName returns the package of a Java file in the Java source
+// directory of a Maven or Gradle project, e.g. "com.example" for
+// "src/test/java/com/example/MyFuzzTest.java". For files outside of a
+// Java source directory, the empty string (i.e. the default package) is
+// returned.
+func javaPackageName(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	dir := filepath.ToSlash(filepath.Dir(absPath))
+	for _, sourceDir := range []string{"/src/test/java", "/src/main/java"} {
+		i := strings.LastIndex(dir+"/", sourceDir+"/")
+		if i == -1 {
+			continue
+		}
+		pkgPath := strings.Trim(dir[i+len(sourceDir):], "/")
+		return strings.ReplaceAll(pkgPath, "/", ".")
+	}
+	return ""
+}
+
 // FuzzTestFilename returns a proposal for a filename,
 // depending on the test type and given directory
 func FuzzTestFilename(testType config.FuzzTestType) (string, error) {
-	var basename, ext, filename string
+	var basename, suffix, filename string
+	separator := "_"
 
 	switch testType {
 	case config.CPP:
-		ext = "cpp"
+		suffix = ".cpp"
 		basename = "my_fuzz_test"
+	case config.GO:
+		// Go only considers files ending in _test.go as tests
+		suffix = "_test.go"
+		basename = "my_fuzz"
+	case config.JAVA:
+		// The file name is the name of the class, so we follow
+		// Java's naming conventions
+		suffix = ".java"
+		basename = "MyFuzzTest"
+		separator = ""
 	default:
 		return "", errors.New("unable to suggest filename: unknown test type")
 	}
 
 	for counter := 1; ; counter++ {
-		filename = filepath.Join(".", fmt.Sprintf("%s_%d.%s", basename, counter, ext))
+		filename = filepath.Join(".", fmt.Sprintf("%s%s%d%s", basename, separator, counter, suffix))
 		exists, err := fileutil.Exists(filename)
 		if err != nil {
 			return "", err
@@ -82,3 +82,68 @@ func TestSuggestFilename(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, filepath.Join(".", "my_fuzz_test_2.cpp"), filename2)
 }
+
+func TestCreate_Go(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, "parser.go"), []byte("package parser\n"), 0644)
+	require.NoError(t, err)
+
+	stubFile := filepath.Join(projectDir, "parse_header_test.go")
+	err = Create(stubFile, config.GO)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(stubFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "package parser\n")
+	assert.Contains(t, string(content), "func FuzzParseHeader(f *testing.F) {")
+}
+
+func TestSuggestFilename_Go(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	err = os.Chdir(projectDir)
+	require.NoError(t, err)
+
+	filename, err := FuzzTestFilename(config.GO)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(".", "my_fuzz_1_test.go"), filename)
+}
+
+func TestCreate_Java(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	pkgDir := filepath.Join(projectDir, "src", "test", "java", "com", "example")
+	err = os.MkdirAll(pkgDir, 0755)
+	require.NoError(t, err)
+
+	stubFile := filepath.Join(pkgDir, "ParserFuzzTest.java")
+	err = Create(stubFile, config.JAVA)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(stubFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "package com.example;\n")
+	assert.Contains(t, string(content), "public class ParserFuzzTest {")
+
+	// Files outside of the source directory are in the default package
+	stubFile = filepath.Join(projectDir, "MyFuzzTest.java")
+	err = Create(stubFile, config.JAVA)
+	require.NoError(t, err)
+
+	content, err = os.ReadFile(stubFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "package ")
+	assert.Contains(t, string(content), "public class MyFuzzTest {")
+}
+
+func TestSuggestFilename_Java(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	err = os.Chdir(projectDir)
+	require.NoError(t, err)
+
+	filename, err := FuzzTestFilename(config.JAVA)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(".", "MyFuzzTest1.java"), filename)
+}
@@ -85,6 +85,31 @@ function(enable_fuzz_testing)
           add_link_options(-fsanitize-link-c++-runtime)
         endif()
       endif()
+    elseif(sanitizer STREQUAL memory)
+      if(MSVC)
+        message(FATAL_ERROR "CIFuzz: MSVC does not support MemorySanitizer")
+      else()
+        add_compile_options(
+            -fsanitize=memory
+            # Report where uninitialized values were created, which is usually more helpful than where they were used.
+            -fsanitize-memory-track-origins
+        )
+        add_link_options(-fsanitize=memory)
+      endif()
+    elseif(sanitizer STREQUAL thread)
+      if(MSVC)
+        message(FATAL_ERROR "CIFuzz: MSVC does not support ThreadSanitizer")
+      else()
+        add_compile_options(-fsanitize=thread)
+        add_link_options(-fsanitize=thread)
+      endif()
+    elseif(sanitizer STREQUAL leak)
+      if(MSVC)
+        message(FATAL_ERROR "CIFuzz: MSVC does not support LeakSanitizer")
+      else()
+        add_compile_options(-fsanitize=leak)
+        add_link_options(-fsanitize=leak)
+      endif()
     elseif(sanitizer STREQUAL coverage)
       if(MSVC)
         message(FATAL_ERROR "CIFuzz: MSVC does not support coverage builds yet")
@@ -155,6 +180,20 @@ function(add_fuzz_test name)
     else()
       message(FATAL_ERROR "CIFuzz: ${CMAKE_CXX_COMPILER_ID} compiler is not supported with the libfuzzer engine")
     endif()
+  elseif(CIFUZZ_ENGINE STREQUAL afl)
+    # The AFL++ compiler wrappers (afl-clang-fast/afl-clang-lto), which cifuzz sets as the compilers, add the coverage
+    # instrumentation themselves. With -fsanitize=fuzzer, they link the AFL++ driver for libFuzzer-style fuzz tests.
+    if(CMAKE_CXX_COMPILER_ID STREQUAL "Clang" OR ((NOT "CXX" IN_LIST _enabled_languages) AND (CMAKE_C_COMPILER_ID STREQUAL "Clang")))
+      target_link_options("${name}" PRIVATE -fsanitize=fuzzer)
+    else()
+      message(FATAL_ERROR "CIFuzz: ${CMAKE_CXX_COMPILER_ID} compiler is not supported with the afl engine")
+    endif()
+  elseif(CIFUZZ_ENGINE STREQUAL honggfuzz)
+    # The honggfuzz compiler wrappers (hfuzz-clang), which cifuzz sets as the compilers, add the coverage instrumentation
+    # and link libhfuzz, which provides a main function calling LLVMFuzzerTestOneInput.
+    if(NOT (CMAKE_CXX_COMPILER_ID STREQUAL "Clang" OR ((NOT "CXX" IN_LIST _enabled_languages) AND (CMAKE_C_COMPILER_ID STREQUAL "Clang"))))
+      message(FATAL_ERROR "CIFuzz: ${CMAKE_CXX_COMPILER_ID} compiler is not supported with the honggfuzz engine")
+    endif()
   else()
     message(FATAL_ERROR "CIFuzz: Unsupported value for CIFUZZ_ENGINE: ${CIFUZZ_ENGINE}")
   endif()
@@ -134,6 +134,38 @@ func TestIntegration_Build_WithMultipleSanitizers(t *testing.T) {
 	})
 }
 
+func TestIntegration_Build_WithMemorySanitizer(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("MSVC does not support MemorySanitizer")
+	}
+	t.Parallel()
+	testutil.RegisterTestDeps("testdata", "cifuzz")
+
+	build(t, cifuzzCmakeBuildType, map[string]string{
+		"CIFUZZ_SANITIZERS": "memory;undefined",
+		"CIFUZZ_TESTING":    "ON",
+	})
+}
+
+func TestIntegration_Build_WithThreadSanitizer(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("MSVC does not support ThreadSanitizer")
+	}
+	t.Parallel()
+	testutil.RegisterTestDeps("testdata", "cifuzz")
+
+	build(t, cifuzzCmakeBuildType, map[string]string{
+		"CIFUZZ_SANITIZERS": "thread",
+		"CIFUZZ_TESTING":    "ON",
+	})
+}
+
 func TestIntegration_Build_LegacyFuzzTests(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -1,6 +1,10 @@
 package fileutil
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,6 +53,21 @@ func Exists(path string) (bool, error) {
 	return !errors.Is(err, os.ErrNotExist), nil
 }
 
+// SHA1 returns the hex-encoded SHA-1 hash of the file's content
+func SHA1(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer file.Close()
+	h := sha1.New()
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // Cleanup removes the specified file or directory and prints any errors
 // to stderr. It's supposed to be used in defer statements to clean up
 // temporary directories.
@@ -100,3 +119,18 @@ func IsBelow(path string, root string) (bool, error) {
 	}
 	return rel != ".." && !strings.HasPrefix(rel, filepath.FromSlash("../")), nil
 }
+
+// PrettifySize returns a human-readable representation of a size in
+// bytes, e.g. "1.5 MiB".
+func PrettifySize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -46,3 +46,23 @@ func TestIsBelow(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, isBelow)
 }
+
+func TestPrettifySize(t *testing.T) {
+	assert.Equal(t, "0 B", fileutil.PrettifySize(0))
+	assert.Equal(t, "1023 B", fileutil.PrettifySize(1023))
+	assert.Equal(t, "1.0 KiB", fileutil.PrettifySize(1024))
+	assert.Equal(t, "1.5 MiB", fileutil.PrettifySize(1024*1024*3/2))
+	assert.Equal(t, "2.0 GiB", fileutil.PrettifySize(2*1024*1024*1024))
+}
+
+func TestSHA1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("bar"), 0644))
+
+	hash, err := fileutil.SHA1(path)
+	require.NoError(t, err)
+	assert.Equal(t, "62cdb7020ff920e5aa642c3d4066950dd1f01f4d", hash)
+
+	_, err = fileutil.SHA1(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}